@@ -0,0 +1,285 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// conversationList is a page of conversations
+type conversationList struct {
+	Conversations []models.Conversation `json:"conversations"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
+
+// conversationSummary is a conversation without its messages
+type conversationSummary struct {
+	*models.Conversation
+	MessageCount int `json:"message_count"`
+}
+
+// messageList is a page of a conversation's messages
+type messageList struct {
+	Messages   []models.Message `json:"messages"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// searchResults is a page of search results
+type searchResults struct {
+	Results    []models.SearchResult `json:"results"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// tagList holds the tags of a conversation, and is the body of tag updates
+type tagList struct {
+	Tags []string `json:"tags"`
+}
+
+func (s *Server) listConversations(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r, 50, 500)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	after, err := decodeCursor(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conversations, err := s.store.ListConversationsPage(filter(r), after, limit+1)
+	if errors.Is(err, storage.ErrStaleCursor) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list conversations: %v", err))
+		return
+	}
+
+	page := conversationList{Conversations: conversations}
+	if len(conversations) > limit {
+		page.Conversations = conversations[:limit]
+		page.NextCursor = encodeCursor(conversations[limit-1].ID)
+	}
+	writeJSON(w, r, http.StatusOK, page)
+}
+
+// conversation loads the conversation named in the path, writing an error
+// response and returning nil if it cannot
+func (s *Server) conversation(w http.ResponseWriter, r *http.Request) *models.Conversation {
+	id, err := parseID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	conv, err := s.store.GetConversation(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("conversation %d not found", id))
+		return nil
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get conversation: %v", err))
+		return nil
+	}
+	return conv
+}
+
+func (s *Server) getConversation(w http.ResponseWriter, r *http.Request) {
+	conv := s.conversation(w, r)
+	if conv == nil {
+		return
+	}
+	count := len(conv.Messages)
+	conv.Messages = nil
+	writeJSON(w, r, http.StatusOK, conversationSummary{Conversation: conv, MessageCount: count})
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseLimit(r, 100, 1000)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := decodeCursor(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	messages, total, err := s.store.GetMessages(id, int(offset), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get messages: %v", err))
+		return
+	}
+	if total == 0 {
+		// Every conversation has messages, so check whether it exists
+		if conv := s.conversation(w, r); conv == nil {
+			return
+		}
+	}
+
+	page := messageList{Messages: messages, Total: total}
+	if next := int(offset) + len(messages); next < total {
+		page.NextCursor = encodeCursor(int64(next))
+	}
+	writeJSON(w, r, http.StatusOK, page)
+}
+
+func (s *Server) exportConversation(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		writeError(w, http.StatusBadRequest, "only JSON format is currently supported")
+		return
+	}
+	conv := s.conversation(w, r)
+	if conv == nil {
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%d.json"`, conv.ID))
+	writeJSON(w, r, http.StatusOK, conv)
+}
+
+func (s *Server) getTags(w http.ResponseWriter, r *http.Request) {
+	conv := s.conversation(w, r)
+	if conv == nil {
+		return
+	}
+	tags := conv.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, r, http.StatusOK, tagList{Tags: tags})
+}
+
+// updateTags applies update to the tags of the conversation in the path and
+// responds with the new tags
+func (s *Server) updateTags(w http.ResponseWriter, r *http.Request, update func(tags []string) []string) {
+	id, err := parseID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tags, err := s.store.UpdateTags(id, update)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("conversation %d not found", id))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update tags: %v", err))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, tagList{Tags: tags})
+}
+
+// readTags reads a tag list from the request body
+func readTags(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	var body tagList
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	tags := make([]string, 0, len(body.Tags))
+	for _, tag := range body.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			writeError(w, http.StatusBadRequest, "tags must not be empty")
+			return nil, false
+		}
+		tags = append(tags, tag)
+	}
+	return tags, true
+}
+
+func (s *Server) setTags(w http.ResponseWriter, r *http.Request) {
+	tags, ok := readTags(w, r)
+	if !ok {
+		return
+	}
+	s.updateTags(w, r, func([]string) []string { return tags })
+}
+
+func (s *Server) addTags(w http.ResponseWriter, r *http.Request) {
+	tags, ok := readTags(w, r)
+	if !ok {
+		return
+	}
+	s.updateTags(w, r, func(current []string) []string { return append(current, tags...) })
+}
+
+func (s *Server) removeTag(w http.ResponseWriter, r *http.Request) {
+	remove := r.PathValue("tag")
+	s.updateTags(w, r, func(current []string) []string {
+		var kept []string
+		for _, tag := range current {
+			if tag != remove {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	})
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.store.ListTags()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list tags: %v", err))
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"tags": tags})
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "the q parameter is required")
+		return
+	}
+	limit, err := parseLimit(r, 20, 200)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := decodeCursor(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := s.searcher.SearchPage(query, filter(r), int(offset), limit+1)
+	if err != nil {
+		// Mostly malformed FTS5 syntax
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("search failed: %v", err))
+		return
+	}
+
+	page := searchResults{Results: results}
+	if page.Results == nil {
+		page.Results = []models.SearchResult{}
+	}
+	if len(results) > limit {
+		page.Results = results[:limit]
+		page.NextCursor = encodeCursor(offset + int64(limit))
+	}
+	writeJSON(w, r, http.StatusOK, page)
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.store.GetStats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get statistics: %v", err))
+		return
+	}
+	stats.EstimatedCost = float64(stats.TotalTokens) * s.usdPerMillionTokens / 1e6
+	writeJSON(w, r, http.StatusOK, stats)
+}
\ No newline at end of file
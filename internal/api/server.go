@@ -0,0 +1,197 @@
+// Package api serves conversations over a local HTTP/JSON API, for editor
+// plugins and scripts that would otherwise parse mem's terminal output.
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jasperwreed/ai-memory/internal/search"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// maxBodySize is the largest request body accepted
+const maxBodySize = 1 << 20
+
+// Server answers API requests from a store
+type Server struct {
+	store               *storage.SQLiteStore
+	searcher            *search.Searcher
+	usdPerMillionTokens float64
+}
+
+// NewServer creates an API server for store. Token costs in stats are
+// estimated at usdPerMillionTokens.
+func NewServer(store *storage.SQLiteStore, usdPerMillionTokens float64) *Server {
+	return &Server{
+		store:               store,
+		searcher:            search.NewSearcher(store),
+		usdPerMillionTokens: usdPerMillionTokens,
+	}
+}
+
+// Handler returns the API's routes, all under /api/
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/conversations", s.listConversations)
+	mux.HandleFunc("GET /api/conversations/{id}", s.getConversation)
+	mux.HandleFunc("GET /api/conversations/{id}/messages", s.listMessages)
+	mux.HandleFunc("GET /api/conversations/{id}/export", s.exportConversation)
+	mux.HandleFunc("GET /api/conversations/{id}/tags", s.getTags)
+	mux.HandleFunc("PUT /api/conversations/{id}/tags", s.setTags)
+	mux.HandleFunc("POST /api/conversations/{id}/tags", s.addTags)
+	mux.HandleFunc("DELETE /api/conversations/{id}/tags/{tag}", s.removeTag)
+	mux.HandleFunc("GET /api/tags", s.listTags)
+	mux.HandleFunc("GET /api/search", s.search)
+	mux.HandleFunc("GET /api/stats", s.stats)
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint: "+r.Method+" "+r.URL.Path)
+	})
+	return mux
+}
+
+// LocalOnly rejects requests whose Host header does not name the loopback
+// interface, so a web page cannot reach a server on localhost through a DNS
+// name it controls
+func LocalOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if host != "localhost" {
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				writeError(w, http.StatusForbidden, "host not allowed: "+r.Host)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(apiError{Error: message})
+}
+
+// writeJSON writes v as the response. Successful GET responses carry an ETag
+// of the body, and a request whose If-None-Match lists it gets 304 Not
+// Modified instead.
+func writeJSON(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to encode response: %v", err))
+		return
+	}
+	body = append(body, '\n')
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodGet && code == http.StatusOK {
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// readJSON decodes a JSON request body into v
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return errors.New("request body must be application/json")
+	}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// encodeCursor makes an opaque cursor for the next page
+func encodeCursor(n int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(n, 10)))
+}
+
+// decodeCursor reads the cursor parameter; no cursor is 0
+func decodeCursor(r *http.Request) (int64, error) {
+	cursor := r.URL.Query().Get("cursor")
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return n, nil
+}
+
+// parseLimit reads the limit parameter, which defaults to fallback and may
+// not exceed max
+func parseLimit(r *http.Request, fallback, max int) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > max {
+		return 0, fmt.Errorf("limit must be between 1 and %d", max)
+	}
+	return limit, nil
+}
+
+// parseID reads the conversation ID from the path
+func parseID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid conversation ID %q", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+// filter reads the tool, project and tag parameters
+func filter(r *http.Request) storage.ConversationFilter {
+	query := r.URL.Query()
+	return storage.ConversationFilter{
+		Tool:    query.Get("tool"),
+		Project: query.Get("project"),
+		Tag:     query.Get("tag"),
+	}
+}
\ No newline at end of file
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, tool := range []string{"claude-code", "aider", "claude-code"} {
+		created := base.Add(time.Duration(i) * time.Hour)
+		conv := &models.Conversation{
+			Title:     "Conversation about migrations",
+			Tool:      tool,
+			Project:   "api",
+			Tags:      []string{"db"},
+			CreatedAt: created,
+			UpdatedAt: created,
+		}
+		for j := 0; j < 5; j++ {
+			conv.Messages = append(conv.Messages, models.Message{
+				Role:      "user",
+				Content:   "How do I run the database migration?",
+				Timestamp: created.Add(time.Duration(j) * time.Minute),
+			})
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewServer(store, 3).Handler()
+}
+
+// do sends a request and decodes a JSON response into v
+func do(t *testing.T, h http.Handler, method, target, body string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s returned invalid JSON: %v\n%s", method, target, err, rec.Body)
+		}
+	}
+	return rec
+}
+
+func TestListPagination(t *testing.T) {
+	h := newTestServer(t)
+
+	var ids []int64
+	target := "/api/conversations?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("Pagination did not end")
+		}
+		var page conversationList
+		if rec := do(t, h, "GET", target, "", &page); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		for _, conv := range page.Conversations {
+			ids = append(ids, conv.ID)
+		}
+		target = ""
+		if page.NextCursor != "" {
+			target = "/api/conversations?limit=2&cursor=" + page.NextCursor
+		}
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[2] != 1 {
+		t.Errorf("Expected conversations 3, 2, 1, got %v", ids)
+	}
+
+	var filtered conversationList
+	do(t, h, "GET", "/api/conversations?tool=aider&tag=db", "", &filtered)
+	if len(filtered.Conversations) != 1 || filtered.Conversations[0].Tool != "aider" {
+		t.Errorf("Expected the aider conversation, got %+v", filtered.Conversations)
+	}
+
+	if rec := do(t, h, "GET", "/api/conversations?cursor=!!", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad cursor, got %d", rec.Code)
+	}
+}
+
+func TestConversationAndMessages(t *testing.T) {
+	h := newTestServer(t)
+
+	var conv conversationSummary
+	do(t, h, "GET", "/api/conversations/1", "", &conv)
+	if conv.MessageCount != 5 || conv.Messages != nil {
+		t.Errorf("Expected a message count without messages, got %+v", conv)
+	}
+
+	var page messageList
+	do(t, h, "GET", "/api/conversations/1/messages?limit=3", "", &page)
+	if len(page.Messages) != 3 || page.Total != 5 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page %+v", page)
+	}
+	var rest messageList
+	do(t, h, "GET", "/api/conversations/1/messages?limit=3&cursor="+page.NextCursor, "", &rest)
+	if len(rest.Messages) != 2 || rest.NextCursor != "" || rest.Messages[0].ID != page.Messages[2].ID+1 {
+		t.Errorf("Unexpected last page %+v", rest)
+	}
+
+	for _, target := range []string{"/api/conversations/99", "/api/conversations/99/messages", "/api/nope"} {
+		if rec := do(t, h, "GET", target, "", nil); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", target, rec.Code)
+		}
+	}
+
+	rec := do(t, h, "GET", "/api/conversations/1/export", "", nil)
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "conversation-1.json") ||
+		!strings.Contains(rec.Body.String(), `"messages"`) {
+		t.Errorf("Expected a JSON download with messages, got %v\n%s", rec.Header(), rec.Body)
+	}
+}
+
+func TestETag(t *testing.T) {
+	h := newTestServer(t)
+
+	rec := do(t, h, "GET", "/api/conversations/1", "", nil)
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag")
+	}
+
+	req := httptest.NewRequest("GET", "/api/conversations/1", nil)
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected 304 with no body, got %d", rec.Code)
+	}
+
+	// Changing the conversation changes its ETag
+	do(t, h, "POST", "/api/conversations/1/tags", `{"tags":["new"]}`, nil)
+	if rec := do(t, h, "GET", "/api/conversations/1", "", nil); rec.Header().Get("ETag") == etag {
+		t.Error("Expected a new ETag after the tags changed")
+	}
+}
+
+func TestTags(t *testing.T) {
+	h := newTestServer(t)
+
+	var tags tagList
+	do(t, h, "POST", "/api/conversations/1/tags", `{"tags":["review"," db "]}`, &tags)
+	if strings.Join(tags.Tags, ",") != "db,review" {
+		t.Errorf("Expected tags db,review, got %v", tags.Tags)
+	}
+	do(t, h, "DELETE", "/api/conversations/1/tags/db", "", &tags)
+	if strings.Join(tags.Tags, ",") != "review" {
+		t.Errorf("Expected tag review, got %v", tags.Tags)
+	}
+	do(t, h, "PUT", "/api/conversations/1/tags", `{"tags":["a","b"]}`, &tags)
+	if strings.Join(tags.Tags, ",") != "a,b" {
+		t.Errorf("Expected tags a,b, got %v", tags.Tags)
+	}
+
+	var counts struct{ Tags []storage.TagCount }
+	do(t, h, "GET", "/api/tags", "", &counts)
+	if len(counts.Tags) != 3 || counts.Tags[2] != (storage.TagCount{Name: "db", Count: 2}) {
+		t.Errorf("Unexpected tag counts %+v", counts.Tags)
+	}
+
+	if rec := do(t, h, "PUT", "/api/conversations/99/tags", `{"tags":[]}`, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", "/api/conversations/1/tags", `{"tags":[""]}`, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an empty tag to be rejected, got %d", rec.Code)
+	}
+
+	// Form posts, which a web page can send without asking, are refused
+	req := httptest.NewRequest("POST", "/api/conversations/1/tags", strings.NewReader(`{"tags":["x"]}`))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a text/plain body to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestSearchAndStats(t *testing.T) {
+	h := newTestServer(t)
+
+	var results searchResults
+	do(t, h, "GET", "/api/search?q=migration&tool=claude-code&limit=100", "", &results)
+	for _, r := range results.Results {
+		if r.Conversation.Tool != "claude-code" {
+			t.Errorf("Expected only claude-code results, got %s", r.Conversation.Tool)
+		}
+	}
+	if len(results.Results) == 0 {
+		t.Fatal("Expected results")
+	}
+
+	var first, second searchResults
+	do(t, h, "GET", "/api/search?q=migration&limit=1", "", &first)
+	do(t, h, "GET", "/api/search?q=migration&limit=1&cursor="+first.NextCursor, "", &second)
+	if len(second.Results) != 1 || first.NextCursor == "" {
+		t.Errorf("Expected a second page, got %+v", second)
+	}
+
+	if rec := do(t, h, "GET", "/api/search", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a query, got %d", rec.Code)
+	}
+
+	var stats models.ConversationStats
+	do(t, h, "GET", "/api/stats", "", &stats)
+	if stats.TotalConversations != 3 || stats.ToolBreakdown["claude-code"] != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestLocalOnly(t *testing.T) {
+	h := LocalOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for host, want := range map[string]int{
+		"localhost:7777":    http.StatusOK,
+		"127.0.0.1:7777":    http.StatusOK,
+		"[::1]:7777":        http.StatusOK,
+		"evil.example:7777": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/api/stats", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Host %s: expected %d, got %d", host, want, rec.Code)
+		}
+	}
+}
+func TestUI(t *testing.T) {
+	h := UI()
+
+	for target, contentType := range map[string]string{
+		"/":          "text/html",
+		"/app.js":    "text/javascript",
+		"/style.css": "text/css",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), contentType) {
+			t.Errorf("%s: expected %s, got %d %s", target, contentType, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rec.Header().Get("Content-Security-Policy"), "script-src 'self'") {
+			t.Errorf("%s: expected a content security policy", target)
+		}
+	}
+
+	// Everything the page loads is served locally
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(rec.Body.String(), "http") {
+		t.Error("Expected the page to load nothing from the network")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,397 @@
+// AI Memory web UI: a read-only browser over the /api endpoints of mem serve.
+// State lives in the URL hash (q, tool, project, tag, c) so views can be
+// bookmarked and shared.
+"use strict";
+
+const LIST_PAGE = 50;
+const SEARCH_PAGE = 30;
+const MESSAGE_PAGE = 200;
+
+const $ = (id) => document.getElementById(id);
+
+let state = {};
+let listCursor = "";
+let listSeen = new Set();
+let listRequest = 0;
+let conversationRequest = 0;
+
+// api fetches a JSON endpoint, throwing the API's error message on failure
+async function api(path, params = {}) {
+  const query = new URLSearchParams();
+  for (const [key, value] of Object.entries(params)) {
+    if (value) query.set(key, value);
+  }
+  const url = "api/" + path + (query.toString() ? "?" + query : "");
+  const response = await fetch(url, { headers: { Accept: "application/json" } });
+  const body = await response.json().catch(() => ({}));
+  if (!response.ok) throw new Error(body.error || response.statusText);
+  return body;
+}
+
+function escapeHTML(text) {
+  return String(text).replace(/[&<>"']/g, (c) =>
+    ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" })[c]);
+}
+
+function formatDate(value) {
+  const date = new Date(value);
+  return isNaN(date) ? "" : date.toLocaleString();
+}
+
+// Markdown
+
+// renderInline renders code spans, emphasis and links in one line. Text is
+// escaped first, so message content can never inject markup.
+function renderInline(raw) {
+  const codes = [];
+  let html = raw.replace(/`([^`\n]+)`/g, (_, code) => {
+    codes.push(code);
+    return "\u0000" + (codes.length - 1) + "\u0000";
+  });
+  html = escapeHTML(html)
+    .replace(/\*\*([^*\n]+)\*\*/g, "<strong>$1</strong>")
+    .replace(/(^|[^*\w])\*([^*\s][^*\n]*)\*(?!\w)/g, "$1<em>$2</em>")
+    .replace(/\[([^\]\n]+)\]\((https?:\/\/[^\s)]+)\)/g,
+      '<a href="$2" target="_blank" rel="noopener noreferrer">$1</a>');
+  return html.replace(/\u0000(\d+)\u0000/g, (_, i) => "<code>" + escapeHTML(codes[i]) + "</code>");
+}
+
+const FENCE = /^\s*(`{3,}|~{3,})\s*([\w+#.-]*)/;
+const HEADING = /^(#{1,6})\s+(.*)$/;
+const LIST_ITEM = /^\s*([-*+]|\d+[.)])\s+/;
+const QUOTE = /^\s*>\s?/;
+const RULE = /^\s*([-*_])(\s*\1){2,}\s*$/;
+
+function startsBlock(line) {
+  return FENCE.test(line) || HEADING.test(line) || LIST_ITEM.test(line) ||
+    QUOTE.test(line) || RULE.test(line);
+}
+
+// renderMarkdown renders the common subset of Markdown that agents write:
+// fenced code, headings, lists, quotes, rules and paragraphs
+function renderMarkdown(text) {
+  const lines = String(text).replace(/\r\n/g, "\n").split("\n");
+  let html = "";
+  let i = 0;
+  while (i < lines.length) {
+    const line = lines[i];
+
+    const fence = line.match(FENCE);
+    if (fence) {
+      const code = [];
+      for (i++; i < lines.length && !lines[i].trim().startsWith(fence[1]); i++) {
+        code.push(lines[i]);
+      }
+      i++;
+      const lang = fence[2] ? ` data-lang="${escapeHTML(fence[2])}"` : "";
+      html += `<pre${lang}><code>${escapeHTML(code.join("\n"))}</code></pre>`;
+      continue;
+    }
+
+    if (line.trim() === "") {
+      i++;
+      continue;
+    }
+
+    if (RULE.test(line)) {
+      html += "<hr>";
+      i++;
+      continue;
+    }
+
+    const heading = line.match(HEADING);
+    if (heading) {
+      // Keep message headings below the conversation title
+      const level = Math.min(heading[1].length + 2, 6);
+      html += `<h${level}>${renderInline(heading[2])}</h${level}>`;
+      i++;
+      continue;
+    }
+
+    if (LIST_ITEM.test(line)) {
+      const ordered = /^\s*\d/.test(line);
+      const items = [];
+      for (; i < lines.length && LIST_ITEM.test(lines[i]) && /^\s*\d/.test(lines[i]) === ordered; i++) {
+        items.push("<li>" + renderInline(lines[i].replace(LIST_ITEM, "")) + "</li>");
+      }
+      const tag = ordered ? "ol" : "ul";
+      html += `<${tag}>${items.join("")}</${tag}>`;
+      continue;
+    }
+
+    if (QUOTE.test(line)) {
+      const quoted = [];
+      for (; i < lines.length && QUOTE.test(lines[i]); i++) {
+        quoted.push(lines[i].replace(QUOTE, ""));
+      }
+      html += `<blockquote>${renderMarkdown(quoted.join("\n"))}</blockquote>`;
+      continue;
+    }
+
+    const paragraph = [];
+    for (; i < lines.length && lines[i].trim() !== "" && (paragraph.length === 0 || !startsBlock(lines[i])); i++) {
+      paragraph.push(renderInline(lines[i]));
+    }
+    html += `<p>${paragraph.join("<br>")}</p>`;
+  }
+  return html;
+}
+
+// Highlighting
+
+// searchTerms returns the words of an FTS5 query, without its syntax
+function searchTerms(query) {
+  return (query || "").split(/\s+/)
+    .map((word) => word.replace(/^["()*^+:-]+|["()*^+:-]+$/g, ""))
+    .filter((word) => word && !["AND", "OR", "NOT", "NEAR"].includes(word));
+}
+
+function termPattern(query) {
+  const terms = searchTerms(query);
+  if (terms.length === 0) return null;
+  const escaped = terms.map((t) => t.replace(/[.*+?^${}()|[\]\\]/g, "\\$&"));
+  return new RegExp("(" + escaped.join("|") + ")", "gi");
+}
+
+// highlight wraps matches of the query's terms in the text under root in <mark>
+function highlight(root, query) {
+  const pattern = termPattern(query);
+  if (!pattern) return;
+
+  const walker = document.createTreeWalker(root, NodeFilter.SHOW_TEXT);
+  const nodes = [];
+  while (walker.nextNode()) nodes.push(walker.currentNode);
+
+  for (const node of nodes) {
+    const text = node.nodeValue;
+    pattern.lastIndex = 0;
+    if (!pattern.test(text)) continue;
+
+    const fragment = document.createDocumentFragment();
+    let last = 0;
+    text.replace(pattern, (match, _, offset) => {
+      fragment.append(text.slice(last, offset));
+      const mark = document.createElement("mark");
+      mark.textContent = match;
+      fragment.append(mark);
+      last = offset + match.length;
+    });
+    fragment.append(text.slice(last));
+    node.replaceWith(fragment);
+  }
+}
+
+// State
+
+function readState() {
+  const params = new URLSearchParams(location.hash.slice(1));
+  return {
+    q: params.get("q") || "",
+    tool: params.get("tool") || "",
+    project: params.get("project") || "",
+    tag: params.get("tag") || "",
+    c: params.get("c") || "",
+  };
+}
+
+function stateHash(next) {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(next)) {
+    if (value) params.set(key, value);
+  }
+  return "#" + params.toString();
+}
+
+function sameList(a, b) {
+  return a.q === b.q && a.tool === b.tool && a.project === b.project && a.tag === b.tag;
+}
+
+function applyState() {
+  const next = readState();
+  const listChanged = !sameList(state, next);
+  const conversationChanged = state.c !== next.c || (next.c && state.q !== next.q);
+  state = next;
+
+  if ($("query").value.trim() !== state.q) $("query").value = state.q;
+  for (const key of ["tool", "project", "tag"]) {
+    ensureOption($(key), state[key]);
+    $(key).value = state[key];
+  }
+
+  if (listChanged) loadList(true);
+  else markSelected();
+  if (conversationChanged) loadConversation();
+}
+
+// Filters
+
+function ensureOption(select, value) {
+  if (!value || [...select.options].some((o) => o.value === value)) return;
+  select.add(new Option(value, value));
+}
+
+function fillOptions(select, names) {
+  for (const name of names.filter(Boolean).sort((a, b) => a.localeCompare(b))) {
+    ensureOption(select, name);
+  }
+}
+
+async function loadFilters() {
+  try {
+    const [stats, tags] = await Promise.all([api("stats"), api("tags")]);
+    fillOptions($("tool"), Object.keys(stats.tool_breakdown || {}));
+    fillOptions($("project"), Object.keys(stats.project_breakdown || {}));
+    fillOptions($("tag"), tags.tags.map((t) => t.name));
+    for (const key of ["tool", "project", "tag"]) $(key).value = state[key];
+  } catch (err) {
+    $("list-status").textContent = "Failed to load filters: " + err.message;
+  }
+}
+
+// Conversation list
+
+function itemHTML(conv, snippet) {
+  const tags = (conv.tags || []).map((t) => `<span class="tag">${escapeHTML(t)}</span>`).join("");
+  const project = conv.project ? " · " + escapeHTML(conv.project) : "";
+  const hash = stateHash({ ...state, c: String(conv.id) });
+  return `<a href="${escapeHTML(hash)}" data-id="${conv.id}">
+    <div class="title">${escapeHTML(conv.title || "Untitled")}</div>
+    <div class="meta">${escapeHTML(conv.tool)}${project} · ${escapeHTML(formatDate(conv.created_at))}${tags}</div>
+    ${snippet ? `<div class="snippet">${escapeHTML(snippet)}</div>` : ""}
+  </a>`;
+}
+
+async function loadList(reset) {
+  const request = ++listRequest;
+  if (reset) {
+    listCursor = "";
+    listSeen = new Set();
+    $("items").innerHTML = "";
+  }
+  $("more").hidden = true;
+  $("list-status").textContent = "Loading…";
+
+  const filters = { tool: state.tool, project: state.project, tag: state.tag, cursor: listCursor };
+  let page;
+  try {
+    page = state.q
+      ? await api("search", { q: state.q, limit: SEARCH_PAGE, ...filters })
+      : await api("conversations", { limit: LIST_PAGE, ...filters });
+  } catch (err) {
+    if (request === listRequest) $("list-status").textContent = err.message;
+    return;
+  }
+  if (request !== listRequest) return;
+
+  // A search returns one result per matching message; show each conversation once
+  const entries = state.q
+    ? page.results.map((r) => [r.conversation, r.snippet])
+    : page.conversations.map((c) => [c, ""]);
+  for (const [conv, snippet] of entries) {
+    if (listSeen.has(conv.id)) continue;
+    listSeen.add(conv.id);
+    const item = document.createElement("li");
+    item.innerHTML = itemHTML(conv, snippet);
+    for (const part of item.querySelectorAll(".title, .snippet")) highlight(part, state.q);
+    $("items").append(item);
+  }
+
+  listCursor = page.next_cursor || "";
+  $("more").hidden = !listCursor;
+  $("list-status").textContent = listSeen.size === 0
+    ? (state.q ? "No results found." : "No conversations yet.")
+    : "";
+  markSelected();
+}
+
+function markSelected() {
+  for (const link of document.querySelectorAll("#items a")) {
+    link.classList.toggle("selected", link.dataset.id === state.c);
+    link.href = stateHash({ ...state, c: link.dataset.id });
+  }
+}
+
+// Conversation view
+
+function messageElement(message) {
+  const role = String(message.role || "");
+  const element = document.createElement("section");
+  element.className = "message " + role.toLowerCase().replace(/[^a-z]/g, "");
+  element.innerHTML = `<div class="meta"><span class="role">${escapeHTML(role)}</span>
+    ${message.timestamp ? " · " + escapeHTML(formatDate(message.timestamp)) : ""}</div>
+    <div class="body">${renderMarkdown(message.content)}</div>`;
+  highlight(element.querySelector(".body"), state.q);
+  return element;
+}
+
+async function loadMessages(id, cursor, view, request) {
+  const page = await api(`conversations/${id}/messages`, { limit: MESSAGE_PAGE, cursor });
+  if (request !== conversationRequest) return;
+
+  for (const message of page.messages) view.append(messageElement(message));
+  if (page.next_cursor) {
+    const more = document.createElement("button");
+    more.type = "button";
+    more.textContent = `Load more messages (${page.total - view.querySelectorAll(".message").length} left)`;
+    more.onclick = () => {
+      more.remove();
+      loadMessages(id, page.next_cursor, view, request).catch(showConversationError);
+    };
+    view.append(more);
+  }
+}
+
+function showConversationError(err) {
+  $("conversation").innerHTML = `<p class="status">${escapeHTML(err.message)}</p>`;
+}
+
+async function loadConversation() {
+  const request = ++conversationRequest;
+  const view = $("conversation");
+  if (!state.c) {
+    view.innerHTML = '<p class="status">Select a conversation</p>';
+    return;
+  }
+  view.innerHTML = '<p class="status">Loading…</p>';
+
+  try {
+    const conv = await api("conversations/" + encodeURIComponent(state.c));
+    if (request !== conversationRequest) return;
+
+    const tags = (conv.tags || []).map((t) => `<span class="tag">${escapeHTML(t)}</span>`).join("");
+    const details = [conv.tool, conv.project, formatDate(conv.created_at), `${conv.message_count} messages`]
+      .filter(Boolean).map(escapeHTML).join(" · ");
+    view.innerHTML = `<h1>${escapeHTML(conv.title || "Untitled")}</h1>
+      <div class="meta">${details}${tags}</div>`;
+    view.scrollTop = 0;
+    await loadMessages(conv.id, "", view, request);
+  } catch (err) {
+    if (request === conversationRequest) showConversationError(err);
+  }
+}
+
+// Events
+
+let typing;
+$("query").addEventListener("input", () => {
+  clearTimeout(typing);
+  typing = setTimeout(() => {
+    history.replaceState(null, "", stateHash({ ...state, q: $("query").value.trim() }));
+    applyState();
+  }, 250);
+});
+
+$("search").addEventListener("submit", (event) => event.preventDefault());
+
+for (const key of ["tool", "project", "tag"]) {
+  $(key).addEventListener("change", () => {
+    location.hash = stateHash({ ...state, [key]: $(key).value });
+  });
+}
+
+$("more").addEventListener("click", () => loadList(false));
+window.addEventListener("hashchange", applyState);
+
+state = { q: null };
+applyState();
+loadFilters();
\ No newline at end of file
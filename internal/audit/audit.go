@@ -3,8 +3,8 @@ package audit
 import (
 	"bufio"
 	"compress/gzip"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -20,6 +20,11 @@ type AuditLogger struct {
 	rotationMutex  sync.Mutex
 	flushInterval  time.Duration
 	compressShards bool
+	recipients     []*Recipient
+	index          *Index
+	pendingIndex   map[string]*indexEntry
+	bytesWritten   int64 // guarded by rotationMutex
+	rotations      int64 // guarded by rotationMutex
 }
 
 // ShardWriter represents a single audit shard file
@@ -27,6 +32,7 @@ type ShardWriter struct {
 	file       *os.File
 	writer     *bufio.Writer
 	gzWriter   *gzip.Writer
+	encWriter  *encryptWriter
 	size       int64
 	path       string
 	startTime  time.Time
@@ -45,6 +51,13 @@ type ShardInfo struct {
 
 // NewAuditLogger creates a new audit logger
 func NewAuditLogger(baseDir string, maxShardSize int64, compress bool) (*AuditLogger, error) {
+	return NewEncryptedAuditLogger(baseDir, maxShardSize, compress, nil)
+}
+
+// NewEncryptedAuditLogger creates an audit logger whose shards are encrypted to
+// recipients. The logger only needs public keys, so it cannot read its own
+// output. With no recipients, shards are written in the clear.
+func NewEncryptedAuditLogger(baseDir string, maxShardSize int64, compress bool, recipients []*Recipient) (*AuditLogger, error) {
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create audit directory: %w", err)
 	}
@@ -54,6 +67,15 @@ func NewAuditLogger(baseDir string, maxShardSize int64, compress bool) (*AuditLo
 		maxShardSize:   maxShardSize,
 		flushInterval:  5 * time.Second,
 		compressShards: compress,
+		recipients:     recipients,
+		pendingIndex:   make(map[string]*indexEntry),
+	}
+
+	// Open the sidecar index; capture still works without it, lookups just scan
+	if index, err := OpenIndex(baseDir); err == nil {
+		logger.index = index
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: audit index unavailable: %v\n", err)
 	}
 
 	// Create initial shard
@@ -67,8 +89,9 @@ func NewAuditLogger(baseDir string, maxShardSize int64, compress bool) (*AuditLo
 	return logger, nil
 }
 
-// WriteRawLine writes a raw JSON line to the current shard
-func (a *AuditLogger) WriteRawLine(line []byte) error {
+// writeLine writes a single line to the current shard, rotating first if
+// needed. When rec is set, the line's position is added to the index.
+func (a *AuditLogger) writeLine(line []byte, rec *Record) error {
 	a.rotationMutex.Lock()
 	defer a.rotationMutex.Unlock()
 
@@ -77,6 +100,7 @@ func (a *AuditLogger) WriteRawLine(line []byte) error {
 		if err := a.rotateShard(); err != nil {
 			return fmt.Errorf("failed to rotate shard: %w", err)
 		}
+		a.rotations++
 	}
 
 	// Write line with newline if not present
@@ -84,6 +108,8 @@ func (a *AuditLogger) WriteRawLine(line []byte) error {
 		line = append(line, '\n')
 	}
 
+	start := a.currentShard.size
+
 	var n int
 	var err error
 	if a.currentShard.compressed {
@@ -97,42 +123,57 @@ func (a *AuditLogger) WriteRawLine(line []byte) error {
 	}
 
 	a.currentShard.size += int64(n)
+	a.bytesWritten += int64(n)
+
+	if rec != nil && a.index != nil {
+		entry, ok := a.pendingIndex[rec.SessionID]
+		if !ok {
+			entry = &indexEntry{sessionID: rec.SessionID}
+			a.pendingIndex[rec.SessionID] = entry
+		}
+		entry.record(rec, start, a.currentShard.size)
+	}
+
 	return nil
 }
 
-// WriteEvent writes a structured event to the audit log
-func (a *AuditLogger) WriteEvent(event map[string]interface{}) error {
-	// Add audit metadata
-	event["_audit_timestamp"] = time.Now().Unix()
-	event["_audit_shard"] = filepath.Base(a.currentShard.path)
-
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+// commitIndex writes pending index entries for the current shard. The caller
+// must hold rotationMutex.
+func (a *AuditLogger) commitIndex(complete bool) {
+	if a.index == nil || a.currentShard == nil {
+		return
+	}
+	if len(a.pendingIndex) == 0 && !complete {
+		return
 	}
 
-	return a.WriteRawLine(data)
+	name := filepath.Base(a.currentShard.path)
+	if err := a.index.commit(name, a.pendingIndex, a.currentShard.size, complete); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update audit index: %v\n", err)
+		return
+	}
+	a.pendingIndex = make(map[string]*indexEntry)
 }
 
 // rotateShard creates a new shard and closes the current one
 func (a *AuditLogger) rotateShard() error {
 	// Close current shard if exists
 	if a.currentShard != nil {
+		a.commitIndex(true)
 		if err := a.currentShard.Close(); err != nil {
 			return fmt.Errorf("failed to close current shard: %w", err)
 		}
 	}
 
 	// Generate new shard filename
-	timestamp := time.Now().Format("20060102_150405")
 	ext := ".jsonl"
 	if a.compressShards {
 		ext = ".jsonl.gz"
 	}
-	shardPath := filepath.Join(a.baseDir, fmt.Sprintf("shard_%s%s", timestamp, ext))
-
-	// Create new shard file
-	file, err := os.Create(shardPath)
+	if len(a.recipients) > 0 {
+		ext += encryptedExt
+	}
+	file, shardPath, err := createShardFile(a.baseDir, time.Now(), ext)
 	if err != nil {
 		return fmt.Errorf("failed to create shard file: %w", err)
 	}
@@ -144,17 +185,50 @@ func (a *AuditLogger) rotateShard() error {
 		compressed: a.compressShards,
 	}
 
+	var dst io.Writer = file
+	if len(a.recipients) > 0 {
+		encWriter, err := newEncryptWriter(file, a.recipients)
+		if err != nil {
+			file.Close()
+			os.Remove(shardPath)
+			return fmt.Errorf("failed to start encrypted shard: %w", err)
+		}
+		shard.encWriter = encWriter
+		dst = encWriter
+	}
+
 	if a.compressShards {
-		shard.gzWriter = gzip.NewWriter(file)
+		shard.gzWriter = gzip.NewWriter(dst)
 		shard.writer = bufio.NewWriterSize(shard.gzWriter, 64*1024)
 	} else {
-		shard.writer = bufio.NewWriterSize(file, 64*1024)
+		shard.writer = bufio.NewWriterSize(dst, 64*1024)
 	}
 
 	a.currentShard = shard
 	return nil
 }
 
+// createShardFile creates a new shard named after the time it was started.
+// Shards started within the same second get a sequence number, which keeps
+// names in the order the shards were created.
+func createShardFile(baseDir string, started time.Time, ext string) (*os.File, string, error) {
+	stem := "shard_" + started.Format("20060102_150405")
+	for n := 0; ; n++ {
+		name := stem + ext
+		if n > 0 {
+			name = fmt.Sprintf("%s_%03d%s", stem, n, ext)
+		}
+		path := filepath.Join(baseDir, name)
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if err == nil {
+			return file, path, nil
+		}
+		if !os.IsExist(err) {
+			return nil, "", err
+		}
+	}
+}
+
 // backgroundFlush periodically flushes the current shard
 func (a *AuditLogger) backgroundFlush() {
 	ticker := time.NewTicker(a.flushInterval)
@@ -163,12 +237,42 @@ func (a *AuditLogger) backgroundFlush() {
 	for range ticker.C {
 		a.rotationMutex.Lock()
 		if a.currentShard != nil {
-			a.currentShard.Flush()
+			if err := a.currentShard.Flush(); err == nil {
+				a.commitIndex(false)
+			}
 		}
 		a.rotationMutex.Unlock()
 	}
 }
 
+// Flush writes buffered records of the current shard to disk
+func (a *AuditLogger) Flush() error {
+	a.rotationMutex.Lock()
+	defer a.rotationMutex.Unlock()
+
+	if a.currentShard == nil {
+		return nil
+	}
+	if err := a.currentShard.Flush(); err != nil {
+		return fmt.Errorf("failed to flush shard: %w", err)
+	}
+	a.commitIndex(false)
+	return nil
+}
+
+// AuditStats summarizes what an audit logger has written since it was opened
+type AuditStats struct {
+	BytesWritten int64 `json:"bytes_written"` // Line bytes before compression
+	Rotations    int64 `json:"rotations"`
+}
+
+// Stats returns what the logger has written since it was opened
+func (a *AuditLogger) Stats() AuditStats {
+	a.rotationMutex.Lock()
+	defer a.rotationMutex.Unlock()
+	return AuditStats{BytesWritten: a.bytesWritten, Rotations: a.rotations}
+}
+
 // GetActiveShards returns information about active shards
 func (a *AuditLogger) GetActiveShards() ([]ShardInfo, error) {
 	pattern := filepath.Join(a.baseDir, "shard_*.jsonl*")
@@ -188,7 +292,7 @@ func (a *AuditLogger) GetActiveShards() ([]ShardInfo, error) {
 			Path:       file,
 			StartTime:  info.ModTime(),
 			Size:       info.Size(),
-			Compressed: filepath.Ext(file) == ".gz",
+			Compressed: isCompressedShard(file),
 		}
 		shards = append(shards, shard)
 	}
@@ -201,7 +305,12 @@ func (a *AuditLogger) Close() error {
 	a.rotationMutex.Lock()
 	defer a.rotationMutex.Unlock()
 
+	if a.index != nil {
+		defer a.index.Close()
+	}
+
 	if a.currentShard != nil {
+		a.commitIndex(true)
 		return a.currentShard.Close()
 	}
 	return nil
@@ -213,7 +322,12 @@ func (s *ShardWriter) Flush() error {
 		return err
 	}
 	if s.compressed && s.gzWriter != nil {
-		return s.gzWriter.Flush()
+		if err := s.gzWriter.Flush(); err != nil {
+			return err
+		}
+	}
+	if s.encWriter != nil {
+		return s.encWriter.Flush()
 	}
 	return nil
 }
@@ -228,5 +342,10 @@ func (s *ShardWriter) Close() error {
 			return err
 		}
 	}
+	if s.encWriter != nil {
+		if err := s.encWriter.Close(); err != nil {
+			return err
+		}
+	}
 	return s.file.Close()
 }
\ No newline at end of file
@@ -0,0 +1,294 @@
+package audit
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useIdentities makes ids available to readers for the rest of the test
+func useIdentities(t *testing.T, ids ...*Identity) {
+	t.Helper()
+	SetIdentities(ids...)
+	t.Cleanup(func() { SetIdentities() })
+}
+
+func newTestIdentity(t *testing.T) *Identity {
+	t.Helper()
+	id, err := GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestEncryptedShards_RoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		SetIdentities()
+		dir := t.TempDir()
+		id := newTestIdentity(t)
+
+		logger, err := NewEncryptedAuditLogger(dir, 1024*1024, compress, []*Recipient{id.Recipient()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, session := range []string{"a", "b", "a"} {
+			raw := []byte(`{"sessionId":"` + session + `","n":` + string(rune('0'+i)) + `}` + "\n")
+			if err := logger.WriteRecord(NewRecord("/p/"+session+".jsonl", session, "claude-code", int64(i*100), raw)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := logger.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		shards, _ := listShards(dir)
+		if len(shards) != 1 || !strings.HasSuffix(shards[0], ".enc") {
+			t.Fatalf("shards = %v, want one encrypted shard", shards)
+		}
+		data, _ := os.ReadFile(shards[0])
+		if strings.Contains(string(data), "sessionId") {
+			t.Error("encrypted shard contains plaintext")
+		}
+
+		if _, err := CollectSessions(dir, CollectFilter{}); !errors.Is(err, ErrNoIdentity) {
+			t.Errorf("reading without a key: error = %v, want ErrNoIdentity", err)
+		}
+
+		useIdentities(t, id)
+
+		// The index knows the shard is complete but not what it holds
+		index, err := OpenIndex(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ranges, err := index.Lookup(dir, StreamFilter{SessionID: "b"})
+		index.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranges) != 1 || ranges[0].Start != 0 || ranges[0].End != -1 || !ranges[0].Complete {
+			t.Errorf("compress=%v: Lookup() = %+v, want the whole complete shard", compress, ranges)
+		}
+		indexData, _ := os.ReadFile(filepath.Join(dir, IndexFileName))
+		if strings.Contains(string(indexData), "/p/a.jsonl") {
+			t.Errorf("compress=%v: index.db names a source path of an encrypted shard", compress)
+		}
+
+		sessions, err := CollectSessions(dir, CollectFilter{SessionID: "b"})
+		if err != nil {
+			t.Fatalf("CollectSessions() error = %v", err)
+		}
+		if len(sessions) != 1 || len(sessions[0].Lines) != 1 {
+			t.Errorf("compress=%v: sessions = %+v", compress, sessions)
+		}
+
+		sessions, err = CollectSessions(dir, CollectFilter{SessionID: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sessions) != 1 || len(sessions[0].Lines) != 2 {
+			t.Errorf("compress=%v: session a = %+v", compress, sessions)
+		}
+	}
+}
+
+func TestEncryptedShards_TailFlushedChunks(t *testing.T) {
+	dir := t.TempDir()
+	id := newTestIdentity(t)
+	useIdentities(t, id)
+
+	logger, err := NewEncryptedAuditLogger(dir, 1024*1024, false, []*Recipient{id.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.WriteRecord(NewRecord("/p", "s", "t", 0, []byte("{\"n\":1}\n")))
+	logger.rotationMutex.Lock()
+	logger.currentShard.Flush()
+	logger.rotationMutex.Unlock()
+
+	reader, err := OpenLiveShard(logger.currentShard.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.ReadLine(); err != nil {
+		t.Fatalf("first line: %v", err)
+	}
+	if _, err := reader.ReadLine(); err != io.EOF {
+		t.Fatalf("before second flush: error = %v, want io.EOF", err)
+	}
+
+	logger.WriteRecord(NewRecord("/p", "s", "t", 8, []byte("{\"n\":2}\n")))
+	logger.rotationMutex.Lock()
+	logger.currentShard.Flush()
+	logger.rotationMutex.Unlock()
+
+	line, err := reader.ReadLine()
+	if err != nil {
+		t.Fatalf("after second flush: %v", err)
+	}
+	if rec, _ := ParseRecord(line); rec == nil || rec.Offset != 8 {
+		t.Errorf("second line = %q", line)
+	}
+}
+
+func TestEncryptedShards_Tampering(t *testing.T) {
+	dir := t.TempDir()
+	id := newTestIdentity(t)
+	useIdentities(t, id)
+
+	logger, err := NewEncryptedAuditLogger(dir, 1024*1024, false, []*Recipient{id.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.WriteRecord(NewRecord("/p", "s", "t", 0, []byte("{\"n\":1}\n")))
+	logger.Close()
+
+	shards, _ := listShards(dir)
+	data, _ := os.ReadFile(shards[0])
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(shards[0], data, 0644)
+
+	reader, err := OpenShard(shards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	// The flipped byte is in the final chunk, after the record
+	for {
+		_, err := reader.ReadLine()
+		if err == io.EOF {
+			t.Fatal("tampered shard read to EOF, want authentication failure")
+		}
+		if err != nil {
+			break
+		}
+	}
+}
+
+func TestEncryptedShards_Truncated(t *testing.T) {
+	dir := t.TempDir()
+	id := newTestIdentity(t)
+	useIdentities(t, id)
+
+	logger, err := NewEncryptedAuditLogger(dir, 1024*1024, false, []*Recipient{id.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.WriteRecord(NewRecord("/p", "s", "t", 0, []byte("{\"sessionId\":\"s\",\"n\":1}\n")))
+	logger.Flush()
+	path := logger.currentShard.path
+	info, _ := os.Stat(path)
+	logger.WriteRecord(NewRecord("/p", "s", "t", 8, []byte("{\"sessionId\":\"s\",\"n\":2}\n")))
+	logger.Close()
+
+	// Cut the closed shard back to its first chunk, losing the final one
+	if err := os.Truncate(path, info.Size()); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenShard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if _, err := reader.ReadLine(); err != nil {
+		t.Fatalf("first line: %v", err)
+	}
+	if _, err := reader.ReadLine(); err != io.ErrUnexpectedEOF {
+		t.Errorf("after the first chunk: error = %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	if _, err := CollectSessions(dir, CollectFilter{SessionID: "s"}); err == nil {
+		t.Error("CollectSessions() on a truncated shard should fail")
+	}
+
+	// Read as a live shard, the same bytes are just all that was flushed
+	live, err := OpenLiveShard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+	live.ReadLine()
+	if _, err := live.ReadLine(); err != io.EOF {
+		t.Errorf("live shard: error = %v, want io.EOF", err)
+	}
+}
+
+func TestRewrapShard(t *testing.T) {
+	dir := t.TempDir()
+	oldID := newTestIdentity(t)
+	newID := newTestIdentity(t)
+
+	logger, err := NewEncryptedAuditLogger(dir, 1024*1024, true, []*Recipient{oldID.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.WriteRecord(NewRecord("/p", "s", "t", 0, []byte("{\"sessionId\":\"s\"}\n")))
+	logger.Close()
+
+	shards, _ := listShards(dir)
+	if err := RewrapShard(shards[0], []*Identity{oldID}, []*Recipient{newID.Recipient()}); err != nil {
+		t.Fatalf("RewrapShard() error = %v", err)
+	}
+
+	useIdentities(t, oldID)
+	if _, err := OpenShard(shards[0]); err == nil {
+		t.Error("old identity should no longer open the shard")
+	}
+
+	SetIdentities(newID)
+	sessions, err := CollectSessions(dir, CollectFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("got %d sessions after rewrap, want 1", len(sessions))
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.key")
+	current := newTestIdentity(t)
+	retired := newTestIdentity(t)
+
+	if err := SaveKeyFile(path, "correct horse", []*Identity{current, retired}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	recipient, err := RecipientFromKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipient.String() != current.Recipient().String() {
+		t.Error("recipient should match the current identity")
+	}
+
+	kf, err := ReadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kf.Unlock("wrong"); err == nil {
+		t.Error("Unlock() with a wrong passphrase should fail")
+	}
+	ids, err := kf.Unlock("correct horse")
+	if err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if len(ids) != 2 || ids[1].String() != retired.String() {
+		t.Errorf("Unlock() returned %d identities", len(ids))
+	}
+}
\ No newline at end of file
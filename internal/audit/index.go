@@ -0,0 +1,449 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	_ "modernc.org/sqlite"
+)
+
+// IndexFileName is the name of the sidecar index stored next to the shards
+const IndexFileName = "index.db"
+
+// Index is a sidecar SQLite database that maps session IDs, tools and time
+// ranges to the shards and uncompressed byte ranges holding their records.
+// Encrypted shards only have their state recorded, so the index does not
+// reveal what they hold; lookups read them in full.
+type Index struct {
+	db *sql.DB
+}
+
+// ShardRange is a byte range within a shard that holds matching records.
+// End is exclusive; an End of -1 means "read to the end of the shard".
+// Complete is set when the shard's writer has closed it.
+type ShardRange struct {
+	Path     string
+	Start    int64
+	End      int64
+	Complete bool
+}
+
+// indexEntry accumulates index information for one session within a shard
+type indexEntry struct {
+	sessionID   string
+	tool        string
+	sourcePath  string
+	firstOffset int64
+	lastOffset  int64
+	count       int64
+	minTime     int64
+	maxTime     int64
+}
+
+const (
+	queryCreateIndexShardsTable = `CREATE TABLE IF NOT EXISTS shards (
+		name TEXT PRIMARY KEY,
+		indexed_size INTEGER NOT NULL DEFAULT 0,
+		complete INTEGER NOT NULL DEFAULT 0
+	)`
+
+	queryCreateIndexEntriesTable = `CREATE TABLE IF NOT EXISTS entries (
+		shard TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		tool TEXT,
+		source_path TEXT,
+		first_offset INTEGER NOT NULL,
+		last_offset INTEGER NOT NULL,
+		record_count INTEGER NOT NULL,
+		min_time INTEGER,
+		max_time INTEGER,
+		PRIMARY KEY (shard, session_id)
+	)`
+
+	queryCreateIndexEntriesSession = `CREATE INDEX IF NOT EXISTS idx_entries_session ON entries(session_id)`
+	queryCreateIndexEntriesTool    = `CREATE INDEX IF NOT EXISTS idx_entries_tool ON entries(tool)`
+
+	queryUpsertIndexEntry = `INSERT INTO entries (shard, session_id, tool, source_path, first_offset, last_offset, record_count, min_time, max_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(shard, session_id) DO UPDATE SET
+			tool = COALESCE(NULLIF(excluded.tool, ''), tool),
+			source_path = COALESCE(NULLIF(excluded.source_path, ''), source_path),
+			first_offset = MIN(first_offset, excluded.first_offset),
+			last_offset = MAX(last_offset, excluded.last_offset),
+			record_count = record_count + excluded.record_count,
+			min_time = MIN(min_time, excluded.min_time),
+			max_time = MAX(max_time, excluded.max_time)`
+
+	queryUpsertIndexShard = `INSERT INTO shards (name, indexed_size, complete) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			indexed_size = MAX(indexed_size, excluded.indexed_size),
+			complete = MAX(complete, excluded.complete)`
+)
+
+// OpenIndex opens (creating if needed) the sidecar index in baseDir
+func OpenIndex(baseDir string) (*Index, error) {
+	db, err := sql.Open("sqlite", filepath.Join(baseDir, IndexFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit index: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	statements := []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA busy_timeout = 5000",
+		queryCreateIndexShardsTable,
+		queryCreateIndexEntriesTable,
+		queryCreateIndexEntriesSession,
+		queryCreateIndexEntriesTool,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialize audit index: %w", err)
+		}
+	}
+
+	return &Index{db: db}, nil
+}
+
+// Close closes the index database
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+// record adds a written record to the pending entries for a shard
+func (e *indexEntry) record(rec *Record, start, end int64) {
+	ts := rec.IngestTime.Unix()
+	if e.count == 0 {
+		e.firstOffset, e.minTime, e.maxTime = start, ts, ts
+	}
+	if start < e.firstOffset {
+		e.firstOffset = start
+	}
+	if end > e.lastOffset {
+		e.lastOffset = end
+	}
+	if ts < e.minTime {
+		e.minTime = ts
+	}
+	if ts > e.maxTime {
+		e.maxTime = ts
+	}
+	if e.tool == "" {
+		e.tool = rec.Tool
+	}
+	if e.sourcePath == "" {
+		e.sourcePath = rec.SourcePath
+	}
+	e.count++
+}
+
+// commit stores pending entries for a shard and records how much of it is indexed
+func (idx *Index) commit(shard string, entries map[string]*indexEntry, indexedSize int64, complete bool) error {
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, encrypted := shardFormat(shard); encrypted {
+		entries = nil
+	}
+	for _, e := range entries {
+		if _, err := tx.Exec(queryUpsertIndexEntry,
+			shard, e.sessionID, e.tool, e.sourcePath,
+			e.firstOffset, e.lastOffset, e.count, e.minTime, e.maxTime,
+		); err != nil {
+			return fmt.Errorf("failed to update index entry: %w", err)
+		}
+	}
+
+	completeFlag := 0
+	if complete {
+		completeFlag = 1
+	}
+	if _, err := tx.Exec(queryUpsertIndexShard, shard, indexedSize, completeFlag); err != nil {
+		return fmt.Errorf("failed to update index shard: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Lookup returns the shard ranges that may contain records matching filter.
+// Shards missing from the index are returned in full so nothing is skipped.
+func (idx *Index) Lookup(baseDir string, filter StreamFilter) ([]ShardRange, error) {
+	shards, err := listShards(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	type shardState struct {
+		indexedSize int64
+		complete    bool
+	}
+	states := make(map[string]shardState)
+	rows, err := idx.db.Query(`SELECT name, indexed_size, complete FROM shards`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name string
+		var state shardState
+		if err := rows.Scan(&name, &state.indexedSize, &state.complete); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		states[name] = state
+	}
+	rows.Close()
+
+	query := `SELECT shard, MIN(first_offset), MAX(last_offset) FROM entries WHERE 1=1`
+	var args []interface{}
+	if filter.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, filter.SessionID)
+	}
+	if filter.Tool != "" {
+		query += " AND (tool = ? OR tool = '' OR tool IS NULL)"
+		args = append(args, filter.Tool)
+	}
+	if !filter.StartTime.IsZero() {
+		query += " AND max_time >= ?"
+		args = append(args, filter.StartTime.Unix())
+	}
+	if !filter.EndTime.IsZero() {
+		query += " AND min_time <= ?"
+		args = append(args, filter.EndTime.Unix())
+	}
+	query += " GROUP BY shard"
+
+	matches := make(map[string][2]int64)
+	rows, err = idx.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var shard string
+		var start, end int64
+		if err := rows.Scan(&shard, &start, &end); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		matches[shard] = [2]int64{start, end}
+	}
+	rows.Close()
+
+	var ranges []ShardRange
+	for _, path := range shards {
+		name := filepath.Base(path)
+		state, indexed := states[name]
+		match, found := matches[name]
+		_, encrypted := shardFormat(name)
+
+		switch {
+		case encrypted:
+			ranges = append(ranges, ShardRange{Path: path, Start: 0, End: -1, Complete: indexed && state.complete})
+		case !indexed:
+			ranges = append(ranges, ShardRange{Path: path, Start: 0, End: -1})
+		case found && state.complete:
+			ranges = append(ranges, ShardRange{Path: path, Start: match[0], End: match[1], Complete: true})
+		case found:
+			// Records may have been written since the last index commit
+			ranges = append(ranges, ShardRange{Path: path, Start: match[0], End: -1})
+		case !state.complete:
+			ranges = append(ranges, ShardRange{Path: path, Start: state.indexedSize, End: -1})
+		}
+	}
+
+	return ranges, nil
+}
+
+// SessionSources returns the source paths recorded for a session
+func (idx *Index) SessionSources(sessionID string) ([]string, error) {
+	rows, err := idx.db.Query(
+		`SELECT DISTINCT source_path FROM entries WHERE session_id = ? AND source_path != '' ORDER BY shard`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// Rebuild indexes every shard in baseDir that is not yet fully indexed.
+// With full set, existing entries are discarded and all shards are reindexed.
+func (idx *Index) Rebuild(baseDir string, full bool) (int, error) {
+	if full {
+		for _, table := range []string{"entries", "shards"} {
+			if _, err := idx.db.Exec("DELETE FROM " + table); err != nil {
+				return 0, fmt.Errorf("failed to clear index: %w", err)
+			}
+		}
+	}
+
+	complete, err := idx.completeShards()
+	if err != nil {
+		return 0, err
+	}
+
+	shards, err := listShards(baseDir)
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for i, path := range shards {
+		name := filepath.Base(path)
+		if complete[name] {
+			continue
+		}
+		// The newest shard may still be receiving records from a running logger
+		if err := idx.indexShard(path, i < len(shards)-1); err != nil {
+			return indexed, fmt.Errorf("failed to index %s: %w", name, err)
+		}
+		indexed++
+	}
+
+	return indexed, nil
+}
+
+// forget removes a shard and its entries from the index
+func (idx *Index) forget(name string) error {
+	if _, err := idx.db.Exec(`DELETE FROM entries WHERE shard = ?`, name); err != nil {
+		return err
+	}
+	_, err := idx.db.Exec(`DELETE FROM shards WHERE name = ?`, name)
+	return err
+}
+
+// completeShards returns the names of shards whose writers have closed them
+func (idx *Index) completeShards() (map[string]bool, error) {
+	rows, err := idx.db.Query(`SELECT name FROM shards WHERE complete = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	complete := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		complete[name] = true
+	}
+	return complete, rows.Err()
+}
+
+// indexShard reads a whole shard and records its entries. The shard is read
+// as far as it has been written; whether a closed shard is cut short is for
+// its readers to find out.
+func (idx *Index) indexShard(path string, complete bool) error {
+	name := filepath.Base(path)
+	if _, encrypted := shardFormat(name); encrypted {
+		return idx.commit(name, nil, 0, complete)
+	}
+
+	reader, err := OpenLiveShard(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if _, err := idx.db.Exec(`DELETE FROM entries WHERE shard = ?`, name); err != nil {
+		return err
+	}
+
+	entries := make(map[string]*indexEntry)
+	for {
+		start := reader.Offset()
+		line, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rec, err := ParseRecord(line)
+		if err != nil {
+			continue
+		}
+		if rec.SessionID == "" && rec.HasPayload() {
+			rec.SessionID = sessionIDFromRaw(rec.Payload())
+		}
+
+		entry, ok := entries[rec.SessionID]
+		if !ok {
+			entry = &indexEntry{sessionID: rec.SessionID}
+			entries[rec.SessionID] = entry
+		}
+		entry.record(rec, start, reader.Offset())
+	}
+
+	return idx.commit(name, entries, reader.Offset(), complete)
+}
+
+// listShards returns all shard paths in baseDir, oldest first
+func listShards(baseDir string) ([]string, error) {
+	shards, err := filepath.Glob(filepath.Join(baseDir, "shard_*.jsonl*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(shards)
+	return shards, nil
+}
+
+// indexExists reports whether baseDir has a sidecar index
+func indexExists(baseDir string) bool {
+	_, err := os.Stat(filepath.Join(baseDir, IndexFileName))
+	return err == nil
+}
+
+// lookupRanges returns the ranges to read for filter, using the index when
+// one exists and the filter is selective, and every shard in full otherwise
+func lookupRanges(baseDir string, filter StreamFilter) ([]ShardRange, error) {
+	selective := filter.SessionID != "" || filter.Tool != "" ||
+		!filter.StartTime.IsZero() || !filter.EndTime.IsZero()
+
+	if selective && indexExists(baseDir) {
+		idx, err := OpenIndex(baseDir)
+		if err == nil {
+			defer idx.Close()
+			if ranges, err := idx.Lookup(baseDir, filter); err == nil {
+				return ranges, nil
+			}
+		}
+	}
+
+	shards, err := listShards(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	complete := make(map[string]bool)
+	if indexExists(baseDir) {
+		if idx, err := OpenIndex(baseDir); err == nil {
+			complete, _ = idx.completeShards()
+			idx.Close()
+		}
+	}
+	ranges := make([]ShardRange, len(shards))
+	for i, path := range shards {
+		ranges[i] = ShardRange{Path: path, Start: 0, End: -1, Complete: complete[filepath.Base(path)]}
+	}
+	return ranges, nil
+}
\ No newline at end of file
@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keyFileIterations is the PBKDF2 work factor for new key files
+const keyFileIterations = 600000
+
+// KeyFile is a passphrase-protected file holding audit identities. The
+// recipient is stored in the clear so writers can encrypt without the
+// passphrase. Identities retired by a rekey are kept so shards that could not
+// be rewrapped yet remain readable.
+type KeyFile struct {
+	Version    int    `json:"version"`
+	Recipient  string `json:"recipient"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Identities string `json:"identities"`
+}
+
+// SaveKeyFile writes identities to path, protected by passphrase. The first
+// identity is the current one and determines the recipient.
+func SaveKeyFile(path, passphrase string, ids []*Identity) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no identities to save")
+	}
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := keyFileAEAD(passphrase, salt, keyFileIterations)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	var secrets []string
+	for _, id := range ids {
+		secrets = append(secrets, id.String())
+	}
+
+	kf := KeyFile{
+		Version:    1,
+		Recipient:  ids[0].Recipient().String(),
+		KDF:        "pbkdf2-sha256",
+		Iterations: keyFileIterations,
+		Salt:       base64.RawStdEncoding.EncodeToString(salt),
+		Nonce:      base64.RawStdEncoding.EncodeToString(nonce),
+		Identities: base64.RawStdEncoding.EncodeToString(
+			aead.Seal(nil, nonce, []byte(strings.Join(secrets, "\n")), nil),
+		),
+	}
+
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadKeyFile reads a key file without decrypting it
+func ReadKeyFile(path string) (*KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kf KeyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+	if kf.Version != 1 || kf.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported key file %s", path)
+	}
+	return &kf, nil
+}
+
+// RecipientFromKeyFile returns the current recipient of a key file
+func RecipientFromKeyFile(path string) (*Recipient, error) {
+	kf, err := ReadKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRecipient(kf.Recipient)
+}
+
+// Unlock decrypts the key file's identities with passphrase, current first
+func (kf *KeyFile) Unlock(passphrase string) ([]*Identity, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(kf.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.RawStdEncoding.DecodeString(kf.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(kf.Identities)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := keyFileAEAD(passphrase, salt, kf.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted key file")
+	}
+
+	var ids []*Identity
+	for _, line := range strings.Split(string(plain), "\n") {
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// keyFileAEAD derives the cipher protecting a key file from its passphrase
+func keyFileAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(key)
+}
\ No newline at end of file
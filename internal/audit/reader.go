@@ -3,83 +3,184 @@ package audit
 import (
 	"bufio"
 	"compress/gzip"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 // ShardReader reads from audit shards
 type ShardReader struct {
 	file       *os.File
 	reader     *bufio.Reader
-	gzReader   *gzip.Reader
+	decoder    io.ReadCloser
 	compressed bool
+	encrypted  bool
+	offset     int64
 }
 
-// OpenShard opens a shard for reading
+// OpenShard opens a closed shard for reading. Encrypted shards are decrypted
+// with the identities registered through SetIdentities or SetIdentityProvider,
+// and one cut off before its final chunk reads as io.ErrUnexpectedEOF.
 func OpenShard(path string) (*ShardReader, error) {
+	return openShard(path, false)
+}
+
+// OpenLiveShard opens a shard a writer may still be appending to. Reading
+// stops with io.EOF at the end of what has been flushed so far.
+func OpenLiveShard(path string) (*ShardReader, error) {
+	return openShard(path, true)
+}
+
+func openShard(path string, live bool) (*ShardReader, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open shard: %w", err)
 	}
 
+	compression, encrypted := shardFormat(path)
 	reader := &ShardReader{
 		file:       file,
-		compressed: filepath.Ext(path) == ".gz",
+		compressed: compression != "",
+		encrypted:  encrypted,
 	}
 
-	if reader.compressed {
-		gzReader, err := gzip.NewReader(file)
+	var src io.Reader = file
+	if encrypted {
+		decrypter, err := newDecryptReader(bufio.NewReader(file), live)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to decrypt shard: %w", err)
+		}
+		src = decrypter
+	}
+
+	switch compression {
+	case ".gz":
+		gzReader, err := gzip.NewReader(src)
 		if err != nil {
 			file.Close()
 			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
 		}
-		reader.gzReader = gzReader
-		reader.reader = bufio.NewReader(gzReader)
+		reader.decoder = gzReader
+	case ".zst":
+		zstdReader, err := zstd.NewReader(src)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		reader.decoder = zstdReader.IOReadCloser()
+	}
+
+	if reader.decoder != nil {
+		reader.reader = bufio.NewReader(reader.decoder)
 	} else {
-		reader.reader = bufio.NewReader(file)
+		reader.reader = bufio.NewReader(src)
 	}
 
 	return reader, nil
 }
 
+// shardFormat returns a shard's compression extension (".gz", ".zst" or "")
+// and whether it is encrypted
+func shardFormat(path string) (string, bool) {
+	encrypted := strings.HasSuffix(path, encryptedExt)
+	ext := filepath.Ext(strings.TrimSuffix(path, encryptedExt))
+	if ext == ".gz" || ext == ".zst" {
+		return ext, encrypted
+	}
+	return "", encrypted
+}
+
+// isCompressedShard reports whether a shard path names a gzip or zstd shard
+func isCompressedShard(path string) bool {
+	compression, _ := shardFormat(path)
+	return compression != ""
+}
+
+// OpenShardAt opens a closed shard for reading starting at an uncompressed
+// byte offset. Plain shards seek directly; compressed and encrypted shards
+// skip ahead without decoding the records in between.
+func OpenShardAt(path string, offset int64) (*ShardReader, error) {
+	return openShardAt(path, offset, false)
+}
+
+func openShardAt(path string, offset int64, live bool) (*ShardReader, error) {
+	reader, err := openShard(path, live)
+	if err != nil {
+		return nil, err
+	}
+	if offset <= 0 {
+		return reader, nil
+	}
+
+	if reader.compressed || reader.encrypted {
+		if _, err := io.CopyN(io.Discard, reader.reader, offset); err != nil && err != io.EOF {
+			reader.Close()
+			return nil, fmt.Errorf("failed to skip to offset %d: %w", offset, err)
+		}
+	} else {
+		if _, err := reader.file.Seek(offset, io.SeekStart); err != nil {
+			reader.Close()
+			return nil, fmt.Errorf("failed to seek to offset %d: %w", offset, err)
+		}
+		reader.reader.Reset(reader.file)
+	}
+	reader.offset = offset
+
+	return reader, nil
+}
+
 // ReadLine reads a single line from the shard
 func (r *ShardReader) ReadLine() ([]byte, error) {
-	return r.reader.ReadBytes('\n')
+	line, err := r.reader.ReadBytes('\n')
+	if err == nil {
+		r.offset += int64(len(line))
+	}
+	return line, err
+}
+
+// Offset returns the uncompressed byte offset of the next line
+func (r *ShardReader) Offset() int64 {
+	return r.offset
 }
 
 // Close closes the shard reader
 func (r *ShardReader) Close() error {
-	if r.compressed && r.gzReader != nil {
-		r.gzReader.Close()
+	if r.decoder != nil {
+		r.decoder.Close()
 	}
 	return r.file.Close()
 }
 
 // ShardIterator provides sequential access to all shards
 type ShardIterator struct {
-	shards       []string
+	shards       []ShardRange
 	currentIndex int
 	currentShard *ShardReader
 }
 
 // NewShardIterator creates a new iterator over audit shards
 func NewShardIterator(baseDir string) (*ShardIterator, error) {
-	pattern := filepath.Join(baseDir, "shard_*.jsonl*")
-	shards, err := filepath.Glob(pattern)
+	return NewFilteredShardIterator(baseDir, StreamFilter{})
+}
+
+// NewFilteredShardIterator creates an iterator that only visits the parts of
+// shards the sidecar index says may hold records matching filter. Callers
+// still need to check each record against the filter.
+func NewFilteredShardIterator(baseDir string, filter StreamFilter) (*ShardIterator, error) {
+	ranges, err := lookupRanges(baseDir, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort shards by name (which includes timestamp)
-	sort.Strings(shards)
-
 	return &ShardIterator{
-		shards:       shards,
+		shards:       ranges,
 		currentIndex: -1,
 	}, nil
 }
@@ -89,14 +190,17 @@ func (it *ShardIterator) Next() ([]byte, error) {
 	for {
 		// Try to read from current shard
 		if it.currentShard != nil {
-			line, err := it.currentShard.ReadLine()
-			if err == nil {
-				return line, nil
-			}
-			if err != io.EOF {
-				return nil, err
+			end := it.shards[it.currentIndex].End
+			if end < 0 || it.currentShard.Offset() < end {
+				line, err := it.currentShard.ReadLine()
+				if err == nil {
+					return line, nil
+				}
+				if err != io.EOF {
+					return nil, err
+				}
 			}
-			// EOF reached, close current shard
+			// End of range reached, close current shard
 			it.currentShard.Close()
 			it.currentShard = nil
 		}
@@ -107,8 +211,9 @@ func (it *ShardIterator) Next() ([]byte, error) {
 			return nil, io.EOF
 		}
 
-		// Open next shard
-		shard, err := OpenShard(it.shards[it.currentIndex])
+		// Open next shard at the start of its range
+		shardRange := it.shards[it.currentIndex]
+		shard, err := openShardAt(shardRange.Path, shardRange.Start, !shardRange.Complete)
 		if err != nil {
 			return nil, err
 		}
@@ -142,8 +247,13 @@ func NewStreamer(baseDir string) *Streamer {
 
 // Stream streams audit logs to the provided handler
 func (s *Streamer) Stream(handler func([]byte) error, follow bool) error {
-	// First, read all existing logs
-	iterator, err := NewShardIterator(s.baseDir)
+	return s.stream(StreamFilter{}, handler, follow)
+}
+
+// stream reads existing logs that may match filter, then optionally follows
+func (s *Streamer) stream(filter StreamFilter, handler func([]byte) error, follow bool) error {
+	// First, read all existing logs, using the index to skip unrelated shards
+	iterator, err := NewFilteredShardIterator(s.baseDir, filter)
 	if err != nil {
 		return fmt.Errorf("failed to create iterator: %w", err)
 	}
@@ -208,7 +318,7 @@ func (s *Streamer) findLatestShard() (string, error) {
 
 // tailShard tails a specific shard file
 func (s *Streamer) tailShard(shardPath string, handler func([]byte) error) error {
-	reader, err := OpenShard(shardPath)
+	reader, err := OpenLiveShard(shardPath)
 	if err != nil {
 		return fmt.Errorf("failed to open shard for tailing: %w", err)
 	}
@@ -285,46 +395,36 @@ type StreamFilter struct {
 func (s *Streamer) FilteredStream(filter StreamFilter, handler func([]byte) error, follow bool) error {
 	// Wrap the handler with filtering logic
 	filteredHandler := func(line []byte) error {
-		// Parse the line to check if it matches the filter
-		var event map[string]interface{}
-		if err := json.Unmarshal(line, &event); err != nil {
+		// Decode the envelope (or legacy line) to check if it matches the filter
+		rec, err := ParseRecord(line)
+		if err != nil {
 			// If we can't parse it, let it through
 			return handler(line)
 		}
 
+		if rec.SessionID == "" && rec.HasPayload() {
+			rec.SessionID = sessionIDFromRaw(rec.Payload())
+		}
+
 		// Apply filters
-		if filter.SessionID != "" {
-			if sid, ok := event["session"].(string); ok && sid != filter.SessionID {
-				return nil // Skip this line
-			}
+		if filter.SessionID != "" && rec.SessionID != filter.SessionID {
+			return nil // Skip this line
 		}
 
-		if filter.Tool != "" {
-			if tool, ok := event["tool"].(string); ok && tool != filter.Tool {
-				return nil // Skip this line
-			}
+		if filter.Tool != "" && rec.Tool != "" && rec.Tool != filter.Tool {
+			return nil // Skip this line
 		}
 
-		if !filter.StartTime.IsZero() {
-			if ts, ok := event["timestamp"].(float64); ok {
-				eventTime := time.Unix(int64(ts), 0)
-				if eventTime.Before(filter.StartTime) {
-					return nil // Skip this line
-				}
-			}
+		if !filter.StartTime.IsZero() && !rec.IngestTime.IsZero() && rec.IngestTime.Before(filter.StartTime) {
+			return nil // Skip this line
 		}
 
-		if !filter.EndTime.IsZero() {
-			if ts, ok := event["timestamp"].(float64); ok {
-				eventTime := time.Unix(int64(ts), 0)
-				if eventTime.After(filter.EndTime) {
-					return nil // Skip this line
-				}
-			}
+		if !filter.EndTime.IsZero() && !rec.IngestTime.IsZero() && rec.IngestTime.After(filter.EndTime) {
+			return nil // Skip this line
 		}
 
 		return handler(line)
 	}
 
-	return s.Stream(filteredHandler, follow)
+	return s.stream(filter, filteredHandler, follow)
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// RecordVersion is the schema version written in every new audit record
+const RecordVersion = 1
+
+// Record is the envelope stored in audit shards for each captured source line.
+// It links the raw payload to the file, session and byte offset it came from.
+type Record struct {
+	Version    int       `json:"v"`
+	SourcePath string    `json:"source_path,omitempty"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Tool       string    `json:"tool,omitempty"`
+	Offset     int64     `json:"offset"`
+	IngestTime time.Time `json:"ingest_time"`
+
+	// Raw holds the source line, including its line terminator, when it is
+	// valid UTF-8. Other payloads are kept byte-for-byte in RawBase64.
+	Raw       string `json:"raw,omitempty"`
+	RawBase64 []byte `json:"raw_b64,omitempty"`
+}
+
+// NewRecord creates a record for a raw source line read at offset
+func NewRecord(sourcePath, sessionID, tool string, offset int64, raw []byte) *Record {
+	rec := &Record{
+		Version:    RecordVersion,
+		SourcePath: sourcePath,
+		SessionID:  sessionID,
+		Tool:       tool,
+		Offset:     offset,
+		IngestTime: time.Now(),
+	}
+	rec.SetPayload(raw)
+	return rec
+}
+
+// SetPayload stores raw as the record payload
+func (r *Record) SetPayload(raw []byte) {
+	if utf8.Valid(raw) {
+		r.Raw = string(raw)
+		r.RawBase64 = nil
+	} else {
+		r.Raw = ""
+		r.RawBase64 = append([]byte(nil), raw...)
+	}
+}
+
+// Payload returns the raw source line exactly as it was captured
+func (r *Record) Payload() []byte {
+	if r.RawBase64 != nil {
+		return r.RawBase64
+	}
+	return []byte(r.Raw)
+}
+
+// HasPayload reports whether the record carries a raw source line
+func (r *Record) HasPayload() bool {
+	return r.Raw != "" || len(r.RawBase64) > 0
+}
+
+// IsLegacy reports whether the record was decoded from a pre-envelope shard line
+func (r *Record) IsLegacy() bool {
+	return r.Version == 0
+}
+
+// ParseRecord decodes a shard line into a Record. Lines written before the
+// envelope format existed are understood too:
+//   - bare raw session lines become records with only a payload;
+//   - metadata events become records without a payload;
+//   - daemon events carrying a "raw" field become records with both.
+//
+// Legacy records have Version 0 and an Offset of -1.
+func ParseRecord(line []byte) (*Record, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return nil, fmt.Errorf("empty audit line")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, fmt.Errorf("invalid audit line: %w", err)
+	}
+
+	if _, ok := fields["v"]; ok {
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("invalid audit record: %w", err)
+		}
+		if rec.Version > RecordVersion {
+			return nil, fmt.Errorf("unsupported audit record version %d", rec.Version)
+		}
+		return &rec, nil
+	}
+
+	return parseLegacyLine(line, fields), nil
+}
+
+// parseLegacyLine converts a pre-envelope shard line into a Record
+func parseLegacyLine(line []byte, fields map[string]json.RawMessage) *Record {
+	var values map[string]interface{}
+	json.Unmarshal(line, &values)
+
+	rec := &Record{Offset: -1}
+
+	if _, isEvent := fields["_audit_timestamp"]; isEvent {
+		rec.SessionID = stringField(values, "session_id", "session")
+		rec.SourcePath = stringField(values, "source_path", "path")
+		rec.Tool = stringField(values, "tool")
+		if ts, ok := values["timestamp"].(float64); ok {
+			rec.IngestTime = time.Unix(int64(ts), 0)
+		} else if ts, ok := values["_audit_timestamp"].(float64); ok {
+			rec.IngestTime = time.Unix(int64(ts), 0)
+		}
+
+		if raw := stringField(values, "raw"); raw != "" {
+			rec.Raw = raw
+			if rec.SessionID == "" {
+				rec.SessionID = sessionIDFromRaw([]byte(raw))
+			}
+		}
+		return rec
+	}
+
+	// A bare raw line from the source session file; the original newline was
+	// not preserved by the legacy writers, so restore it
+	rec.SetPayload(append(append([]byte(nil), line...), '\n'))
+	rec.SessionID = stringField(values, "sessionId")
+	rec.IngestTime = timestampFromRaw(line)
+	return rec
+}
+
+// WriteRecord writes an envelope record to the current shard
+func (a *AuditLogger) WriteRecord(rec *Record) error {
+	if rec.Version == 0 {
+		rec.Version = RecordVersion
+	}
+	if rec.IngestTime.IsZero() {
+		rec.IngestTime = time.Now()
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	return a.writeLine(data, rec)
+}
\ No newline at end of file
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRecord_RoundTrip(t *testing.T) {
+	raw := []byte("{\"type\":\"user\",\"sessionId\":\"s\"}\n")
+	rec := NewRecord("/p/s.jsonl", "s", "claude-code", 42, raw)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseRecord(data)
+	if err != nil {
+		t.Fatalf("ParseRecord() error = %v", err)
+	}
+
+	if got.Version != RecordVersion {
+		t.Errorf("Version = %d, want %d", got.Version, RecordVersion)
+	}
+	if got.SourcePath != "/p/s.jsonl" || got.SessionID != "s" || got.Tool != "claude-code" || got.Offset != 42 {
+		t.Errorf("metadata not preserved: %+v", got)
+	}
+	if !bytes.Equal(got.Payload(), raw) {
+		t.Errorf("Payload() = %q, want %q", got.Payload(), raw)
+	}
+}
+
+func TestParseRecord_BinaryPayload(t *testing.T) {
+	raw := []byte{'{', 0xff, 0xfe, '}', '\n'}
+	data, err := json.Marshal(NewRecord("/p", "s", "t", 0, raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseRecord(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Payload(), raw) {
+		t.Errorf("Payload() = %v, want %v", got.Payload(), raw)
+	}
+}
+
+func TestParseRecord_Legacy(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		sessionID  string
+		sourcePath string
+		payload    string
+	}{
+		{
+			name:      "bare raw line",
+			line:      `{"type":"user","sessionId":"abc"}`,
+			sessionID: "abc",
+			payload:   "{\"type\":\"user\",\"sessionId\":\"abc\"}\n",
+		},
+		{
+			name:       "scan metadata event",
+			line:       `{"type":"import","source_path":"/p/abc.jsonl","session_id":"abc","tool":"claude-code","timestamp":1700000000,"_audit_timestamp":1700000000}`,
+			sessionID:  "abc",
+			sourcePath: "/p/abc.jsonl",
+		},
+		{
+			name:       "daemon event with raw line",
+			line:       `{"type":"message","tool":"claude-code","session":"","path":"/p/abc.jsonl","raw":"{\"sessionId\":\"abc\"}\n","_audit_timestamp":1}`,
+			sessionID:  "abc",
+			sourcePath: "/p/abc.jsonl",
+			payload:    "{\"sessionId\":\"abc\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := ParseRecord([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("ParseRecord() error = %v", err)
+			}
+			if !rec.IsLegacy() {
+				t.Error("legacy line should decode with version 0")
+			}
+			if rec.Offset != -1 {
+				t.Errorf("Offset = %d, want -1", rec.Offset)
+			}
+			if rec.SessionID != tt.sessionID {
+				t.Errorf("SessionID = %q, want %q", rec.SessionID, tt.sessionID)
+			}
+			if rec.SourcePath != tt.sourcePath {
+				t.Errorf("SourcePath = %q, want %q", rec.SourcePath, tt.sourcePath)
+			}
+			if string(rec.Payload()) != tt.payload {
+				t.Errorf("Payload() = %q, want %q", rec.Payload(), tt.payload)
+			}
+		})
+	}
+}
+
+func TestParseRecord_FutureVersion(t *testing.T) {
+	if _, err := ParseRecord([]byte(`{"v":99,"raw":"x"}`)); err == nil {
+		t.Error("ParseRecord() should reject unknown schema versions")
+	}
+}
\ No newline at end of file
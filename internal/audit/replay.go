@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SessionLines holds the raw source lines captured for one session
+type SessionLines struct {
+	SessionID  string
+	Tool       string
+	SourcePath string
+	Shards     []string
+	Lines      [][]byte
+	FirstSeen  time.Time
+	LastSeen   time.Time
+
+	offsets []int64
+	seen    map[string]bool
+}
+
+// CollectFilter limits which sessions CollectSessions returns
+type CollectFilter struct {
+	SessionID string
+	Since     time.Time
+}
+
+// CollectSessions streams every shard in baseDir and regroups the raw lines
+// by session ID. Lines written more than once (for example by repeated scans)
+// are kept only once, in the order they were first seen.
+func CollectSessions(baseDir string, filter CollectFilter) ([]*SessionLines, error) {
+	iterator, err := NewFilteredShardIterator(baseDir, StreamFilter{SessionID: filter.SessionID})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	sessions := make(map[string]*SessionLines)
+	var order []string
+
+	get := func(id string) *SessionLines {
+		session, ok := sessions[id]
+		if !ok {
+			session = &SessionLines{SessionID: id, seen: make(map[string]bool)}
+			sessions[id] = session
+			order = append(order, id)
+		}
+		return session
+	}
+
+	for {
+		line, err := iterator.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec, err := ParseRecord(line)
+		if err != nil {
+			continue
+		}
+
+		if rec.SessionID == "" && rec.HasPayload() {
+			rec.SessionID = sessionIDFromRaw(rec.Payload())
+		}
+		if rec.SessionID == "" || (filter.SessionID != "" && rec.SessionID != filter.SessionID) {
+			continue
+		}
+
+		session := get(rec.SessionID)
+		if rec.SourcePath != "" && session.SourcePath == "" {
+			session.SourcePath = rec.SourcePath
+		}
+		if rec.Tool != "" && session.Tool == "" {
+			session.Tool = rec.Tool
+		}
+		if rec.HasPayload() {
+			session.add(rec, iterator.CurrentShard())
+		}
+	}
+
+	var result []*SessionLines
+	for _, id := range order {
+		session := sessions[id]
+		if len(session.Lines) == 0 {
+			continue
+		}
+		if !filter.Since.IsZero() && !session.LastSeen.IsZero() && session.LastSeen.Before(filter.Since) {
+			continue
+		}
+		if session.Tool == "" {
+			session.Tool = "claude-code"
+		}
+		session.sortByOffset()
+		result = append(result, session)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FirstSeen.Before(result[j].FirstSeen)
+	})
+
+	return result, nil
+}
+
+// Content returns the session's raw lines joined as JSONL
+func (s *SessionLines) Content() []byte {
+	var buf bytes.Buffer
+	for _, line := range s.Lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// add appends a record's payload unless an identical line was already collected
+func (s *SessionLines) add(rec *Record, shard string) {
+	line := bytes.TrimRight(rec.Payload(), "\r\n")
+	key := string(line)
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+
+	s.Lines = append(s.Lines, append([]byte(nil), line...))
+	s.offsets = append(s.offsets, rec.Offset)
+	if shard != "" && (len(s.Shards) == 0 || s.Shards[len(s.Shards)-1] != shard) {
+		s.Shards = append(s.Shards, shard)
+	}
+
+	if ts := timestampFromRaw(line); !ts.IsZero() {
+		if s.FirstSeen.IsZero() || ts.Before(s.FirstSeen) {
+			s.FirstSeen = ts
+		}
+		if ts.After(s.LastSeen) {
+			s.LastSeen = ts
+		}
+	}
+}
+
+// sortByOffset restores source file order when every line has a known offset
+func (s *SessionLines) sortByOffset() {
+	for _, offset := range s.offsets {
+		if offset < 0 {
+			return
+		}
+	}
+
+	sort.Stable(byOffset{s})
+}
+
+// byOffset sorts a session's lines by their source file offset
+type byOffset struct{ s *SessionLines }
+
+func (b byOffset) Len() int           { return len(b.s.Lines) }
+func (b byOffset) Less(i, j int) bool { return b.s.offsets[i] < b.s.offsets[j] }
+func (b byOffset) Swap(i, j int) {
+	b.s.Lines[i], b.s.Lines[j] = b.s.Lines[j], b.s.Lines[i]
+	b.s.offsets[i], b.s.offsets[j] = b.s.offsets[j], b.s.offsets[i]
+}
+
+// CurrentShard returns the file name of the shard the last line was read from
+func (it *ShardIterator) CurrentShard() string {
+	if it.currentIndex < 0 || it.currentIndex >= len(it.shards) {
+		return ""
+	}
+	return filepath.Base(it.shards[it.currentIndex].Path)
+}
+
+// stringField returns the first non-empty string value found under keys
+func stringField(fields map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// sessionIDFromRaw extracts the sessionId field from a raw session line
+func sessionIDFromRaw(raw []byte) string {
+	var line struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := json.Unmarshal(raw, &line); err != nil {
+		return ""
+	}
+	return line.SessionID
+}
+
+// timestampFromRaw extracts the RFC3339 timestamp field from a raw session line
+func timestampFromRaw(raw []byte) time.Time {
+	var line struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(raw, &line); err != nil || line.Timestamp == "" {
+		return time.Time{}
+	}
+	ts, err := time.Parse(time.RFC3339, line.Timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
\ No newline at end of file
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestShard(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	var content []byte
+	for _, line := range lines {
+		content = append(content, line...)
+		content = append(content, '\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectSessions_GroupsAndDedups(t *testing.T) {
+	dir := t.TempDir()
+
+	userA := `{"type":"user","sessionId":"a","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"hi"}}`
+	asstA := `{"type":"assistant","sessionId":"a","timestamp":"2025-01-01T10:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"hello"}]}}`
+	userB := `{"type":"user","sessionId":"b","timestamp":"2025-02-01T10:00:00Z","message":{"role":"user","content":"other"}}`
+	meta := `{"type":"import","source_path":"/p/a.jsonl","session_id":"a","tool":"claude-code","_audit_timestamp":1}`
+
+	// First scan wrote session a, a later scan rewrote it in full plus session b
+	writeTestShard(t, dir, "shard_20250101_100000.jsonl", userA, meta, asstA, meta)
+	writeTestShard(t, dir, "shard_20250201_100000.jsonl", userA, meta, asstA, meta, userB)
+
+	sessions, err := CollectSessions(dir, CollectFilter{})
+	if err != nil {
+		t.Fatalf("CollectSessions() error = %v", err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("CollectSessions() returned %d sessions, want 2", len(sessions))
+	}
+
+	a := sessions[0]
+	if a.SessionID != "a" {
+		t.Fatalf("first session = %q, want a", a.SessionID)
+	}
+	if len(a.Lines) != 2 {
+		t.Errorf("session a has %d lines, want 2 after dedup", len(a.Lines))
+	}
+	if a.SourcePath != "/p/a.jsonl" {
+		t.Errorf("session a source = %q, want /p/a.jsonl", a.SourcePath)
+	}
+	if a.Shards[0] != "shard_20250101_100000.jsonl" {
+		t.Errorf("session a first shard = %q", a.Shards[0])
+	}
+}
+
+func TestCollectSessions_Filters(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestShard(t, dir, "shard_20250101_100000.jsonl",
+		`{"type":"user","sessionId":"old","timestamp":"2025-01-01T10:00:00Z"}`,
+		`{"type":"user","sessionId":"new","timestamp":"2025-03-01T10:00:00Z"}`,
+	)
+
+	sessions, err := CollectSessions(dir, CollectFilter{SessionID: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionID != "old" {
+		t.Errorf("session filter returned %v", sessions)
+	}
+
+	since := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	sessions, err = CollectSessions(dir, CollectFilter{Since: since})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionID != "new" {
+		t.Errorf("since filter returned %v", sessions)
+	}
+}
+
+func TestCollectSessions_LegacyDaemonEvents(t *testing.T) {
+	dir := t.TempDir()
+
+	raw := `{"type":"user","sessionId":"d","timestamp":"2025-01-01T10:00:00Z"}`
+	event := `{"type":"message","tool":"claude-code","session":"","path":"/p/d.jsonl","raw":"{\"type\":\"user\",\"sessionId\":\"d\",\"timestamp\":\"2025-01-01T10:00:00Z\"}\n","_audit_timestamp":1}`
+
+	writeTestShard(t, dir, "shard_20250101_100000.jsonl", raw, event)
+
+	sessions, err := CollectSessions(dir, CollectFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	if len(sessions[0].Lines) != 1 {
+		t.Errorf("got %d lines, want the duplicated raw line once", len(sessions[0].Lines))
+	}
+	if sessions[0].SourcePath != "/p/d.jsonl" {
+		t.Errorf("source = %q, want /p/d.jsonl", sessions[0].SourcePath)
+	}
+}
+
+func TestCollectSessions_EnvelopeRecordsSortedByOffset(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := NewAuditLogger(dir, 1024*1024, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := []byte(`{"type":"user","sessionId":"e","timestamp":"2025-01-01T10:00:00Z"}` + "\n")
+	second := []byte(`{"type":"assistant","sessionId":"e","timestamp":"2025-01-01T10:01:00Z"}` + "\n")
+
+	// Written out of order, as two interleaved captures might
+	logger.WriteRecord(NewRecord("/p/e.jsonl", "e", "claude-code", int64(len(first)), second))
+	logger.WriteRecord(NewRecord("/p/e.jsonl", "e", "claude-code", 0, first))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := CollectSessions(dir, CollectFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+
+	want := string(first) + string(second)
+	if got := string(sessions[0].Content()); got != want {
+		t.Errorf("Content() = %q, want %q", got, want)
+	}
+}
\ No newline at end of file
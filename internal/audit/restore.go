@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+// RestoredFile is a source file reconstructed from the audit log
+type RestoredFile struct {
+	SourcePath string
+	Content    []byte
+	Lines      int
+
+	// Complete is false when the captured offsets leave gaps, or when legacy
+	// records without offsets had to be appended in capture order
+	Complete bool
+}
+
+// restoreLine is a captured payload and where it sat in its source file
+type restoreLine struct {
+	offset  int64
+	payload []byte
+}
+
+// RestoreSession reconstructs the source files of a session from the raw
+// payloads in baseDir. Records carrying offsets are written back in file
+// order; a record captured more than once at the same offset is kept once.
+func RestoreSession(baseDir, sessionID string) ([]*RestoredFile, error) {
+	iterator, err := NewFilteredShardIterator(baseDir, StreamFilter{SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	lines := make(map[string][]restoreLine)
+	var paths []string
+
+	for {
+		line, err := iterator.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec, err := ParseRecord(line)
+		if err != nil || !rec.HasPayload() {
+			continue
+		}
+		if rec.SessionID == "" {
+			rec.SessionID = sessionIDFromRaw(rec.Payload())
+		}
+		if rec.SessionID != sessionID {
+			continue
+		}
+
+		if _, ok := lines[rec.SourcePath]; !ok {
+			paths = append(paths, rec.SourcePath)
+		}
+		lines[rec.SourcePath] = append(lines[rec.SourcePath], restoreLine{
+			offset:  rec.Offset,
+			payload: rec.Payload(),
+		})
+	}
+
+	// Bare legacy lines carry no source path; attribute them to the session's
+	// file when there is exactly one
+	if orphans, ok := lines[""]; ok && len(paths) == 2 {
+		for _, path := range paths {
+			if path != "" {
+				lines[path] = append(lines[path], orphans...)
+			}
+		}
+		delete(lines, "")
+	}
+
+	var files []*RestoredFile
+	for _, path := range paths {
+		if captured, ok := lines[path]; ok {
+			files = append(files, assembleFile(path, captured))
+		}
+	}
+
+	return files, nil
+}
+
+// assembleFile orders a source file's captured lines and joins their payloads
+func assembleFile(path string, captured []restoreLine) *RestoredFile {
+	var located, legacy []restoreLine
+	seenOffset := make(map[int64]bool)
+	seenContent := make(map[string]bool)
+
+	for _, line := range captured {
+		if line.offset < 0 {
+			continue
+		}
+		if seenOffset[line.offset] {
+			continue
+		}
+		seenOffset[line.offset] = true
+		seenContent[string(bytes.TrimRight(line.payload, "\r\n"))] = true
+		located = append(located, line)
+	}
+
+	// Legacy lines have no offset, so dedup them by content instead
+	for _, line := range captured {
+		if line.offset >= 0 {
+			continue
+		}
+		key := string(bytes.TrimRight(line.payload, "\r\n"))
+		if seenContent[key] {
+			continue
+		}
+		seenContent[key] = true
+		legacy = append(legacy, line)
+	}
+
+	sort.SliceStable(located, func(i, j int) bool {
+		return located[i].offset < located[j].offset
+	})
+
+	file := &RestoredFile{SourcePath: path, Complete: len(legacy) == 0}
+
+	var buf bytes.Buffer
+	for _, line := range located {
+		if int64(buf.Len()) != line.offset {
+			file.Complete = false
+		}
+		buf.Write(line.payload)
+	}
+	for _, line := range legacy {
+		buf.Write(line.payload)
+	}
+
+	file.Content = buf.Bytes()
+	file.Lines = len(located) + len(legacy)
+	return file
+}
\ No newline at end of file
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestRestoreSession_ByteForByte(t *testing.T) {
+	dir := t.TempDir()
+
+	// CRLF terminators and a final line without a newline must survive
+	original := "{\"sessionId\":\"r\",\"n\":1}\r\n{\"sessionId\":\"r\",\"n\":2}\n{\"sessionId\":\"r\",\"n\":3}"
+	lines := []string{
+		"{\"sessionId\":\"r\",\"n\":1}\r\n",
+		"{\"sessionId\":\"r\",\"n\":2}\n",
+		"{\"sessionId\":\"r\",\"n\":3}",
+	}
+
+	logger, err := NewAuditLogger(dir, 1024*1024, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offsets []int64
+	var offset int64
+	for _, line := range lines {
+		offsets = append(offsets, offset)
+		offset += int64(len(line))
+	}
+
+	// Out of order and captured twice, as a rescan after a daemon capture would
+	for _, i := range []int{1, 0, 2, 0, 1} {
+		if err := logger.WriteRecord(NewRecord("/p/r.jsonl", "r", "claude-code", offsets[i], []byte(lines[i]))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger.WriteRecord(NewRecord("/p/other.jsonl", "other", "claude-code", 0, []byte("{\"sessionId\":\"other\"}\n")))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := RestoreSession(dir, "r")
+	if err != nil {
+		t.Fatalf("RestoreSession() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("RestoreSession() returned %d files, want 1", len(files))
+	}
+
+	file := files[0]
+	if file.SourcePath != "/p/r.jsonl" {
+		t.Errorf("SourcePath = %q", file.SourcePath)
+	}
+	if string(file.Content) != original {
+		t.Errorf("Content = %q, want %q", file.Content, original)
+	}
+	if !file.Complete {
+		t.Error("file with contiguous offsets should be complete")
+	}
+}
+
+func TestRestoreSession_GapsAndLegacy(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestShard(t, dir, "shard_20250101_100000.jsonl",
+		`{"v":1,"source_path":"/p/g.jsonl","session_id":"g","offset":0,"ingest_time":"2025-01-01T10:00:00Z","raw":"{\"sessionId\":\"g\",\"n\":1}\n"}`,
+		`{"v":1,"source_path":"/p/g.jsonl","session_id":"g","offset":500,"ingest_time":"2025-01-01T10:00:00Z","raw":"{\"sessionId\":\"g\",\"n\":9}\n"}`,
+		`{"sessionId":"g","n":1}`,
+	)
+
+	files, err := RestoreSession(dir, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want legacy lines merged into the known source", len(files))
+	}
+	if files[0].Lines != 2 {
+		t.Errorf("Lines = %d, want duplicate legacy line dropped", files[0].Lines)
+	}
+	if files[0].Complete {
+		t.Error("file with an offset gap should not be complete")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,628 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// idleShardAge is how long a shard must go unmodified before it is treated as
+// closed when the index does not say so (e.g. its writer crashed)
+const idleShardAge = time.Hour
+
+// RetentionPolicy controls how long audit shards are kept and how they are stored
+type RetentionPolicy struct {
+	// MaxAge removes shards started longer ago than this; zero keeps them forever
+	MaxAge time.Duration
+	// MaxTotalSize removes the oldest shards until the audit directory fits; zero is unlimited
+	MaxTotalSize int64
+	// CompactBelow merges closed shards smaller than this many bytes on disk
+	CompactBelow int64
+	// TargetShardSize caps the uncompressed size of shards written by compaction
+	TargetShardSize int64
+	// Recompress rewrites gzip and plain shards as zstd
+	Recompress bool
+	// Recipients encrypts shards written by compaction, and plaintext shards
+	// are rewritten encrypted. Without recipients encrypted shards are only
+	// subject to the age and size policies.
+	Recipients []*Recipient
+}
+
+// DefaultRetentionPolicy keeps everything but compacts and recompresses old shards
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		CompactBelow:    10 * 1024 * 1024,
+		TargetShardSize: 100 * 1024 * 1024,
+		Recompress:      true,
+	}
+}
+
+// GCReport describes what a garbage collection run did, or would do
+type GCReport struct {
+	DryRun           bool     `json:"dry_run"`
+	ShardsScanned    int      `json:"shards_scanned"`
+	Expired          []string `json:"expired"`
+	Evicted          []string `json:"evicted"`
+	Compacted        []string `json:"compacted"`
+	Created          []string `json:"created"`
+	DuplicateRecords int      `json:"duplicate_records"`
+	BytesBefore      int64    `json:"bytes_before"`
+	BytesAfter       int64    `json:"bytes_after"`
+	// Moved maps the name of each compacted shard to the shard its records
+	// were merged into, or to "" if they were all duplicates
+	Moved map[string]string `json:"moved,omitempty"`
+}
+
+// ShardReferences maps the name of every shard the run replaced or removed
+// to the shard now holding its records, or to "" when they are gone. Callers
+// use it to update references such as conversations.audit_shard.
+func (r *GCReport) ShardReferences() map[string]string {
+	refs := make(map[string]string)
+	if r.DryRun {
+		return refs
+	}
+
+	gone := make(map[string]bool)
+	for _, path := range append(append([]string{}, r.Expired...), r.Evicted...) {
+		gone[filepath.Base(path)] = true
+		refs[filepath.Base(path)] = ""
+	}
+	for from, to := range r.Moved {
+		if from == to {
+			continue
+		}
+		if gone[to] {
+			to = ""
+		}
+		refs[from] = to
+	}
+	return refs
+}
+
+// ReclaimableBytes returns the disk space freed by the run. For dry runs the
+// compaction savings are estimated from the share of duplicate records.
+func (r *GCReport) ReclaimableBytes() int64 {
+	if r.BytesAfter > r.BytesBefore {
+		return 0
+	}
+	return r.BytesBefore - r.BytesAfter
+}
+
+// shardFile is a shard on disk. complete is set when the index says its
+// writer closed it.
+type shardFile struct {
+	path     string
+	name     string
+	size     int64
+	started  time.Time
+	modTime  time.Time
+	complete bool
+}
+
+// shardStats summarizes the records of one shard during compaction planning
+type shardStats struct {
+	records    int
+	duplicates int
+	rawBytes   int64
+	dupBytes   int64
+}
+
+// compactOutput is a shard being written by compaction
+type compactOutput struct {
+	tmpPath string
+	path    string
+	file    *os.File
+	encoder *zstd.Encoder
+	crypter *encryptWriter
+	writer  *bufio.Writer
+	size    int64
+}
+
+// firstSeen locates the first occurrence of a record across shards
+type firstSeen struct {
+	shard int
+	line  int
+}
+
+// RunGC applies policy to the shards in baseDir. The newest shard and any
+// shard a writer may still have open are never touched. With dryRun set,
+// nothing is changed and the report estimates the outcome.
+func RunGC(baseDir string, policy RetentionPolicy, dryRun bool) (*GCReport, error) {
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		return &GCReport{DryRun: dryRun}, nil
+	}
+
+	index, err := OpenIndex(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer index.Close()
+
+	shards, err := listShardFiles(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GCReport{DryRun: dryRun}
+	for _, shard := range shards {
+		report.BytesBefore += shard.size
+	}
+
+	closed, err := closedShards(index, shards)
+	if err != nil {
+		return nil, err
+	}
+	report.ShardsScanned = len(closed)
+
+	// Age policy
+	var kept []shardFile
+	for _, shard := range closed {
+		if policy.MaxAge > 0 && time.Since(shard.started) > policy.MaxAge {
+			report.Expired = append(report.Expired, shard.path)
+			continue
+		}
+		kept = append(kept, shard)
+	}
+	if !dryRun {
+		for _, path := range report.Expired {
+			if err := removeShard(index, path); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	// Compaction and recompression
+	kept, err = compactShards(baseDir, index, kept, policy, dryRun, report)
+	if err != nil {
+		return report, err
+	}
+
+	// Size policy, evicting the oldest closed shards first
+	total := int64(0)
+	open := make(map[string]bool)
+	for _, shard := range shards {
+		open[shard.path] = true
+	}
+	for _, shard := range closed {
+		delete(open, shard.path)
+	}
+	for _, shard := range shards {
+		if open[shard.path] {
+			total += shard.size
+		}
+	}
+	for _, shard := range kept {
+		total += shard.size
+	}
+
+	if policy.MaxTotalSize > 0 {
+		for _, shard := range kept {
+			if total <= policy.MaxTotalSize {
+				break
+			}
+			report.Evicted = append(report.Evicted, shard.path)
+			total -= shard.size
+			if !dryRun {
+				if err := removeShard(index, shard.path); err != nil {
+					return report, err
+				}
+			}
+		}
+	}
+
+	report.BytesAfter = total
+	return report, nil
+}
+
+// compactShards merges small shards, drops duplicate records and recompresses
+// old formats. It returns the shards left afterwards, oldest first; in a dry
+// run their sizes are estimates.
+func compactShards(baseDir string, index *Index, shards []shardFile, policy RetentionPolicy, dryRun bool, report *GCReport) ([]shardFile, error) {
+	// Encrypted shards can only be rewritten when there is a key to re-encrypt
+	// to; otherwise they are kept as they are, in their place
+	keep := make([]bool, len(shards))
+	for i, shard := range shards {
+		_, encrypted := shardFormat(shard.path)
+		keep[i] = encrypted && len(policy.Recipients) == 0
+	}
+
+	// First pass: find the first occurrence of every record
+	seen := make(map[[16]byte]firstSeen)
+	stats := make([]shardStats, len(shards))
+	for i, shard := range shards {
+		if keep[i] {
+			continue
+		}
+		line := 0
+		err := readShardLines(shard, func(data []byte) error {
+			key := recordKey(data)
+			if _, dup := seen[key]; dup {
+				stats[i].duplicates++
+				stats[i].dupBytes += int64(len(data))
+			} else {
+				seen[key] = firstSeen{shard: i, line: line}
+			}
+			stats[i].records++
+			stats[i].rawBytes += int64(len(data))
+			line++
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", shard.name, err)
+		}
+	}
+
+	rewrite := make([]bool, len(shards))
+	for i, shard := range shards {
+		if keep[i] {
+			continue
+		}
+		compression, encrypted := shardFormat(shard.path)
+		rewrite[i] = stats[i].duplicates > 0 ||
+			(policy.CompactBelow > 0 && shard.size < policy.CompactBelow) ||
+			(policy.Recompress && compression != ".zst") ||
+			(len(policy.Recipients) > 0 && !encrypted)
+		report.DuplicateRecords += stats[i].duplicates
+	}
+
+	var result []shardFile
+	for i := 0; i < len(shards); {
+		if !rewrite[i] {
+			result = append(result, shards[i])
+			i++
+			continue
+		}
+
+		// Merge each run of consecutive shards that need rewriting
+		j := i
+		for j < len(shards) && rewrite[j] {
+			j++
+		}
+
+		if dryRun {
+			var estimate int64
+			for k := i; k < j; k++ {
+				report.Compacted = append(report.Compacted, shards[k].path)
+				if stats[k].rawBytes > 0 {
+					estimate += shards[k].size * (stats[k].rawBytes - stats[k].dupBytes) / stats[k].rawBytes
+				}
+			}
+			result = append(result, shardFile{path: shards[i].path, name: shards[i].name, size: estimate, started: shards[i].started})
+		} else {
+			created, moved, err := mergeShards(baseDir, index, shards, i, j, seen, policy)
+			if err != nil {
+				return nil, err
+			}
+			if report.Moved == nil {
+				report.Moved = make(map[string]string)
+			}
+			for from, to := range moved {
+				report.Moved[from] = to
+			}
+			for k := i; k < j; k++ {
+				report.Compacted = append(report.Compacted, shards[k].path)
+			}
+			for _, shard := range created {
+				report.Created = append(report.Created, shard.path)
+			}
+			result = append(result, created...)
+		}
+		i = j
+	}
+
+	return result, nil
+}
+
+// mergeShards rewrites shards[from:to] as zstd shards, keeping only the first
+// occurrence of each record, then replaces the originals. It also returns the
+// name of the new shard each original's first records went to.
+func mergeShards(baseDir string, index *Index, shards []shardFile, from, to int, seen map[[16]byte]firstSeen, policy RetentionPolicy) ([]shardFile, map[string]string, error) {
+	ext := ".jsonl.zst"
+	if len(policy.Recipients) > 0 {
+		ext += encryptedExt
+	}
+
+	var outputs []*compactOutput
+	var current *compactOutput
+
+	inputs := make(map[string]bool)
+	for i := from; i < to; i++ {
+		inputs[shards[i].path] = true
+	}
+
+	// A name is free unless another output or a shard outside this run has it
+	taken := func(path string) bool {
+		for _, out := range outputs {
+			if out.path == path {
+				return true
+			}
+		}
+		_, err := os.Stat(path)
+		return err == nil && !inputs[path]
+	}
+
+	closeCurrent := func() error {
+		if current == nil {
+			return nil
+		}
+		if err := current.writer.Flush(); err != nil {
+			return err
+		}
+		if err := current.encoder.Close(); err != nil {
+			return err
+		}
+		if current.crypter != nil {
+			if err := current.crypter.Close(); err != nil {
+				return err
+			}
+		}
+		return current.file.Close()
+	}
+
+	cleanup := func() {
+		if current != nil {
+			current.file.Close()
+		}
+		for _, out := range outputs {
+			os.Remove(out.tmpPath)
+		}
+	}
+
+	// Index into outputs of the shard each input's records start in
+	target := make([]int, to-from)
+
+	for i := from; i < to; i++ {
+		stem := shardStem(shards[i].name)
+		line := 0
+		target[i-from] = -1
+
+		err := readShardLines(shards[i], func(data []byte) error {
+			first := seen[recordKey(data)]
+			line++
+			if first.shard != i || first.line != line-1 {
+				return nil
+			}
+
+			if current == nil || (policy.TargetShardSize > 0 && current.size >= policy.TargetShardSize) {
+				if err := closeCurrent(); err != nil {
+					return err
+				}
+
+				name := stem + ext
+				for n := 1; taken(filepath.Join(baseDir, name)); n++ {
+					name = fmt.Sprintf("%s_%d%s", stem, n, ext)
+				}
+
+				file, err := os.CreateTemp(baseDir, "compact_*.tmp")
+				if err != nil {
+					return err
+				}
+				current = &compactOutput{
+					tmpPath: file.Name(),
+					path:    filepath.Join(baseDir, name),
+					file:    file,
+				}
+				outputs = append(outputs, current)
+
+				var dst io.Writer = file
+				if len(policy.Recipients) > 0 {
+					if current.crypter, err = newEncryptWriter(file, policy.Recipients); err != nil {
+						return err
+					}
+					dst = current.crypter
+				}
+				if current.encoder, err = zstd.NewWriter(dst, zstd.WithEncoderLevel(zstd.SpeedBetterCompression)); err != nil {
+					return err
+				}
+				current.writer = bufio.NewWriterSize(current.encoder, 64*1024)
+			}
+
+			if target[i-from] < 0 {
+				target[i-from] = len(outputs) - 1
+			}
+			n, err := current.writer.Write(data)
+			current.size += int64(n)
+			return err
+		})
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to compact %s: %w", shards[i].name, err)
+		}
+	}
+
+	if err := closeCurrent(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to finish compacted shard: %w", err)
+	}
+
+	// A shard whose records were all duplicates goes with its neighbours
+	moved := make(map[string]string)
+	for k := range target {
+		if target[k] < 0 && k > 0 {
+			target[k] = target[k-1]
+		}
+	}
+	for k := len(target) - 1; k >= 0; k-- {
+		if target[k] < 0 && k < len(target)-1 {
+			target[k] = target[k+1]
+		}
+	}
+	for k, out := range target {
+		moved[shards[from+k].name] = ""
+		if out >= 0 {
+			moved[shards[from+k].name] = filepath.Base(outputs[out].path)
+		}
+	}
+
+	// Publish the new shards before removing the originals, so a crash in
+	// between leaves duplicates for the next run rather than losing records
+	var created []shardFile
+	replaced := make(map[string]bool)
+	for _, out := range outputs {
+		if err := os.Rename(out.tmpPath, out.path); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to publish compacted shard: %w", err)
+		}
+		replaced[out.path] = true
+	}
+
+	for i := from; i < to; i++ {
+		if replaced[shards[i].path] {
+			continue
+		}
+		if err := removeShard(index, shards[i].path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, out := range outputs {
+		if err := index.forget(filepath.Base(out.path)); err != nil {
+			return nil, nil, err
+		}
+		if err := index.indexShard(out.path, true); err != nil {
+			return nil, nil, fmt.Errorf("failed to index compacted shard: %w", err)
+		}
+		shard, err := statShard(out.path)
+		if err != nil {
+			return nil, nil, err
+		}
+		shard.complete = true
+		created = append(created, shard)
+	}
+
+	return created, moved, nil
+}
+
+// recordKey identifies a record regardless of when it was captured. Envelope
+// records are keyed by source, offset and payload; legacy lines by content.
+func recordKey(line []byte) [16]byte {
+	h := sha256.New()
+	if rec, err := ParseRecord(line); err == nil && !rec.IsLegacy() && rec.HasPayload() {
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", rec.SessionID, rec.SourcePath, rec.Offset)
+		h.Write(rec.Payload())
+	} else {
+		h.Write([]byte("legacy\x00"))
+		h.Write(bytes.TrimRight(line, "\r\n"))
+	}
+
+	var key [16]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// readShardLines calls fn with every line of a shard, newline terminated.
+// A final line cut short by a crashed writer is passed on too, but a shard
+// its writer closed must be whole.
+func readShardLines(shard shardFile, fn func([]byte) error) error {
+	reader, err := openShard(shard.path, !shard.complete)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		line, err := reader.ReadLine()
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' {
+				line = append(line, '\n')
+			}
+			if fnErr := fn(line); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// closedShards returns the shards no writer can still be appending to: those
+// whose writer marked them complete, and any but the newest that have been idle
+func closedShards(index *Index, shards []shardFile) ([]shardFile, error) {
+	complete, err := index.completeShards()
+	if err != nil {
+		return nil, err
+	}
+
+	var closed []shardFile
+	for i, shard := range shards {
+		newest := i == len(shards)-1
+		if complete[shard.name] || (!newest && time.Since(shard.modTime) > idleShardAge) {
+			shard.complete = complete[shard.name]
+			closed = append(closed, shard)
+		}
+	}
+	return closed, nil
+}
+
+// removeShard deletes a shard file and its index entries
+func removeShard(index *Index, path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", filepath.Base(path), err)
+	}
+	if err := index.forget(filepath.Base(path)); err != nil {
+		return fmt.Errorf("failed to update audit index: %w", err)
+	}
+	return nil
+}
+
+// listShardFiles returns the shards in baseDir with their sizes, oldest first
+func listShardFiles(baseDir string) ([]shardFile, error) {
+	paths, err := listShards(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var shards []shardFile
+	for _, path := range paths {
+		shard, err := statShard(path)
+		if err != nil {
+			continue
+		}
+		shards = append(shards, shard)
+	}
+	return shards, nil
+}
+
+// statShard describes a shard file on disk
+func statShard(path string) (shardFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return shardFile{}, err
+	}
+
+	name := filepath.Base(path)
+	started := info.ModTime()
+	if stamp := strings.TrimPrefix(shardStem(name), "shard_"); len(stamp) >= 15 {
+		if t, err := time.ParseInLocation("20060102_150405", stamp[:15], time.Local); err == nil {
+			started = t
+		}
+	}
+
+	return shardFile{
+		path:    path,
+		name:    name,
+		size:    info.Size(),
+		started: started,
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// shardStem strips the extensions from a shard file name
+func shardStem(name string) string {
+	if i := strings.Index(name, ".jsonl"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
\ No newline at end of file
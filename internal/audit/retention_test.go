@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeOldShard writes a shard whose writer has long gone
+func writeOldShard(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	writeTestShard(t, dir, name, lines...)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunGC_CompactsAndDedups(t *testing.T) {
+	dir := t.TempDir()
+
+	a1 := `{"v":1,"source_path":"/p/a.jsonl","session_id":"a","offset":0,"ingest_time":"2025-01-01T10:00:00Z","raw":"{\"sessionId\":\"a\",\"n\":1}\n"}`
+	a1again := `{"v":1,"source_path":"/p/a.jsonl","session_id":"a","offset":0,"ingest_time":"2025-01-02T10:00:00Z","raw":"{\"sessionId\":\"a\",\"n\":1}\n"}`
+	a2 := `{"v":1,"source_path":"/p/a.jsonl","session_id":"a","offset":23,"ingest_time":"2025-01-02T10:00:00Z","raw":"{\"sessionId\":\"a\",\"n\":2}\n"}`
+
+	// The second scan rewrote session a in full
+	writeOldShard(t, dir, "shard_20250101_100000.jsonl", a1)
+	writeOldShard(t, dir, "shard_20250102_100000.jsonl", a1again, a2)
+	writeTestShard(t, dir, "shard_20250103_100000.jsonl", `{"sessionId":"active"}`)
+
+	report, err := RunGC(dir, DefaultRetentionPolicy(), true)
+	if err != nil {
+		t.Fatalf("RunGC(dry run) error = %v", err)
+	}
+	if report.DuplicateRecords != 1 || len(report.Compacted) != 2 {
+		t.Errorf("dry run report = %+v", report)
+	}
+	if shards, _ := listShards(dir); len(shards) != 3 {
+		t.Fatalf("dry run changed shards: %v", shards)
+	}
+
+	report, err = RunGC(dir, DefaultRetentionPolicy(), false)
+	if err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+	if len(report.Created) != 1 || !strings.HasSuffix(report.Created[0], "shard_20250101_100000.jsonl.zst") {
+		t.Errorf("Created = %v", report.Created)
+	}
+
+	refs := report.ShardReferences()
+	for _, name := range []string{"shard_20250101_100000.jsonl", "shard_20250102_100000.jsonl"} {
+		if refs[name] != "shard_20250101_100000.jsonl.zst" {
+			t.Errorf("ShardReferences()[%s] = %q, want the compacted shard", name, refs[name])
+		}
+	}
+
+	shards, _ := listShards(dir)
+	if len(shards) != 2 || filepath.Base(shards[1]) != "shard_20250103_100000.jsonl" {
+		t.Fatalf("shards after gc = %v, want compacted shard plus untouched active shard", shards)
+	}
+
+	files, err := RestoreSession(dir, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"sessionId\":\"a\",\"n\":1}\n{\"sessionId\":\"a\",\"n\":2}\n"
+	if len(files) != 1 || string(files[0].Content) != want {
+		t.Errorf("restored after gc = %+v", files)
+	}
+
+	// The index now points at the compacted shard
+	index, err := OpenIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	ranges, err := index.Lookup(dir, StreamFilter{SessionID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) == 0 || !strings.HasSuffix(ranges[0].Path, ".zst") || ranges[0].End < 0 {
+		t.Errorf("Lookup() after gc = %+v", ranges)
+	}
+}
+
+func TestRunGC_AgeAndSizePolicies(t *testing.T) {
+	dir := t.TempDir()
+
+	line := `{"sessionId":"x","pad":"` + strings.Repeat("x", 1000) + `"}`
+	writeOldShard(t, dir, "shard_20200101_100000.jsonl", line)
+	writeOldShard(t, dir, time.Now().Add(-2*time.Hour).Format("shard_20060102_150405.jsonl"), line+" ")
+	writeOldShard(t, dir, time.Now().Add(-time.Hour).Format("shard_20060102_150405.jsonl"), line+"  ")
+	writeTestShard(t, dir, time.Now().Format("shard_20060102_150405.jsonl"), line)
+
+	policy := RetentionPolicy{MaxAge: 30 * 24 * time.Hour, MaxTotalSize: 2500}
+	report, err := RunGC(dir, policy, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Expired) != 1 || !strings.Contains(report.Expired[0], "20200101") {
+		t.Errorf("Expired = %v", report.Expired)
+	}
+	if len(report.Evicted) != 1 {
+		t.Errorf("Evicted = %v, want the oldest remaining shard", report.Evicted)
+	}
+	if refs := report.ShardReferences(); len(refs) != 2 || refs["shard_20200101_100000.jsonl"] != "" {
+		t.Errorf("ShardReferences() = %v, want the removed shards cleared", refs)
+	}
+	if report.BytesAfter > policy.MaxTotalSize {
+		t.Errorf("BytesAfter = %d, want at most %d", report.BytesAfter, policy.MaxTotalSize)
+	}
+
+	shards, _ := listShards(dir)
+	if len(shards) != 2 {
+		t.Errorf("shards after gc = %v", shards)
+	}
+}
+
+func TestRunGC_EncryptsCompactedShards(t *testing.T) {
+	dir := t.TempDir()
+	id, err := GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetIdentities(id)
+	t.Cleanup(func() { SetIdentities() })
+
+	writeOldShard(t, dir, "shard_20250101_100000.jsonl", `{"sessionId":"p","n":1}`)
+	writeTestShard(t, dir, "shard_20250102_100000.jsonl", `{"sessionId":"active"}`)
+
+	policy := DefaultRetentionPolicy()
+	policy.Recipients = []*Recipient{id.Recipient()}
+	report, err := RunGC(dir, policy, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Created) != 1 || !strings.HasSuffix(report.Created[0], ".jsonl.zst.enc") {
+		t.Fatalf("Created = %v, want an encrypted zstd shard", report.Created)
+	}
+
+	sessions, err := CollectSessions(dir, CollectFilter{SessionID: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("got %d sessions from encrypted shard, want 1", len(sessions))
+	}
+}
+func TestRunGC_SizePolicyKeepsOrderWithEncryptedShards(t *testing.T) {
+	dir := t.TempDir()
+	id, err := GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An encrypted shard between two plaintext ones, with no key to rewrite it
+	encDir := t.TempDir()
+	logger, err := NewEncryptedAuditLogger(encDir, 1024*1024, false, []*Recipient{id.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.WriteRecord(NewRecord("/p/e.jsonl", "e", "claude-code", 0, []byte("{\"sessionId\":\"e\"}\n")))
+	logger.Close()
+	encShards, _ := listShards(encDir)
+	encrypted := filepath.Join(dir, "shard_20250102_100000.jsonl.enc")
+	if err := os.Rename(encShards[0], encrypted); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(encrypted, old, old)
+
+	writeOldShard(t, dir, "shard_20250101_100000.jsonl", `{"sessionId":"old"}`)
+	writeTestShard(t, dir, "shard_20250103_100000.jsonl", `{"sessionId":"active"}`)
+
+	shards, _ := listShardFiles(dir)
+	total := int64(0)
+	for _, shard := range shards {
+		total += shard.size
+	}
+
+	policy := RetentionPolicy{MaxTotalSize: total - 1}
+	report, err := RunGC(dir, policy, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Evicted) != 1 || !strings.Contains(report.Evicted[0], "20250101") {
+		t.Errorf("Evicted = %v, want the oldest shard", report.Evicted)
+	}
+	if _, err := os.Stat(encrypted); err != nil {
+		t.Errorf("newer encrypted shard was removed: %v", err)
+	}
+}
\ No newline at end of file
@@ -127,6 +127,7 @@ func (c *Capturer) detectMessages(lines []string) []models.Message {
 
 	for i := range messages {
 		messages[i].TokenCount = c.tokenEstimator.EstimateTokens(messages[i].Content)
+		messages[i].Files = MentionedFiles(messages[i].Content, "")
 	}
 
 	return messages
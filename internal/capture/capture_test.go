@@ -1,8 +1,11 @@
 package capture
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
 )
 
 func TestCaptureFromReader(t *testing.T) {
@@ -107,4 +110,83 @@ func TestEstimateTokens(t *testing.T) {
 				tt.text, tokens, tt.minTokens, tt.maxTokens)
 		}
 	}
+}
+func TestToolUseSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Edit", `{"file_path":"/src/main.go","old_string":"a"}`, "[Used tool: Edit /src/main.go]"},
+		{"NotebookEdit", `{"notebook_path":"/src/a.ipynb"}`, "[Used tool: NotebookEdit /src/a.ipynb]"},
+		{"Grep", `{"pattern":"TODO","path":"/src"}`, "[Used tool: Grep /src]"},
+		{"Bash", `{"command":"ls"}`, "[Used tool: Bash]"},
+	}
+
+	for _, tt := range tests {
+		result := toolUseSummary(ClaudeContentItem{Type: "tool_use", Name: tt.name, Input: []byte(tt.input)})
+		if result != tt.expected {
+			t.Errorf("toolUseSummary(%s) = %q, want %q", tt.name, result, tt.expected)
+		}
+	}
+}
+func TestToolFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []models.FileRef
+	}{
+		{"Edit", `{"file_path":"/repo/internal/main.go"}`, []models.FileRef{{Path: "internal/main.go", Access: models.FileEdit}}},
+		{"Read", `{"file_path":"/etc/hosts.conf"}`, []models.FileRef{{Path: "/etc/hosts.conf", Access: models.FileRead}}},
+		{"Write", `{"file_path":"./docs/../README.md"}`, []models.FileRef{{Path: "README.md", Access: models.FileWrite}}},
+		{"Bash", `{"command":"go test ./... && gofmt -l --config=lint.toml cmd/mem/main.go 'a b.py' | grep x.Println"}`, []models.FileRef{
+			{Path: "lint.toml", Access: models.FileCommand},
+			{Path: "cmd/mem/main.go", Access: models.FileCommand},
+			{Path: "a b.py", Access: models.FileCommand},
+		}},
+		{"Grep", `{"pattern":"TODO","path":"/repo"}`, nil},
+	}
+
+	for _, tt := range tests {
+		files := ToolFiles(tt.name, []byte(tt.input), "/repo")
+		if !reflect.DeepEqual(files, tt.expected) {
+			t.Errorf("ToolFiles(%s) = %v, want %v", tt.name, files, tt.expected)
+		}
+	}
+}
+
+func TestMentionedFiles(t *testing.T) {
+	text := "The bug is in internal/storage/sqlite.go:42 (see /repo/cmd/mem/main.go). " +
+		"Call `os.Getenv` from `config.go`, not `v1.2`; docs at https://example.com/docs/index.html, e.g. later."
+
+	var paths []string
+	for _, f := range MentionedFiles(text, "/repo") {
+		if f.Access != models.FileMention {
+			t.Errorf("Unexpected access %q", f.Access)
+		}
+		paths = append(paths, f.Path)
+	}
+	expected := []string{"internal/storage/sqlite.go", "cmd/mem/main.go", "config.go"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("MentionedFiles() = %v, want %v", paths, expected)
+	}
+}
+
+func TestParseLineFiles(t *testing.T) {
+	line := `{"type":"assistant","cwd":"/repo","message":{"role":"assistant","content":[` +
+		`{"type":"text","text":"Fixing internal/api/server.go now"},` +
+		`{"type":"tool_use","name":"Edit","input":{"file_path":"/repo/internal/api/server.go"}},` +
+		`{"type":"tool_use","name":"Edit","input":{"file_path":"/repo/internal/api/server.go"}}]}}`
+
+	parsed, err := NewClaudeCodeParser().ParseLine([]byte(line))
+	if err != nil || parsed.Message == nil {
+		t.Fatalf("ParseLine() = %+v, %v", parsed, err)
+	}
+	expected := []models.FileRef{
+		{Path: "internal/api/server.go", Access: models.FileMention},
+		{Path: "internal/api/server.go", Access: models.FileEdit},
+	}
+	if !reflect.DeepEqual(parsed.Message.Files, expected) {
+		t.Errorf("Files = %v, want %v", parsed.Message.Files, expected)
+	}
 }
\ No newline at end of file
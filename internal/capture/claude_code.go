@@ -2,6 +2,7 @@ package capture
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -71,38 +72,27 @@ func (p *ClaudeCodeParser) ParseJSONL(r io.Reader) (*models.Conversation, error)
 	var timestamp time.Time
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
 			continue
 		}
 
-		var msg ClaudeCodeMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		parsed, err := p.ParseLine(line)
+		if err != nil {
 			continue
 		}
 
-		if msg.SessionID != "" && sessionID == "" {
-			sessionID = msg.SessionID
+		if parsed.SessionID != "" && sessionID == "" {
+			sessionID = parsed.SessionID
 		}
-		if msg.CWD != "" && projectPath == "" {
-			projectPath = msg.CWD
+		if parsed.CWD != "" && projectPath == "" {
+			projectPath = parsed.CWD
 		}
-
-		if msg.Timestamp != "" {
-			if t, err := time.Parse(time.RFC3339, msg.Timestamp); err == nil {
-				timestamp = t
-			}
+		if !parsed.Timestamp.IsZero() {
+			timestamp = parsed.Timestamp
 		}
-
-		switch msg.Type {
-		case "user":
-			if userMsg := p.parseUserMessage(msg.Message); userMsg != nil {
-				messages = append(messages, *userMsg)
-			}
-		case "assistant":
-			if assistantMsg := p.parseAssistantMessage(msg.Message); assistantMsg != nil {
-				messages = append(messages, *assistantMsg)
-			}
+		if parsed.Message != nil {
+			messages = append(messages, *parsed.Message)
 		}
 	}
 
@@ -114,6 +104,53 @@ func (p *ClaudeCodeParser) ParseJSONL(r io.Reader) (*models.Conversation, error)
 		return nil, fmt.Errorf("no messages found in Claude Code session")
 	}
 
+	return p.NewConversation(sessionID, projectPath, timestamp, messages), nil
+}
+
+// ParsedLine is what a single Claude Code session line contributes to a conversation
+type ParsedLine struct {
+	SessionID string
+	CWD       string
+	Timestamp time.Time
+	Message   *models.Message // nil for lines that carry no user or assistant text
+}
+
+// ParseLine parses one JSONL line of a Claude Code session. It returns an
+// error only if the line is not valid JSON.
+func (p *ClaudeCodeParser) ParseLine(line []byte) (*ParsedLine, error) {
+	var msg ClaudeCodeMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return nil, fmt.Errorf("invalid session line: %w", err)
+	}
+
+	parsed := &ParsedLine{
+		SessionID: msg.SessionID,
+		CWD:       msg.CWD,
+	}
+	if msg.Timestamp != "" {
+		if t, err := time.Parse(time.RFC3339, msg.Timestamp); err == nil {
+			parsed.Timestamp = t
+		}
+	}
+
+	switch msg.Type {
+	case "user":
+		parsed.Message = p.parseUserMessage(msg.Message, msg.CWD)
+	case "assistant":
+		parsed.Message = p.parseAssistantMessage(msg.Message, msg.CWD)
+	}
+
+	// Messages are dated by their line, when it has a time
+	if parsed.Message != nil && !parsed.Timestamp.IsZero() {
+		parsed.Message.Timestamp = parsed.Timestamp
+	}
+
+	return parsed, nil
+}
+
+// NewConversation builds a conversation from messages parsed out of this
+// parser's session file
+func (p *ClaudeCodeParser) NewConversation(sessionID, projectPath string, createdAt time.Time, messages []models.Message) *models.Conversation {
 	// Extract Claude's project path from the source file path
 	claudeProjectPath := ""
 	if p.sourcePath != "" {
@@ -124,23 +161,22 @@ func (p *ClaudeCodeParser) ParseJSONL(r io.Reader) (*models.Conversation, error)
 		claudeProjectPath = filepath.Base(dir)
 	}
 
-	conv := &models.Conversation{
+	return &models.Conversation{
 		Tool:        "claude-code",
 		Project:     extractProjectName(projectPath),
 		ProjectPath: claudeProjectPath,
+		WorkDir:     projectPath,
 		Title:       generateTitleFromMessages(messages),
 		SessionID:   sessionID,
 		SourcePath:  p.sourcePath,
-		CreatedAt:   timestamp,
+		CreatedAt:   createdAt,
 		UpdatedAt:   time.Now(),
 		Messages:    messages,
 		Tags:        []string{"claude-code"},
 	}
-
-	return conv, nil
 }
 
-func (p *ClaudeCodeParser) parseUserMessage(raw json.RawMessage) *models.Message {
+func (p *ClaudeCodeParser) parseUserMessage(raw json.RawMessage, cwd string) *models.Message {
 	var userMsg ClaudeUserMessage
 	if err := json.Unmarshal(raw, &userMsg); err != nil {
 		return nil
@@ -170,10 +206,13 @@ func (p *ClaudeCodeParser) parseUserMessage(raw json.RawMessage) *models.Message
 		Content:    content,
 		Timestamp:  time.Now(),
 		TokenCount: estimateTokens(content),
+		Files:      MentionedFiles(content, cwd),
 	}
 }
 
-func (p *ClaudeCodeParser) parseAssistantMessage(raw json.RawMessage) *models.Message {
+// parseAssistantMessage joins the text and tool calls of an assistant message.
+// Files the tools worked on and the text mentions are recorded relative to cwd.
+func (p *ClaudeCodeParser) parseAssistantMessage(raw json.RawMessage, cwd string) *models.Message {
 	var assistantMsg ClaudeAssistantMessage
 	if err := json.Unmarshal(raw, &assistantMsg); err != nil {
 		return nil
@@ -184,14 +223,21 @@ func (p *ClaudeCodeParser) parseAssistantMessage(raw json.RawMessage) *models.Me
 	}
 
 	var contentParts []string
+	var files []models.FileRef
 	for _, item := range assistantMsg.Content {
 		switch item.Type {
 		case "text":
 			if item.Text != "" {
 				contentParts = append(contentParts, item.Text)
+				for _, f := range MentionedFiles(item.Text, cwd) {
+					files = appendFile(files, f)
+				}
 			}
 		case "tool_use":
-			contentParts = append(contentParts, fmt.Sprintf("[Used tool: %s]", item.Name))
+			contentParts = append(contentParts, toolUseSummary(item))
+			for _, f := range ToolFiles(item.Name, item.Input, cwd) {
+				files = appendFile(files, f)
+			}
 		}
 	}
 
@@ -205,7 +251,26 @@ func (p *ClaudeCodeParser) parseAssistantMessage(raw json.RawMessage) *models.Me
 		Content:    content,
 		Timestamp:  time.Now(),
 		TokenCount: estimateTokens(content),
+		Files:      files,
+	}
+}
+
+// toolUseSummary describes a tool call, naming the file it works on so the
+// conversation can be found by file later
+func toolUseSummary(item ClaudeContentItem) string {
+	var input struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Path         string `json:"path"`
+	}
+	json.Unmarshal(item.Input, &input)
+
+	for _, path := range []string{input.FilePath, input.NotebookPath, input.Path} {
+		if path != "" {
+			return fmt.Sprintf("[Used tool: %s %s]", item.Name, path)
+		}
 	}
+	return fmt.Sprintf("[Used tool: %s]", item.Name)
 }
 
 func extractProjectName(path string) string {
@@ -0,0 +1,197 @@
+package capture
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// toolAccess maps the Claude Code tools that work on a single file to the
+// access they make
+var toolAccess = map[string]string{
+	"Read":         models.FileRead,
+	"NotebookRead": models.FileRead,
+	"Edit":         models.FileEdit,
+	"MultiEdit":    models.FileEdit,
+	"NotebookEdit": models.FileEdit,
+	"Write":        models.FileWrite,
+}
+
+var (
+	// pathMention matches a path with a directory and a file extension, as
+	// in internal/storage/sqlite.go:42
+	pathMention = regexp.MustCompile("(?:^|[\\s(\\[{<\"'`*])((?:~|\\.{1,2})?/?(?:[\\w@+-][\\w.@+-]*/)+[\\w@+-][\\w.@+-]*\\.[A-Za-z][A-Za-z0-9]{0,9})\\b")
+	// codeSpan matches inline code, where a bare file name is taken as a path
+	codeSpan = regexp.MustCompile("`([^`\\s]+)`")
+	// lineSuffix matches a line and column after a file name
+	lineSuffix = regexp.MustCompile(`(:\d+)+$`)
+)
+
+// sourceExtensions are the extensions a file name without a directory needs
+// to be taken as a file rather than, say, a method call like os.Getenv
+var sourceExtensions = map[string]bool{
+	"c": true, "cc": true, "cfg": true, "conf": true, "cpp": true, "cs": true, "css": true,
+	"csv": true, "dart": true, "env": true, "ex": true, "exs": true, "go": true, "gradle": true,
+	"h": true, "hpp": true, "hs": true, "html": true, "ini": true, "java": true, "js": true,
+	"json": true, "jsonl": true, "jsx": true, "kt": true, "lock": true, "lua": true, "md": true,
+	"mod": true, "php": true, "proto": true, "py": true, "rb": true, "rs": true, "scss": true,
+	"sh": true, "sql": true, "sum": true, "svelte": true, "swift": true, "tf": true, "toml": true,
+	"ts": true, "tsx": true, "txt": true, "vue": true, "xml": true, "yaml": true, "yml": true,
+	"zig": true,
+}
+
+// ToolFiles returns the files a Claude Code tool call works on, with paths
+// relative to cwd where they are inside it
+func ToolFiles(name string, input json.RawMessage, cwd string) []models.FileRef {
+	var in struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Command      string `json:"command"`
+	}
+	if json.Unmarshal(input, &in) != nil {
+		return nil
+	}
+
+	if name == "Bash" {
+		return CommandFiles(in.Command, cwd)
+	}
+	access, ok := toolAccess[name]
+	if !ok {
+		return nil
+	}
+	for _, path := range []string{in.FilePath, in.NotebookPath} {
+		if path != "" {
+			return []models.FileRef{{Path: NormalizePath(path, cwd), Access: access}}
+		}
+	}
+	return nil
+}
+
+// CommandFiles returns the files named as arguments of a shell command
+func CommandFiles(command, cwd string) []models.FileRef {
+	var files []models.FileRef
+	for _, word := range shellWords(command) {
+		if i := strings.IndexByte(word, '='); i >= 0 && strings.HasPrefix(word, "-") {
+			word = word[i+1:]
+		}
+		if strings.HasPrefix(word, "-") || !looksLikeFile(word) {
+			continue
+		}
+		files = appendFile(files, models.FileRef{Path: NormalizePath(word, cwd), Access: models.FileCommand})
+	}
+	return files
+}
+
+// shellWords splits a command into words the way a shell would, honouring
+// quotes and backslashes. Operators such as | and && separate words.
+func shellWords(command string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	end := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case unicode.IsSpace(r) || strings.ContainsRune(";|&()<>", r):
+			end()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	end()
+	return words
+}
+
+// MentionedFiles returns the files named in message text: paths with a
+// directory, and file names written as inline code
+func MentionedFiles(text, cwd string) []models.FileRef {
+	var files []models.FileRef
+	add := func(path string) {
+		path = lineSuffix.ReplaceAllString(path, "")
+		if looksLikeFile(path) {
+			files = appendFile(files, models.FileRef{Path: NormalizePath(path, cwd), Access: models.FileMention})
+		}
+	}
+
+	for _, m := range pathMention.FindAllStringSubmatch(text, -1) {
+		add(m[1])
+	}
+	for _, m := range codeSpan.FindAllStringSubmatch(text, -1) {
+		add(m[1])
+	}
+	return files
+}
+
+// NormalizePath cleans a path and makes it relative to cwd if it is inside it
+func NormalizePath(path, cwd string) string {
+	path = filepath.Clean(strings.TrimSpace(path))
+	if cwd != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(filepath.Clean(cwd), path); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// looksLikeFile reports whether word reads as the path of a file: it has an
+// extension with a letter, a directory or a common source extension, and is
+// not a URL or shell expression
+func looksLikeFile(word string) bool {
+	if word == "" || strings.Contains(word, "://") || strings.ContainsAny(word, "$*?{}[]=,`") {
+		return false
+	}
+	base := filepath.Base(word)
+	ext := filepath.Ext(base)
+	if len(ext) < 2 || len(ext) > 11 || ext == base {
+		return false
+	}
+	for _, r := range ext[1:] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if strings.IndexFunc(ext, unicode.IsLetter) < 0 {
+		return false
+	}
+	return strings.Contains(word, "/") || sourceExtensions[ext[1:]]
+}
+
+// appendFile adds a file reference unless files already has it
+func appendFile(files []models.FileRef, ref models.FileRef) []models.FileRef {
+	for _, f := range files {
+		if f == ref {
+			return files
+		}
+	}
+	return append(files, ref)
+}
\ No newline at end of file
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// heatGlyphs shade heatmap cells from no messages to the busiest day
+var heatGlyphs = []string{"·", "░", "▒", "▓", "█"}
+
+// histogramWidth is the length of the longest bar of the hour histogram
+const histogramWidth = 40
+
+func NewActivityCommand() *cobra.Command {
+	var weeks int
+	var projectFilter string
+
+	cmd := &cobra.Command{
+		Use:   "activity",
+		Short: "Show a heatmap of messages by day and a histogram by hour",
+		Long: `Show how many messages were sent each day of the last weeks as a heatmap, one
+column per week from Sunday to Saturday, and how many were sent in each hour of
+the day, both in local time. Conversations come from all_conversations.db
+unless --db is given.`,
+		Example: `  # The last half year
+  mem activity
+
+  # A year of one project
+  mem activity --weeks 52 --project api`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if weeks < 1 {
+				return fmt.Errorf("--weeks must be at least 1")
+			}
+			project, err := NewValidator().ParseProject(projectFilter)
+			if err != nil {
+				return err
+			}
+			return runActivity(storage.ConversationFilter{Project: project}, weeks)
+		},
+	}
+
+	cmd.Flags().IntVar(&weeks, "weeks", 26, "Number of weeks to show")
+	cmd.Flags().StringVar(&projectFilter, "project", "", "Only count conversations of this project (. for the current directory)")
+
+	return withResults(cmd)
+}
+
+func runActivity(filter storage.ConversationFilter, weeks int) error {
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	today := time.Now()
+	activity, err := store.Activity(filter, heatmapStart(today, weeks), time.Local)
+	if err != nil {
+		return fmt.Errorf("failed to read activity: %w", err)
+	}
+
+	return printResult(activity, func() {
+		messages := 0
+		for _, day := range activity.Days {
+			messages += day.Messages
+		}
+		fmt.Printf("📊 %d message(s) on %d day(s) in the last %d week(s)\n\n", messages, len(activity.Days), weeks)
+		renderHeatmap(os.Stdout, activity.Days, today, weeks)
+		fmt.Println("\n🕐 Messages by hour")
+		renderHourHistogram(os.Stdout, activity.Hours)
+	})
+}
+
+// heatmapStart returns the start of the Sunday beginning the first week of a
+// heatmap of weeks weeks ending with today's
+func heatmapStart(today time.Time, weeks int) time.Time {
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	return midnight.AddDate(0, 0, -int(midnight.Weekday())-7*(weeks-1))
+}
+
+// heatLevel returns the glyph index for a day with n messages when the
+// busiest day has most
+func heatLevel(n, most int) int {
+	if n <= 0 || most <= 0 {
+		return 0
+	}
+	return min((n*(len(heatGlyphs)-1)+most-1)/most, len(heatGlyphs)-1)
+}
+
+// renderHeatmap writes a GitHub-style heatmap of messages per day with a row
+// per weekday and a column per week, the last one holding today
+func renderHeatmap(w io.Writer, days []models.DayActivity, today time.Time, weeks int) {
+	start := heatmapStart(today, weeks)
+	first, last := start.Format("2006-01-02"), today.Format("2006-01-02")
+	counts := make(map[string]int)
+	most := 0
+	for _, day := range days {
+		counts[day.Date] = day.Messages
+		if day.Date >= first && day.Date <= last {
+			most = max(most, day.Messages)
+		}
+	}
+	const labelWidth = 5
+
+	// Month names over the first week of each month
+	header := []byte(strings.Repeat(" ", labelWidth+2*weeks+2))
+	free := 0
+	for c := 0; c < weeks; c++ {
+		week := start.AddDate(0, 0, 7*c)
+		if c > 0 && week.Month() == week.AddDate(0, 0, -7).Month() {
+			continue
+		}
+		pos := labelWidth + 2*c
+		if pos >= free {
+			copy(header[pos:], week.Format("Jan"))
+			free = pos + 4
+		}
+	}
+	fmt.Fprintln(w, strings.TrimRight(string(header), " "))
+
+	for weekday := 0; weekday < 7; weekday++ {
+		label := ""
+		if weekday%2 == 1 {
+			label = time.Weekday(weekday).String()[:3]
+		}
+		var row strings.Builder
+		fmt.Fprintf(&row, "%-*s", labelWidth, label)
+		for c := 0; c < weeks; c++ {
+			date := start.AddDate(0, 0, 7*c+weekday).Format("2006-01-02")
+			if date > last {
+				break
+			}
+			row.WriteString(heatGlyphs[heatLevel(counts[date], most)] + " ")
+		}
+		fmt.Fprintln(w, strings.TrimRight(row.String(), " "))
+	}
+	fmt.Fprintf(w, "%*sLess %s More\n", labelWidth, "", strings.Join(heatGlyphs, " "))
+}
+
+// renderHourHistogram writes a bar per hour of the day, scaled so the busiest
+// hour fills histogramWidth
+func renderHourHistogram(w io.Writer, hours [24]int) {
+	most := 0
+	for _, n := range hours {
+		most = max(most, n)
+	}
+	for hour, n := range hours {
+		bar := ""
+		if n > 0 {
+			bar = strings.Repeat("█", max(n*histogramWidth/most, 1)) + " "
+		}
+		fmt.Fprintf(w, "  %02d:00 %s%d\n", hour, bar, n)
+	}
+}
\ No newline at end of file
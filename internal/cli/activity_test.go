@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func TestRenderHeatmap(t *testing.T) {
+	// A Wednesday: the last column stops after it
+	today := time.Date(2025, 6, 4, 15, 0, 0, 0, time.UTC)
+	if start := heatmapStart(today, 3); !start.Equal(time.Date(2025, 5, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("heatmapStart() = %v", start)
+	}
+
+	days := []models.DayActivity{
+		{Date: "2025-05-19", Messages: 1},
+		{Date: "2025-05-28", Messages: 4},
+		{Date: "2025-06-04", Messages: 2},
+		{Date: "2025-06-05", Messages: 9}, // Tomorrow is not shown
+	}
+	var out bytes.Buffer
+	renderHeatmap(&out, days, today, 3)
+
+	expected := []string{
+		"     May Jun",
+		"     · · ·",
+		"Mon  ░ · ·",
+		"     · · ·",
+		"Wed  · █ ▒",
+		"     · ·",
+		"Fri  · ·",
+		"     · ·",
+		"     Less · ░ ▒ ▓ █ More",
+	}
+	if got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("renderHeatmap() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct{ n, most, expected int }{
+		{0, 10, 0},
+		{1, 10, 1},
+		{3, 10, 2},
+		{10, 10, 4},
+		{1, 1, 4},
+	}
+	for _, tt := range tests {
+		if got := heatLevel(tt.n, tt.most); got != tt.expected {
+			t.Errorf("heatLevel(%d, %d) = %d, want %d", tt.n, tt.most, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderHourHistogram(t *testing.T) {
+	var hours [24]int
+	hours[9], hours[14] = 80, 1
+
+	var out bytes.Buffer
+	renderHourHistogram(&out, hours)
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 25 || lines[0] != "  00:00 0" || lines[9] != "  09:00 "+strings.Repeat("█", histogramWidth)+" 80" || lines[14] != "  14:00 █ 1" {
+		t.Errorf("Unexpected histogram:\n%s", out.String())
+	}
+}
\ No newline at end of file
@@ -0,0 +1,515 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/audit"
+	"github.com/jasperwreed/ai-memory/internal/capture"
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Work with the raw audit log",
+		Long: `Inspect and reuse the raw session lines preserved in audit shards.
+
+The audit log is the source of truth for captured sessions: the database can
+always be rebuilt from it, for example after a parser fix or a lost database.`,
+		Example: `  # Rebuild the default database from all audit shards
+  mem audit replay
+
+  # Replay a single session into a fresh database
+  mem audit replay --session 2f1c... --db /tmp/rebuilt.db
+
+  # Replay sessions active in the last week
+  mem audit replay --since 7d
+
+  # Put a purged Claude Code session back so it can be resumed
+  mem audit restore 2f1c...
+
+  # Encrypt new shards at rest
+  mem audit keygen`,
+	}
+
+	cmd.AddCommand(
+		newAuditReplayCommand(),
+		newAuditReindexCommand(),
+		newAuditRestoreCommand(),
+		newAuditGCCommand(),
+		newAuditKeygenCommand(),
+		newAuditRekeyCommand(),
+	)
+
+	return cmd
+}
+
+func newAuditReplayCommand() *cobra.Command {
+	var since string
+	var sessionID string
+	var auditDir string
+	var dryRun bool
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Rebuild conversations from audit shards",
+		Long: `Stream every audit shard, regroup raw lines by session ID and run them through
+the matching format parser into the database.
+
+Replay is idempotent: sessions already in the database are replaced in place,
+keeping their IDs and any tags added since import.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validator := NewValidator()
+			sinceTime, err := validator.ParseSince(since)
+			if err != nil {
+				return err
+			}
+
+			database := dbPath
+			if database == "" {
+				database, err = validator.GetDefaultDatabasePath()
+				if err != nil {
+					return err
+				}
+			}
+
+			filter := audit.CollectFilter{
+				SessionID: sessionID,
+				Since:     sinceTime,
+			}
+			return runAuditReplay(auditDir, database, filter, dryRun, verbose)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only replay sessions active since this age or date (e.g. 7d, 2006-01-02)")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Only replay this session ID")
+	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir(), "Directory containing audit shards")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be replayed without writing to the database")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed progress")
+
+	return cmd
+}
+
+func newAuditReindexCommand() *cobra.Command {
+	var auditDir string
+	var full bool
+
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Build the audit shard index",
+		Long: `Build or update the sidecar index that maps sessions, tools and time ranges to
+shard offsets. New records are indexed as they are written; run this once for
+shards captured before the index existed, or with --full to start over.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			index, err := audit.OpenIndex(auditDir)
+			if err != nil {
+				return err
+			}
+			defer index.Close()
+
+			indexed, err := index.Rebuild(auditDir, full)
+			if err != nil {
+				return fmt.Errorf("failed to rebuild index: %w", err)
+			}
+
+			fmt.Printf("✓ Indexed %d shard(s) in %s\n", indexed, auditDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir(), "Directory containing audit shards")
+	cmd.Flags().BoolVar(&full, "full", false, "Discard the existing index and reindex every shard")
+
+	return cmd
+}
+
+func newAuditRestoreCommand() *cobra.Command {
+	var toDir string
+	var auditDir string
+	var force bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <session-id>",
+		Short: "Restore a session's original files from audit shards",
+		Long: `Reconstruct the original session JSONL byte-for-byte from the raw lines kept in
+audit shards, for example after Claude Code purged it.
+
+Files are written back to their original path unless --to is given, in which
+case they are written into that directory under their original file name.
+Existing files are never overwritten without --force.`,
+		Example: `  # Restore to the original location so 'claude --resume' finds it again
+  mem audit restore 2f1c...
+
+  # Restore into another directory
+  mem audit restore 2f1c... --to ~/.claude/projects/-home-me-project`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuditRestore(auditDir, args[0], toDir, force, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&toDir, "to", "", "Directory to restore into instead of the original path")
+	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir(), "Directory containing audit shards")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be restored without writing files")
+
+	return cmd
+}
+
+func runAuditRestore(auditDir, sessionID, toDir string, force, dryRun bool) error {
+	files, err := audit.RestoreSession(auditDir, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to read audit shards: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no raw lines found for session %s in %s", sessionID, auditDir)
+	}
+
+	for _, file := range files {
+		target, err := restoreTarget(file.SourcePath, sessionID, toDir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("📄 %s (%d lines, %d bytes)\n", target, file.Lines, len(file.Content))
+		if !file.Complete {
+			fmt.Println("   ⚠️  Some lines were not captured; the restored file may be partial")
+		}
+		if dryRun {
+			continue
+		}
+
+		if _, err := os.Stat(target); err == nil && !force {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", target)
+		}
+		// Transcripts may have been decrypted, so only the user can read
+		// them. An overwritten file keeps its mode.
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(target, file.Content, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+
+	if dryRun {
+		fmt.Println("\n(Dry run - no files written)")
+	} else {
+		fmt.Printf("✓ Restored session %s\n", sessionID)
+	}
+	return nil
+}
+
+// restoreTarget picks where a restored file is written. Legacy shards may not
+// record the source path, so fall back to the one stored in the database.
+func restoreTarget(sourcePath, sessionID, toDir string) (string, error) {
+	if sourcePath == "" {
+		sourcePath = sourcePathFromDatabase(sessionID)
+	}
+
+	if toDir != "" {
+		name := sessionID + ".jsonl"
+		if sourcePath != "" {
+			name = filepath.Base(sourcePath)
+		}
+		return filepath.Join(toDir, name), nil
+	}
+
+	if sourcePath == "" {
+		return "", fmt.Errorf("original path of session %s is unknown; use --to to choose a directory", sessionID)
+	}
+	return sourcePath, nil
+}
+
+// sourcePathFromDatabase looks up a session's source path in the database
+func sourcePathFromDatabase(sessionID string) string {
+	database := dbPath
+	if database == "" {
+		var err error
+		database, err = NewValidator().GetDefaultDatabasePath()
+		if err != nil {
+			return ""
+		}
+	}
+	if _, err := os.Stat(database); err != nil {
+		return ""
+	}
+
+	store, err := openStore(database)
+	if err != nil {
+		return ""
+	}
+	defer store.Close()
+
+	conv, err := store.GetConversationBySessionID(sessionID)
+	if err != nil || conv == nil {
+		return ""
+	}
+	return conv.SourcePath
+}
+
+func newAuditGCCommand() *cobra.Command {
+	var auditDir string
+	var maxAge string
+	var maxSize string
+	var compactBelow string
+	var noRecompress bool
+	var dryRun bool
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Apply retention policies and compact audit shards",
+		Long: `Apply age and size retention policies to closed audit shards, merge small
+shards, drop raw lines captured more than once (for example by repeated scans)
+and recompress gzip and plain shards with zstd.
+
+The newest shard, and any shard a running capture may still be writing, are
+never touched. Conversations in the database that name a compacted shard are
+pointed at the shard now holding their records, and those naming a removed one
+no longer name a shard. Use --dry-run to see how much space would be reclaimed.`,
+		Example: `  # See what would be reclaimed
+  mem audit gc --dry-run
+
+  # Keep 90 days of audit history, at most 5GB
+  mem audit gc --max-age 90d --max-size 5GB`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validator := NewValidator()
+			policy := audit.DefaultRetentionPolicy()
+
+			var err error
+			if policy.MaxAge, err = validator.ParseAge(maxAge); err != nil {
+				return err
+			}
+			if policy.MaxTotalSize, err = validator.ParseSize(maxSize); err != nil {
+				return err
+			}
+			if compactBelow != "" {
+				if policy.CompactBelow, err = validator.ParseSize(compactBelow); err != nil {
+					return err
+				}
+			}
+			policy.Recompress = !noRecompress
+			if policy.Recipients, err = auditRecipients(); err != nil {
+				return err
+			}
+
+			return runAuditGC(auditDir, policy, dryRun, verbose)
+		},
+	}
+
+	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir(), "Directory containing audit shards")
+	cmd.Flags().StringVar(&maxAge, "max-age", "", "Remove shards older than this (e.g. 90d)")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Remove the oldest shards until the audit log fits (e.g. 5GB)")
+	cmd.Flags().StringVar(&compactBelow, "compact-below", "", "Merge shards smaller than this (default 10MB)")
+	cmd.Flags().BoolVar(&noRecompress, "no-recompress", false, "Keep gzip and plain shards as they are")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report reclaimable space without changing anything")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "List affected shards")
+
+	return withResults(cmd)
+}
+
+// gcResult is what mem audit gc prints
+type gcResult struct {
+	*audit.GCReport
+	ReclaimableBytes     int64 `json:"reclaimable_bytes"`
+	ConversationsUpdated int64 `json:"conversations_updated"`
+}
+
+func runAuditGC(auditDir string, policy audit.RetentionPolicy, dryRun, verbose bool) error {
+	progressf("🧹 Collecting audit shards in %s\n", auditDir)
+
+	report, err := audit.RunGC(auditDir, policy, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to collect audit shards: %w", err)
+	}
+	result := gcResult{GCReport: report, ReclaimableBytes: report.ReclaimableBytes()}
+
+	// Conversations name the shard they were captured to
+	if refs := report.ShardReferences(); len(refs) > 0 {
+		store, err := openDefaultStore()
+		if err == nil {
+			result.ConversationsUpdated, err = store.RetargetAuditShards(refs)
+			store.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to update conversations' audit shards: %v\n", err)
+		}
+	}
+
+	return printResult(result, func() {
+		printGCReport(result, verbose)
+	})
+}
+
+// printGCReport prints what a gc run did, or would do, for people
+func printGCReport(result gcResult, verbose bool) {
+	report := result.GCReport
+	listShards := func(label string, paths []string) {
+		if !verbose || len(paths) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", label)
+		for _, path := range paths {
+			fmt.Printf("  • %s\n", filepath.Base(path))
+		}
+	}
+	listShards("Expired", report.Expired)
+	listShards("Compacted", report.Compacted)
+	listShards("Created", report.Created)
+	listShards("Evicted", report.Evicted)
+
+	fmt.Println()
+	fmt.Println("═══════════════════════════════════")
+	if report.DryRun {
+		fmt.Printf("📊 GC Plan\n")
+	} else {
+		fmt.Printf("📊 GC Complete\n")
+	}
+	fmt.Printf("   Closed shards: %d\n", report.ShardsScanned)
+	fmt.Printf("   Expired by age: %d\n", len(report.Expired))
+	fmt.Printf("   Compacted: %d\n", len(report.Compacted))
+	fmt.Printf("   Duplicate records: %d\n", report.DuplicateRecords)
+	fmt.Printf("   Evicted by size: %d\n", len(report.Evicted))
+	fmt.Printf("   Size: %s → %s\n", formatBytes(report.BytesBefore), formatBytes(report.BytesAfter))
+	if report.DryRun {
+		fmt.Printf("   Reclaimable: ~%s\n", formatBytes(result.ReclaimableBytes))
+		fmt.Println("\n(Dry run - no changes made)")
+	} else {
+		fmt.Printf("   Reclaimed: %s\n", formatBytes(result.ReclaimableBytes))
+		if result.ConversationsUpdated > 0 {
+			fmt.Printf("   Conversations updated: %d\n", result.ConversationsUpdated)
+		}
+	}
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func runAuditReplay(auditDir, database string, filter audit.CollectFilter, dryRun, verbose bool) error {
+	fmt.Printf("📜 Reading audit shards from %s\n", auditDir)
+
+	sessions, err := audit.CollectSessions(auditDir, filter)
+	if err != nil {
+		return fmt.Errorf("failed to read audit shards: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No sessions found in audit log.")
+		return nil
+	}
+
+	fmt.Printf("📁 Found %d session(s)\n", len(sessions))
+
+	if dryRun {
+		for _, session := range sessions {
+			fmt.Printf("  • %s (%s, %d lines)\n", session.SessionID, session.Tool, len(session.Lines))
+			if verbose && session.SourcePath != "" {
+				fmt.Printf("    Source: %s\n", session.SourcePath)
+			}
+		}
+		fmt.Println("\n(Dry run - no changes made)")
+		return nil
+	}
+
+	store, err := openStore(database)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	fmt.Printf("💾 Database: %s\n", database)
+
+	created, replaced, failed := 0, 0, 0
+	for i, session := range sessions {
+		if verbose {
+			fmt.Printf("  [%d/%d] Replaying %s...\n", i+1, len(sessions), session.SessionID)
+		}
+
+		conv, err := parseSessionLines(session)
+		if err != nil {
+			if verbose {
+				fmt.Printf("    ⚠️  Failed to parse: %v\n", err)
+			}
+			failed++
+			continue
+		}
+
+		existed, err := store.ReplaceConversationBySessionID(conv)
+		if err != nil {
+			if verbose {
+				fmt.Printf("    ❌ Failed to save: %v\n", err)
+			}
+			failed++
+			continue
+		}
+
+		if existed {
+			replaced++
+		} else {
+			created++
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("═══════════════════════════════════")
+	fmt.Printf("📊 Replay Complete\n")
+	fmt.Printf("   Sessions replayed: %d\n", created+replaced)
+	fmt.Printf("   New conversations: %d\n", created)
+	fmt.Printf("   Updated in place: %d\n", replaced)
+	if failed > 0 {
+		fmt.Printf("   Failed: %d\n", failed)
+	}
+
+	return nil
+}
+
+// parseSessionLines runs a session's raw lines through the parser for its format
+func parseSessionLines(session *audit.SessionLines) (*models.Conversation, error) {
+	content := session.Content()
+
+	var conv *models.Conversation
+	var err error
+	if session.Tool == "claude-code" || capture.DetectFormat(string(content)) == capture.FormatClaudeCode {
+		parser := capture.NewClaudeCodeParserWithPath(session.SourcePath)
+		conv, err = parser.ParseJSONL(bytes.NewReader(content))
+	} else {
+		capturer := capture.NewCapturer(session.Tool, "", nil)
+		conv, err = capturer.CaptureFromReader(bytes.NewReader(content))
+		if err == nil {
+			conv.SessionID = session.SessionID
+			conv.SourcePath = session.SourcePath
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(session.Shards) > 0 {
+		conv.AuditShard = session.Shards[0]
+	}
+
+	return conv, nil
+}
+
+// defaultAuditDir returns the configured audit shard directory
+func defaultAuditDir() string {
+	return settings().Storage.AuditDir
+}
\ No newline at end of file
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"github.com/jasperwreed/ai-memory/internal/audit"
+)
+
+// passphraseEnv lets scripts supply the key file passphrase without a prompt
+const passphraseEnv = "AI_MEMORY_PASSPHRASE"
+
+func newAuditKeygenCommand() *cobra.Command {
+	var keyFile string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Create a key for encrypting audit shards",
+		Long: `Create a passphrase-protected key file for audit shard encryption.
+
+Once the key file exists, scans and the daemon encrypt new shards to its public
+recipient, which needs no passphrase, so a capture process can write shards it
+cannot read. Reading shards (replay, restore, logs) asks for the passphrase,
+or reads it from $AI_MEMORY_PASSPHRASE.
+
+The shard index (index.db) records no session IDs, paths or tools for
+encrypted shards, so looking up a session reads all of them. Shard file names
+and sizes stay visible.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(keyFile); err == nil && !force {
+				return fmt.Errorf("%s already exists (use 'mem audit rekey' to rotate it)", keyFile)
+			}
+
+			passphrase, err := readNewPassphrase("New passphrase")
+			if err != nil {
+				return err
+			}
+
+			identity, err := audit.GenerateIdentity()
+			if err != nil {
+				return err
+			}
+			if err := audit.SaveKeyFile(keyFile, passphrase, []*audit.Identity{identity}); err != nil {
+				return err
+			}
+
+			fmt.Printf("🔑 Key file: %s\n", keyFile)
+			fmt.Printf("   Recipient: %s\n", identity.Recipient())
+			fmt.Println("\nNew audit shards will be encrypted. Restart the daemon to pick up the key.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&keyFile, "key-file", defaultAuditKeyFile(), "Path to the key file")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing key file")
+
+	return cmd
+}
+
+func newAuditRekeyCommand() *cobra.Command {
+	var keyFile string
+	var auditDir string
+	var passphraseOnly bool
+	var dropOld bool
+
+	cmd := &cobra.Command{
+		Use:   "rekey",
+		Short: "Rotate the audit encryption key",
+		Long: `Generate a new audit key and re-encrypt the file key of every closed encrypted
+shard to it. Shard payloads are not rewritten.
+
+Shards that are still being written keep the old key; old keys stay in the key
+file so those shards remain readable. Run rekey again with --drop-old once they
+are closed to discard the old keys. With --passphrase-only, only the key file's
+passphrase changes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuditRekey(keyFile, auditDir, passphraseOnly, dropOld)
+		},
+	}
+
+	cmd.Flags().StringVar(&keyFile, "key-file", defaultAuditKeyFile(), "Path to the key file")
+	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir(), "Directory containing audit shards")
+	cmd.Flags().BoolVar(&passphraseOnly, "passphrase-only", false, "Only change the key file passphrase")
+	cmd.Flags().BoolVar(&dropOld, "drop-old", false, "Discard old keys once no shard needs them")
+
+	return cmd
+}
+
+func runAuditRekey(keyFile, auditDir string, passphraseOnly, dropOld bool) error {
+	kf, err := audit.ReadKeyFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	current, err := readPassphrase("Current passphrase")
+	if err != nil {
+		return err
+	}
+	ids, err := kf.Unlock(current)
+	if err != nil {
+		return err
+	}
+
+	if !passphraseOnly {
+		identity, err := audit.GenerateIdentity()
+		if err != nil {
+			return err
+		}
+		ids = append([]*audit.Identity{identity}, ids...)
+
+		fmt.Printf("🔄 Rewrapping shards in %s\n", auditDir)
+		result, err := audit.RekeyShards(auditDir, ids, []*audit.Recipient{identity.Recipient()})
+		if err != nil {
+			return fmt.Errorf("failed to rekey shards: %w", err)
+		}
+
+		fmt.Printf("   Rewrapped: %d\n", result.Rewrapped)
+		if len(result.Skipped) > 0 {
+			fmt.Printf("   Still open: %d (old key kept for them)\n", len(result.Skipped))
+		}
+		if dropOld && len(result.Skipped) == 0 {
+			ids = ids[:1]
+		}
+	} else if dropOld {
+		return fmt.Errorf("--drop-old cannot be combined with --passphrase-only")
+	}
+
+	passphrase, err := readNewPassphrase("New passphrase (empty keeps the current one)")
+	if err != nil && err != errEmptyPassphrase {
+		return err
+	}
+	if passphrase == "" {
+		passphrase = current
+	}
+
+	if err := audit.SaveKeyFile(keyFile, passphrase, ids); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Key file updated: %s\n", keyFile)
+	fmt.Printf("   Recipient: %s\n", ids[0].Recipient())
+	if len(ids) > 1 {
+		fmt.Printf("   Old keys kept: %d\n", len(ids)-1)
+	}
+	if !passphraseOnly {
+		fmt.Println("\nRestart the daemon so new shards are encrypted to the new key.")
+	}
+	return nil
+}
+
+// stdinReader is shared so consecutive prompts can read piped lines
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// errEmptyPassphrase is returned by readNewPassphrase when nothing was entered
+var errEmptyPassphrase = fmt.Errorf("passphrase must not be empty")
+
+// readNewPassphrase asks for a passphrase twice, from $AI_MEMORY_PASSPHRASE if set
+func readNewPassphrase(prompt string) (string, error) {
+	if value, ok := os.LookupEnv(passphraseEnv); ok {
+		return value, nil
+	}
+
+	passphrase, err := readPassphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errEmptyPassphrase
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		confirm, err := readPassphrase("Confirm passphrase")
+		if err != nil {
+			return "", err
+		}
+		if confirm != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// readPassphrase reads a passphrase from $AI_MEMORY_PASSPHRASE, the terminal
+// without echo, or a line on stdin
+func readPassphrase(prompt string) (string, error) {
+	if value, ok := os.LookupEnv(passphraseEnv); ok {
+		return value, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		return string(data), nil
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// unlockAuditIdentities unlocks the default key file so encrypted shards can
+// be read. It runs the first time an encrypted shard is opened.
+func unlockAuditIdentities() ([]*audit.Identity, error) {
+	keyFile := defaultAuditKeyFile()
+	kf, err := audit.ReadKeyFile(keyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("audit shards are encrypted but %s does not exist", keyFile)
+		}
+		return nil, err
+	}
+
+	passphrase, err := readPassphrase("Audit key passphrase")
+	if err != nil {
+		return nil, err
+	}
+	return kf.Unlock(passphrase)
+}
+
+// auditRecipients returns the recipients new shards should be encrypted to:
+// the default key file's recipient when it exists, otherwise none
+func auditRecipients() ([]*audit.Recipient, error) {
+	recipient, err := audit.RecipientFromKeyFile(defaultAuditKeyFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load audit key: %w", err)
+	}
+	return []*audit.Recipient{recipient}, nil
+}
+
+// defaultAuditKeyFile returns the configured audit key file path
+func defaultAuditKeyFile() string {
+	return settings().Storage.AuditKeyFile
+}
\ No newline at end of file
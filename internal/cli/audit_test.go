@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jasperwreed/ai-memory/internal/audit"
+)
+
+func TestRunAuditRestore_Permissions(t *testing.T) {
+	auditDir := t.TempDir()
+	logger, err := audit.NewAuditLogger(auditDir, 1024*1024, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.WriteRecord(audit.NewRecord("/p/r.jsonl", "r", "claude-code", 0, []byte("{\"sessionId\":\"r\"}\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	toDir := filepath.Join(t.TempDir(), "restored")
+	if err := runAuditRestore(auditDir, "r", toDir, false, false); err != nil {
+		t.Fatalf("runAuditRestore() error = %v", err)
+	}
+	target := filepath.Join(toDir, "r.jsonl")
+	for path, want := range map[string]os.FileMode{toDir: 0700, target: 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != want {
+			t.Errorf("%s has mode %o, want %o", path, mode, want)
+		}
+	}
+
+	// An overwritten file keeps the mode it had
+	if err := os.Chmod(target, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := runAuditRestore(auditDir, "r", toDir, true, false); err != nil {
+		t.Fatalf("runAuditRestore() error = %v", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0640 {
+		t.Errorf("overwritten file has mode %o, want 640", mode)
+	}
+}
\ No newline at end of file
@@ -3,12 +3,10 @@ package cli
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/jasperwreed/ai-memory/internal/daemon"
 	"github.com/jasperwreed/ai-memory/internal/tui"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewBrowseCommand() *cobra.Command {
@@ -46,21 +44,18 @@ func runBrowse(customDB string, useAll bool, startCapture bool) error {
 
 	// If --all flag is used and no custom DB specified, use all_conversations.db
 	if useAll && customDB == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		database = filepath.Join(homeDir, ".ai-memory", "all_conversations.db")
+		database = settings().Storage.Database
 	}
 
 	// Start auto-capture daemon if requested
 	var captureDaemon *daemon.CaptureDaemon
 	if startCapture {
-		config := daemon.DefaultConfig()
+		config := daemonConfig(settings())
 		d, err := daemon.NewCaptureDaemon(config)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to start auto-capture daemon: %v\n", err)
 		} else {
+			d.SetStoreOpener(openStore)
 			if err := d.Start(); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to start auto-capture daemon: %v\n", err)
 			} else {
@@ -76,7 +71,7 @@ func runBrowse(customDB string, useAll bool, startCapture bool) error {
 		}
 	}
 
-	store, err := storage.NewSQLiteStore(database)
+	store, err := openStore(database)
 	if err != nil {
 		return err
 	}
@@ -7,7 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/jasperwreed/ai-memory/internal/capture"
-	"github.com/jasperwreed/ai-memory/internal/storage"
+	"github.com/jasperwreed/ai-memory/internal/models"
 )
 
 func NewCaptureCommand() *cobra.Command {
@@ -28,7 +28,14 @@ func NewCaptureCommand() *cobra.Command {
 	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Comma-separated tags")
 	cmd.Flags().Bool("auto-detect", false, "Auto-detect tool from input")
 
-	return cmd
+	return withResults(cmd)
+}
+
+// capturedConversation is what mem capture reports about a saved conversation
+type capturedConversation struct {
+	models.Conversation
+	MessageCount int `json:"message_count"`
+	TokenCount   int `json:"token_count"`
 }
 
 func runCapture(cmd *cobra.Command, args []string) error {
@@ -39,7 +46,7 @@ func runCapture(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	store, err := storage.NewSQLiteStore(dbPath)
+	store, err := openStore(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -61,22 +68,26 @@ func runCapture(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save conversation: %w", err)
 	}
 
-	fmt.Printf("✓ Captured conversation (ID: %d)\n", conversation.ID)
-	fmt.Printf("  Title: %s\n", conversation.Title)
-	fmt.Printf("  Tool: %s\n", conversation.Tool)
-	if conversation.Project != "" {
-		fmt.Printf("  Project: %s\n", conversation.Project)
+	result := capturedConversation{
+		Conversation: *conversation,
+		MessageCount: len(conversation.Messages),
 	}
-	if len(conversation.Tags) > 0 {
-		fmt.Printf("  Tags: %s\n", strings.Join(conversation.Tags, ", "))
-	}
-	fmt.Printf("  Messages: %d\n", len(conversation.Messages))
-
-	totalTokens := 0
+	result.Messages = nil
 	for _, msg := range conversation.Messages {
-		totalTokens += msg.TokenCount
+		result.TokenCount += msg.TokenCount
 	}
-	fmt.Printf("  Estimated tokens: %d\n", totalTokens)
 
-	return nil
+	return printResult(result, func() {
+		fmt.Printf("✓ Captured conversation (ID: %d)\n", conversation.ID)
+		fmt.Printf("  Title: %s\n", conversation.Title)
+		fmt.Printf("  Tool: %s\n", conversation.Tool)
+		if conversation.Project != "" {
+			fmt.Printf("  Project: %s\n", conversation.Project)
+		}
+		if len(conversation.Tags) > 0 {
+			fmt.Printf("  Tags: %s\n", strings.Join(conversation.Tags, ", "))
+		}
+		fmt.Printf("  Messages: %d\n", result.MessageCount)
+		fmt.Printf("  Estimated tokens: %d\n", result.TokenCount)
+	})
 }
\ No newline at end of file
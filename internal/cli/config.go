@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/config"
+	"github.com/jasperwreed/ai-memory/internal/daemon"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+var (
+	appConfig    *config.Config
+	appConfigErr error
+)
+
+// settings returns the config from the config file and environment. If it
+// cannot be loaded, the defaults are used and loadSettings reports why.
+func settings() *config.Config {
+	if appConfig == nil {
+		appConfig, appConfigErr = config.Load(config.Path())
+		if appConfigErr != nil {
+			appConfig = config.Builtin()
+		}
+	}
+	return appConfig
+}
+
+// loadSettings checks the output flags, loads the config and returns what is
+// wrong with them
+func loadSettings(cmd *cobra.Command, args []string) error {
+	if err := validateOutput(cmd); err != nil {
+		return err
+	}
+
+	// mem config must work with a broken config, to help fix it
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "config" && c.Parent() == cmd.Root() {
+			return nil
+		}
+	}
+
+	settings()
+	if appConfigErr != nil {
+		return fmt.Errorf("%w\nfix it with 'mem config set' or by editing the file", appConfigErr)
+	}
+	return nil
+}
+
+// storeRedactor returns the configured redactor, or nil if no patterns are set
+func storeRedactor() (*storage.Redactor, error) {
+	redaction := settings().Redaction
+	if len(redaction.Patterns) == 0 {
+		return nil, nil
+	}
+	return storage.NewRedactor(redaction.Patterns, redaction.Replacement)
+}
+
+// daemonConfig returns the daemon settings of cfg
+func daemonConfig(cfg *config.Config) *daemon.CaptureConfig {
+	d := daemon.DefaultConfig()
+	d.AuditDir = cfg.Storage.AuditDir
+	d.Database = cfg.Storage.Database
+	d.WatchDirs = cfg.Daemon.WatchDirs
+	d.FlushInterval = cfg.Daemon.FlushInterval
+	d.BatchSize = cfg.Daemon.BatchSize
+	d.MaxShardSize = cfg.Daemon.MaxShardSize
+	d.CompressShards = cfg.Daemon.CompressShards
+	d.Ingest = cfg.Daemon.Ingest
+	d.QueueMaxBytes = cfg.Daemon.QueueMaxBytes
+	d.LogFile = cfg.Daemon.LogFile
+	d.MetricsAddr = cfg.Daemon.MetricsAddr
+	d.AuditRecipients = cfg.Daemon.Recipients
+	d.DisabledScanners = cfg.Scanners.Disabled
+	return d
+}
+
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show and change settings",
+		Long: `Show and change settings in ~/.ai-memory/config.toml ($AI_MEMORY_CONFIG).
+
+Settings are grouped in sections: storage, daemon, scanners, redaction and
+pricing. Any setting can be overridden with an environment variable named
+AI_MEMORY_<SECTION>_<KEY>, e.g. AI_MEMORY_DAEMON_BATCH_SIZE. Lists are
+comma-separated, or a TOML array such as '["a,b", "c"]' if items contain
+commas. A running daemon picks up changes to daemon.watch_dirs; other daemon
+settings apply after a restart.`,
+		Example: `  # Show every setting in effect
+  mem config show
+
+  # Watch another directory
+  mem config set daemon.watch_dirs "~/transcripts, ~/work/sessions"
+
+  # Redact API keys before they are stored
+  mem config set redaction.patterns '["sk-[A-Za-z0-9_-]{20,}"]'
+
+  # Check a config file
+  mem config validate`,
+	}
+
+	cmd.AddCommand(
+		newConfigShowCommand(),
+		newConfigGetCommand(),
+		newConfigSetCommand(),
+		newConfigValidateCommand(),
+	)
+
+	return cmd
+}
+
+func newConfigShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show every setting in effect",
+		Long:  `Show the settings in effect after defaults and environment overrides, as TOML.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(config.Path())
+			if err != nil {
+				return err
+			}
+			data, err := cfg.Encode()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("# %s\n", config.Path())
+			if _, err := os.Stat(config.Path()); os.IsNotExist(err) {
+				fmt.Println("# (no config file; showing defaults)")
+			}
+			fmt.Print(string(data))
+			return nil
+		},
+	}
+}
+
+func newConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <section.key>",
+		Short: "Print one setting",
+		Long:  `Print the value in effect for one setting. Lists are printed one item per line.`,
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return config.Keys(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(config.Path())
+			if err != nil {
+				return err
+			}
+			value, err := cfg.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(value)
+			return nil
+		},
+	}
+}
+
+func newConfigSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <section.key> <value>",
+		Short: "Change one setting in the config file",
+		Long: `Change one setting in the config file, creating it if needed. The file is
+only written if the result is valid. Lists are given comma-separated or as a
+TOML array. Comments in the file are not kept.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return config.Keys(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+			if err := config.SetInFile(config.Path(), key, value); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Set %s in %s\n", key, config.Path())
+
+			if strings.HasPrefix(key, "daemon.") && key != "daemon.watch_dirs" {
+				if _, running := daemon.RunningPID(settings().Storage.AuditDir); running {
+					fmt.Println("ℹ️  Restart the daemon to apply this setting")
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Check a config file",
+		Long:  `Check a config file, by default the one in use, and list every problem found.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := config.Path()
+			if len(args) == 1 {
+				path = args[0]
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read config file: %w", err)
+			}
+			if _, err := config.Parse(path, data); err != nil {
+				return err
+			}
+			fmt.Printf("✓ %s is valid\n", path)
+			return nil
+		},
+	}
+}
\ No newline at end of file
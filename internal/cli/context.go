@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/capture"
+	"github.com/jasperwreed/ai-memory/internal/search"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+func NewContextCommand() *cobra.Command {
+	var budget int
+	var format string
+	var ids []int64
+	var filter storage.ConversationFilter
+
+	cmd := &cobra.Command{
+		Use:   "context [query]",
+		Short: "Build a context block from past conversations for a new AI session",
+		Long: `Select the past messages most relevant to a query, or from a project or
+chosen conversations, and print them as one Markdown or XML block that fits a
+token budget. Matches are ranked by search relevance and recency; newer messages
+win ties. Each message cites its source as mem:<conversation>#<message>.
+
+Without a query, the most recent messages of the matching conversations are used.
+--project . means the project of the current directory. The block uses
+all_conversations.db unless --db is given.`,
+		Example: `  # Prime a new session with what was learned about migrations
+  mem context "database migration" | claude -p "Continue the migration work"
+
+  # Recent work on the current project, as XML
+  mem context --project . --format xml --budget 4000
+
+  # Specific conversations, written to a file CLAUDE.md includes
+  mem context --ids 12,40 > .claude/past-context.md`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := strings.Join(args, " ")
+			if query == "" && len(ids) == 0 && filter.Project == "" && filter.Tool == "" && filter.Tag == "" {
+				return fmt.Errorf("give a query, --project, --tool, --tag or --ids")
+			}
+			if query != "" && len(ids) > 0 {
+				return fmt.Errorf("--ids cannot be combined with a query")
+			}
+			if budget <= 0 {
+				return fmt.Errorf("--budget must be positive")
+			}
+			if format != "markdown" && format != "xml" {
+				return fmt.Errorf("unknown format %q (expected markdown or xml)", format)
+			}
+			project, err := NewValidator().ParseProject(filter.Project)
+			if err != nil {
+				return err
+			}
+			filter.Project = project
+
+			return runContext(search.ContextOptions{
+				Query:  query,
+				Filter: filter,
+				IDs:    ids,
+				Budget: budget,
+			}, format)
+		},
+	}
+
+	cmd.Flags().IntVar(&budget, "budget", 8000, "Maximum estimated tokens in the block")
+	cmd.Flags().StringVar(&format, "format", "markdown", "Block format: markdown or xml")
+	cmd.Flags().Int64SliceVar(&ids, "ids", nil, "Comma-separated conversation IDs to take messages from")
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Only use conversations of this project (. for the current directory)")
+	cmd.Flags().StringVar(&filter.Tool, "tool", "", "Only use conversations of this tool")
+	cmd.Flags().StringVar(&filter.Tag, "tag", "", "Only use conversations with this tag")
+
+	return withResults(cmd)
+}
+
+func runContext(opts search.ContextOptions, format string) error {
+	database := dbPath
+	if database == "" {
+		database = settings().Storage.Database
+	}
+
+	store, err := openStore(database)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	pack, err := search.NewSearcher(store).Context(opts, capture.NewSimpleTokenEstimator())
+	if err != nil {
+		return fmt.Errorf("failed to build context: %w", err)
+	}
+
+	// stdout carries only the block, so it can be piped
+	if len(pack.Sources) == 0 {
+		fmt.Fprintln(os.Stderr, "ℹ️  No matching conversations found.")
+	} else {
+		fmt.Fprintf(os.Stderr, "📝 %d conversation(s), about %d of %d tokens\n", len(pack.Sources), pack.Tokens, pack.Budget)
+	}
+
+	return printResult(pack, func() {
+		if len(pack.Sources) == 0 {
+			return
+		}
+		if format == "xml" {
+			fmt.Print(pack.XML())
+		} else {
+			fmt.Print(pack.Markdown())
+		}
+	})
+}
\ No newline at end of file
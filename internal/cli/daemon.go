@@ -2,14 +2,20 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/jasperwreed/ai-memory/internal/audit"
+	"github.com/jasperwreed/ai-memory/internal/config"
 	"github.com/jasperwreed/ai-memory/internal/daemon"
+	"github.com/jasperwreed/ai-memory/internal/watcher"
 )
 
 func NewDaemonCommand() *cobra.Command {
@@ -20,12 +26,24 @@ func NewDaemonCommand() *cobra.Command {
 		Example: `  # Start the daemon
   mem daemon start
 
+  # Start the daemon detached from the terminal
+  mem daemon start -b
+
+  # Run the daemon as a systemd user service or launchd agent
+  mem daemon install
+
   # Stop the daemon
   mem daemon stop
 
   # Check daemon status
   mem daemon status
 
+  # List the session files being captured
+  mem daemon sessions
+
+  # Watch another directory without restarting
+  mem daemon watch ~/transcripts
+
   # View audit logs
   mem daemon logs
 
@@ -38,6 +56,18 @@ func NewDaemonCommand() *cobra.Command {
 		newDaemonStopCommand(),
 		newDaemonStatusCommand(),
 		newDaemonLogsCommand(),
+		newDaemonInstallCommand(),
+		newDaemonSessionsCommand(),
+		newDaemonWatchCommand(),
+		newDaemonUnwatchCommand(),
+		newDaemonControlCommand("flush", "Write captured lines now instead of at the next flush",
+			"✓ Flushed", (*daemon.Client).Flush),
+		newDaemonControlCommand("pause", "Stop reading session files until resumed",
+			"⏸  Capture paused", (*daemon.Client).Pause),
+		newDaemonControlCommand("resume", "Read session files again, catching up on what was written while paused",
+			"▶  Capture resumed", (*daemon.Client).Resume),
+		newDaemonControlCommand("reload", "Reload the config file, applying changed watch directories",
+			"✓ Config reloaded", (*daemon.Client).Reload),
 	)
 
 	return cmd
@@ -46,22 +76,45 @@ func NewDaemonCommand() *cobra.Command {
 func newDaemonStartCommand() *cobra.Command {
 	var background bool
 	var configFile string
+	var recipients []string
+	var metricsAddr string
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the auto-capture daemon",
 		Long:  `Start the background daemon that watches for AI tool session files.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config := daemon.DefaultConfig()
-
-			// Load custom config if provided
+			// Watch directories follow the config file while running
+			reloadPath := config.Path()
 			if configFile != "" {
-				data, err := os.ReadFile(configFile)
+				reloadPath = absPath(configFile)
+			}
+
+			config, err := loadDaemonConfig(configFile)
+			if err != nil {
+				return err
+			}
+
+			if dbPath != "" {
+				config.Database = dbPath
+			}
+			if metricsAddr != "" {
+				config.MetricsAddr = metricsAddr
+			}
+
+			if background {
+				return startDetached(config, daemonStartArgs(configFile, recipients, metricsAddr))
+			}
+
+			// Encrypt to explicit recipients, or to the audit key file if there is one
+			config.AuditRecipients = append(config.AuditRecipients, recipients...)
+			if len(config.AuditRecipients) == 0 {
+				keyRecipients, err := auditRecipients()
 				if err != nil {
-					return fmt.Errorf("failed to read config file: %w", err)
+					return err
 				}
-				if err := json.Unmarshal(data, config); err != nil {
-					return fmt.Errorf("failed to parse config file: %w", err)
+				for _, recipient := range keyRecipients {
+					config.AuditRecipients = append(config.AuditRecipients, recipient.String())
 				}
 			}
 
@@ -69,16 +122,11 @@ func newDaemonStartCommand() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to create daemon: %w", err)
 			}
+			d.SetStoreOpener(openStore)
 
-			if background {
-				// Start in background and return
-				if err := d.Start(); err != nil {
-					return fmt.Errorf("failed to start daemon: %w", err)
-				}
-				fmt.Println("Daemon started in background")
-				fmt.Printf("Audit logs: %s\n", config.AuditDir)
-				return nil
-			}
+			d.SetReloader(reloadPath, func() (*daemon.CaptureConfig, error) {
+				return loadDaemonConfig(configFile)
+			})
 
 			// Run in foreground
 			fmt.Println("Starting daemon in foreground (Ctrl+C to stop)...")
@@ -88,59 +136,170 @@ func newDaemonStartCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&background, "background", "b", false, "Run daemon in background")
-	cmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to config file")
+	cmd.Flags().StringVarP(&configFile, "config", "c", "", "Config file to use instead of ~/.ai-memory/config.toml")
+	cmd.Flags().StringArrayVar(&recipients, "recipient", nil, "Encrypt audit shards to this public key (repeatable)")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address, e.g. 127.0.0.1:9464")
 
 	return cmd
 }
 
+// loadDaemonConfig returns the daemon settings from the config file, or from
+// file if given. A .json file holds only daemon settings, as written before
+// config.toml existed, applied over the config file.
+func loadDaemonConfig(file string) (*daemon.CaptureConfig, error) {
+	if file == "" {
+		cfg, err := config.Load(config.Path())
+		if err != nil {
+			return nil, err
+		}
+		return daemonConfig(cfg), nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(file), ".json") {
+		cfg, err := config.Load(file)
+		if err != nil {
+			return nil, err
+		}
+		return daemonConfig(cfg), nil
+	}
+
+	captureConfig := daemonConfig(settings())
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, captureConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := captureConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", file, err)
+	}
+	return captureConfig, nil
+}
+
+// daemonStartArgs returns the arguments that start the daemon in the
+// foreground with the same settings. Paths are made absolute, since the
+// daemon does not run in the current directory.
+func daemonStartArgs(configFile string, recipients []string, metricsAddr string) []string {
+	args := []string{"daemon", "start"}
+	if configFile != "" {
+		args = append(args, "--config", absPath(configFile))
+	}
+	for _, recipient := range recipients {
+		args = append(args, "--recipient", recipient)
+	}
+	if metricsAddr != "" {
+		args = append(args, "--metrics-addr", metricsAddr)
+	}
+	if dbPath != "" {
+		args = append(args, "--db", absPath(dbPath))
+	}
+	if dbKeyFile != "" {
+		args = append(args, "--db-key-file", absPath(dbKeyFile))
+	}
+	if dbKeyCmd != "" {
+		args = append(args, "--db-key-cmd", dbKeyCmd)
+	}
+	return args
+}
+
+// absPath returns path made absolute, or unchanged if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// startDetached starts the daemon in a new session and waits until it holds
+// the instance lock
+func startDetached(config *daemon.CaptureConfig, args []string) error {
+	if pid, running := daemon.RunningPID(config.AuditDir); running {
+		return fmt.Errorf("daemon already running (PID %d)", pid)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.LogFile), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	process, err := daemon.Detach(args, config.LogFile)
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		process.Wait()
+		close(exited)
+	}()
+
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case <-exited:
+			return fmt.Errorf("daemon exited during startup, see %s", config.LogFile)
+		case <-deadline:
+			return fmt.Errorf("daemon did not start within 10s, see %s", config.LogFile)
+		case <-time.After(100 * time.Millisecond):
+			if pid, running := daemon.RunningPID(config.AuditDir); running && pid == process.Pid {
+				fmt.Printf("✓ Daemon started in background (PID %d)\n", pid)
+				fmt.Printf("Audit logs: %s\n", config.AuditDir)
+				fmt.Printf("Daemon log: %s\n", config.LogFile)
+				return nil
+			}
+		}
+	}
+}
+
 func newDaemonStopCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the auto-capture daemon",
 		Long:  `Stop the running auto-capture daemon.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				return err
-			}
-
-			pidFile := filepath.Join(homeDir, ".ai-memory", "audit", "daemon.pid")
+			auditDir := settings().Storage.AuditDir
 
-			// Read PID file
-			data, err := os.ReadFile(pidFile)
-			if err != nil {
-				if os.IsNotExist(err) {
-					fmt.Println("Daemon is not running")
-					return nil
-				}
-				return fmt.Errorf("failed to read PID file: %w", err)
+			pid, running := daemon.RunningPID(auditDir)
+			if !running {
+				// Clear a PID file left by a daemon that did not shut down cleanly
+				os.Remove(filepath.Join(auditDir, "daemon.pid"))
+				fmt.Println("Daemon is not running")
+				return nil
 			}
 
-			var pid int
-			fmt.Sscanf(string(data), "%d", &pid)
-
-			// Find and terminate process
-			process, err := os.FindProcess(pid)
-			if err != nil {
-				return fmt.Errorf("failed to find process: %w", err)
+			// The daemon replies once it has flushed everything
+			err := daemon.NewClient(auditDir).Shutdown()
+			if errors.Is(err, daemon.ErrNotRunning) {
+				// Not serving the control socket (yet); fall back to a signal
+				err = interruptProcess(pid)
 			}
-
-			if err := process.Signal(os.Interrupt); err != nil {
+			if err != nil {
 				return fmt.Errorf("failed to stop daemon: %w", err)
 			}
 
-			// Wait a moment for graceful shutdown
-			time.Sleep(2 * time.Second)
-
-			// Remove PID file
-			os.Remove(pidFile)
-
-			fmt.Println("Daemon stopped")
-			return nil
+			// Wait for the process to exit and release its lock
+			deadline := time.Now().Add(10 * time.Second)
+			for time.Now().Before(deadline) {
+				if _, running := daemon.RunningPID(auditDir); !running {
+					fmt.Println("Daemon stopped")
+					return nil
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			return fmt.Errorf("daemon (PID %d) did not stop within 10s", pid)
 		},
 	}
 }
 
+// interruptProcess asks the process with pid to shut down
+func interruptProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process: %w", err)
+	}
+	return process.Signal(os.Interrupt)
+}
+
 func newDaemonStatusCommand() *cobra.Command {
 	var detailed bool
 
@@ -149,61 +308,67 @@ func newDaemonStatusCommand() *cobra.Command {
 		Short: "Show daemon status",
 		Long:  `Display the current status of the auto-capture daemon.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				return err
-			}
-
-			auditDir := filepath.Join(homeDir, ".ai-memory", "audit")
+			auditDir := settings().Storage.AuditDir
 			status, err := daemon.GetStatus(auditDir)
 			if err != nil {
 				return fmt.Errorf("failed to get status: %w", err)
 			}
 
-			if status.Status == "stopped" {
-				fmt.Println("Daemon status: stopped")
-				return nil
-			}
-
-			fmt.Printf("Daemon status: %s\n", status.Status)
-			fmt.Printf("PID: %d\n", status.PID)
-			fmt.Printf("Updated: %s\n", status.UpdatedAt.Format("2006-01-02 15:04:05"))
-
-			if status.Metrics != nil {
-				fmt.Println("\nMetrics:")
-				fmt.Printf("  Events received: %d\n", status.Metrics.EventsReceived)
-				fmt.Printf("  Events processed: %d\n", status.Metrics.EventsProcessed)
-				fmt.Printf("  Events dropped: %d\n", status.Metrics.EventsDropped)
-				fmt.Printf("  Bytes written: %d\n", status.Metrics.BytesWritten)
-				fmt.Printf("  Active sessions: %d\n", status.Metrics.ActiveSessions)
-				fmt.Printf("  Running since: %s\n", status.Metrics.StartTime.Format("2006-01-02 15:04:05"))
+			return printResult(status, func() {
+				if status.Status == "stopped" {
+					fmt.Println("Daemon status: stopped")
+					return
+				}
 
-				if !status.Metrics.LastEventTime.IsZero() {
-					fmt.Printf("  Last event: %s\n", status.Metrics.LastEventTime.Format("2006-01-02 15:04:05"))
+				fmt.Printf("Daemon status: %s\n", status.Status)
+				fmt.Printf("PID: %d\n", status.PID)
+
+				if status.Metrics != nil {
+					fmt.Println("\nMetrics:")
+					fmt.Printf("  Events received: %d\n", status.Metrics.EventsReceived)
+					fmt.Printf("  Events processed: %d\n", status.Metrics.EventsProcessed)
+					fmt.Printf("  Events dropped: %d\n", status.Metrics.EventsDropped)
+					fmt.Printf("  Bytes written: %d\n", status.Metrics.BytesWritten)
+					fmt.Printf("  Active sessions: %d\n", status.Metrics.ActiveSessions)
+					fmt.Printf("  Lines ingested: %d\n", status.Metrics.LinesIngested)
+					fmt.Printf("  Lines failed: %d\n", status.Metrics.LinesFailed)
+					fmt.Printf("  Running since: %s\n", status.Metrics.StartTime.Format("2006-01-02 15:04:05"))
+
+					if !status.Metrics.LastEventTime.IsZero() {
+						fmt.Printf("  Last event: %s\n", status.Metrics.LastEventTime.Format("2006-01-02 15:04:05"))
+					}
 				}
-			}
 
-			if detailed && status.Config != nil {
-				fmt.Println("\nConfiguration:")
-				fmt.Printf("  Audit directory: %s\n", status.Config.AuditDir)
-				fmt.Printf("  Max shard size: %d MB\n", status.Config.MaxShardSize/(1024*1024))
-				fmt.Printf("  Compress shards: %v\n", status.Config.CompressShards)
-				fmt.Printf("  Batch size: %d\n", status.Config.BatchSize)
-				fmt.Printf("  Flush interval: %s\n", status.Config.FlushInterval)
+				if detailed && status.Config != nil {
+					fmt.Println("\nConfiguration:")
+					fmt.Printf("  Audit directory: %s\n", status.Config.AuditDir)
+					fmt.Printf("  Max shard size: %d MB\n", status.Config.MaxShardSize/(1024*1024))
+					fmt.Printf("  Compress shards: %v\n", status.Config.CompressShards)
+					fmt.Printf("  Encrypt shards: %v\n", len(status.Config.AuditRecipients) > 0)
+					fmt.Printf("  Batch size: %d\n", status.Config.BatchSize)
+					fmt.Printf("  Flush interval: %s\n", status.Config.FlushInterval)
+					if status.Config.Ingest {
+						fmt.Printf("  Ingest into: %s\n", status.Config.Database)
+					}
+					if status.Config.LogFile != "" {
+						fmt.Printf("  Log file: %s\n", status.Config.LogFile)
+					}
+					if status.Config.MetricsAddr != "" {
+						fmt.Printf("  Metrics: http://%s/metrics\n", status.Config.MetricsAddr)
+					}
 
-				fmt.Println("\n  Watch directories:")
-				for _, dir := range status.Config.WatchDirs {
-					fmt.Printf("    - %s\n", dir)
+					fmt.Println("\n  Watch directories:")
+					for _, dir := range status.Watching {
+						fmt.Printf("    - %s\n", dir)
+					}
 				}
-			}
-
-			return nil
+			})
 		},
 	}
 
 	cmd.Flags().BoolVarP(&detailed, "detailed", "d", false, "Show detailed status including configuration")
 
-	return cmd
+	return withResults(cmd)
 }
 
 func newDaemonLogsCommand() *cobra.Command {
@@ -217,12 +382,7 @@ func newDaemonLogsCommand() *cobra.Command {
 		Short: "View audit logs",
 		Long:  `Display audit logs captured by the daemon.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				return err
-			}
-
-			auditDir := filepath.Join(homeDir, ".ai-memory", "audit")
+			auditDir := settings().Storage.AuditDir
 
 			// Create streamer
 			streamer := audit.NewStreamer(auditDir)
@@ -247,47 +407,7 @@ func newDaemonLogsCommand() *cobra.Command {
 					}
 				}
 
-				// Try to pretty-print JSON
-				var data map[string]interface{}
-				if err := json.Unmarshal(line, &data); err == nil {
-					// Format timestamp if present
-					if ts, ok := data["timestamp"].(float64); ok {
-						timestamp := time.Unix(int64(ts), 0)
-						fmt.Printf("[%s] ", timestamp.Format("15:04:05"))
-					}
-
-					// Print key fields
-					if tool, ok := data["tool"].(string); ok {
-						fmt.Printf("<%s> ", tool)
-					}
-					if eventType, ok := data["type"].(string); ok {
-						fmt.Printf("%s ", eventType)
-					}
-					if session, ok := data["session"].(string); ok && session != "" {
-						fmt.Printf("(session: %.8s) ", session)
-					}
-
-					// Print raw content if available
-					if raw, ok := data["raw"].(string); ok {
-						// Truncate long lines
-						if len(raw) > 100 {
-							raw = raw[:97] + "..."
-						}
-						fmt.Print(raw)
-					} else {
-						// Print the whole JSON
-						output, _ := json.Marshal(data)
-						fmt.Print(string(output))
-					}
-				} else {
-					// Print raw line if not JSON
-					fmt.Print(string(line))
-				}
-
-				if len(line) == 0 || line[len(line)-1] != '\n' {
-					fmt.Println()
-				}
-
+				printAuditLine(line)
 				return nil
 			}
 
@@ -302,4 +422,234 @@ func newDaemonLogsCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&tool, "tool", "t", "", "Filter by tool name")
 
 	return cmd
+}
+
+func newDaemonInstallCommand() *cobra.Command {
+	var launchd bool
+	var systemd bool
+	var printOnly bool
+	var configFile string
+	var recipients []string
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the daemon as a user service",
+		Long: `Write a systemd user unit (Linux) or launchd agent (macOS) that runs the
+daemon at login and restarts it if it fails.`,
+		Example: `  # Install for the current platform
+  mem daemon install
+
+  # Print the launchd agent instead of writing it
+  mem daemon install --launchd --print`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if launchd && systemd {
+				return fmt.Errorf("--launchd and --systemd are mutually exclusive")
+			}
+			if !launchd && !systemd {
+				launchd = runtime.GOOS == "darwin"
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to find executable: %w", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = resolved
+			}
+			// Catch config mistakes now rather than when the service starts
+			captureConfig, err := loadDaemonConfig(configFile)
+			if err != nil {
+				return err
+			}
+			startArgs := daemonStartArgs(configFile, recipients, metricsAddr)
+
+			var content, path string
+			if launchd {
+				content = daemon.LaunchdPlist(exe, startArgs, captureConfig.LogFile)
+				path, err = daemon.LaunchdPlistPath()
+			} else {
+				content = daemon.SystemdUnit(exe, startArgs)
+				path, err = daemon.SystemdUnitPath()
+			}
+			if err != nil {
+				return err
+			}
+
+			if printOnly {
+				fmt.Print(content)
+				return nil
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create service directory: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write service file: %w", err)
+			}
+
+			fmt.Printf("✓ Wrote %s\n", path)
+			fmt.Println("\nTo start the daemon now and at every login:")
+			if launchd {
+				fmt.Printf("  launchctl load -w %s\n", path)
+			} else {
+				fmt.Println("  systemctl --user daemon-reload")
+				fmt.Printf("  systemctl --user enable --now %s\n", daemon.ServiceName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&launchd, "launchd", false, "Write a launchd agent (default on macOS)")
+	cmd.Flags().BoolVar(&systemd, "systemd", false, "Write a systemd user unit (default elsewhere)")
+	cmd.Flags().BoolVar(&printOnly, "print", false, "Print the service file instead of writing it")
+	cmd.Flags().StringVarP(&configFile, "config", "c", "", "Config file for the installed daemon")
+	cmd.Flags().StringArrayVar(&recipients, "recipient", nil, "Encrypt audit shards to this public key (repeatable)")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address, e.g. 127.0.0.1:9464")
+
+	return cmd
+}
+
+// daemonClient returns a client for the daemon writing to the configured audit directory
+func daemonClient() (*daemon.Client, error) {
+	return daemon.NewClient(settings().Storage.AuditDir), nil
+}
+
+// newDaemonControlCommand returns a subcommand sending one command to the daemon
+func newDaemonControlCommand(use, short, done string, send func(*daemon.Client) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			if err := send(client); err != nil {
+				return err
+			}
+			fmt.Println(done)
+			return nil
+		},
+	}
+}
+
+func newDaemonSessionsCommand() *cobra.Command {
+	return withResults(&cobra.Command{
+		Use:   "sessions",
+		Short: "List the session files the daemon is capturing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := daemonClient()
+			if err != nil {
+				return err
+			}
+
+			sessions, err := client.Sessions()
+			if err != nil {
+				return err
+			}
+			if sessions == nil {
+				sessions = []watcher.SessionInfo{}
+			}
+
+			sort.Slice(sessions, func(i, j int) bool { return sessions[i].Path < sessions[j].Path })
+			return printResult(sessions, func() {
+				if len(sessions) == 0 {
+					fmt.Println("No active sessions")
+					return
+				}
+				fmt.Printf("📂 %d active sessions\n\n", len(sessions))
+				for _, session := range sessions {
+					fmt.Printf("%s\n", session.Path)
+					fmt.Printf("   Tool: %s", session.Tool)
+					if session.SessionID != "" {
+						fmt.Printf(" | Session: %.8s", session.SessionID)
+					}
+					fmt.Printf(" | Read: %d/%d bytes | Since: %s\n",
+						session.Offset, session.Size, session.StartTime.Format("2006-01-02 15:04:05"))
+				}
+			})
+		},
+	})
+}
+
+func newDaemonWatchCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch <dir>",
+		Short: "Watch another directory tree for session files",
+		Long: `Start capturing *.jsonl session files under a directory, without restarting
+the daemon. The watch lasts until the daemon stops; add the directory to
+watch_dirs in the daemon config to keep it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			dir := absPath(args[0])
+			if err := client.Watch(dir); err != nil {
+				return err
+			}
+			fmt.Printf("👀 Watching %s\n", dir)
+			return nil
+		},
+	}
+}
+
+func newDaemonUnwatchCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unwatch <dir>",
+		Short: "Stop watching a directory tree",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			dir := absPath(args[0])
+			if err := client.Unwatch(dir); err != nil {
+				return err
+			}
+			fmt.Printf("✓ No longer watching %s\n", dir)
+			return nil
+		},
+	}
+}
+
+// printAuditLine prints a one-line summary of an audit record
+func printAuditLine(line []byte) {
+	rec, err := audit.ParseRecord(line)
+	if err != nil {
+		// Print raw line if it isn't an audit record
+		fmt.Println(strings.TrimRight(string(line), "\n"))
+		return
+	}
+
+	if !rec.IngestTime.IsZero() {
+		fmt.Printf("[%s] ", rec.IngestTime.Local().Format("15:04:05"))
+	}
+	if rec.Tool != "" {
+		fmt.Printf("<%s> ", rec.Tool)
+	}
+	if rec.SessionID != "" {
+		fmt.Printf("(session: %.8s) ", rec.SessionID)
+	}
+	if rec.Offset >= 0 {
+		fmt.Printf("@%d ", rec.Offset)
+	}
+
+	if rec.HasPayload() {
+		// Truncate long lines
+		raw := strings.TrimRight(string(rec.Payload()), "\r\n")
+		if len(raw) > 100 {
+			raw = raw[:97] + "..."
+		}
+		fmt.Println(raw)
+	} else if rec.SourcePath != "" {
+		fmt.Println(rec.SourcePath)
+	} else {
+		fmt.Println()
+	}
 }
\ No newline at end of file
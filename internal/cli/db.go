@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// Environment variables that configure the database key when the flags are not set
+const (
+	dbKeyFileEnv = "AI_MEMORY_DB_KEY_FILE"
+	dbKeyCmdEnv  = "AI_MEMORY_DB_KEY_CMD"
+)
+
+var (
+	dbKeyFile string
+	dbKeyCmd  string
+)
+
+func NewDBCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage database encryption",
+		Long: `Manage encryption of the conversation database.
+
+An encrypted database stores message content, shell commands and raw JSON
+encrypted with a key from a key file (--db-key-file, $AI_MEMORY_DB_KEY_FILE,
+default ~/.ai-memory/db.key) or printed by a command such as an OS keyring
+helper (--db-key-cmd, $AI_MEMORY_DB_KEY_CMD). The search index holds keyed
+hashes of words instead of the words themselves, so full-word search keeps
+working but prefix searches (foo*) do not. Titles, tags, tools, projects and paths, including
+those of files sessions touched, stay readable so listing does not need the key.`,
+		Example: `  # Encrypt the default database, creating ~/.ai-memory/db.key
+  mem db encrypt
+
+  # Keep the key in the OS keyring instead
+  export AI_MEMORY_DB_KEY_CMD="secret-tool lookup service ai-memory"
+  mem db encrypt
+
+  # Convert back to plaintext
+  mem db decrypt`,
+	}
+
+	cmd.AddCommand(
+		newDBEncryptCommand(),
+		newDBDecryptCommand(),
+	)
+
+	return cmd
+}
+
+func newDBEncryptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt an existing database in place",
+		Long: `Encrypt message content, shell commands and raw JSON of an existing database
+and replace its search index with a blinded one. If no key is configured, a new key file is
+created first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := resolveDatabasePath()
+			if err != nil {
+				return err
+			}
+
+			// A key file that does not exist yet is created
+			cipher, err := databaseCipher()
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if cipher == nil {
+				if cipher, err = createDatabaseKey(); err != nil {
+					return err
+				}
+			}
+
+			fmt.Printf("🔒 Encrypting %s\n", database)
+			result, err := storage.EncryptDatabase(database, cipher)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt database: %w", err)
+			}
+
+			fmt.Printf("✓ Encrypted %d messages in %d conversations\n", result.Messages, result.Conversations)
+			return nil
+		},
+	}
+}
+
+func newDBDecryptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt",
+		Short: "Convert an encrypted database back to plaintext",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := resolveDatabasePath()
+			if err != nil {
+				return err
+			}
+
+			cipher, err := databaseCipher()
+			if err != nil {
+				return err
+			}
+			if cipher == nil {
+				return fmt.Errorf("no database key configured")
+			}
+
+			fmt.Printf("🔓 Decrypting %s\n", database)
+			result, err := storage.DecryptDatabase(database, cipher)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt database: %w", err)
+			}
+
+			fmt.Printf("✓ Decrypted %d messages in %d conversations\n", result.Messages, result.Conversations)
+			return nil
+		},
+	}
+}
+
+// resolveDatabasePath returns --db if set, otherwise the default database
+func resolveDatabasePath() (string, error) {
+	if dbPath != "" {
+		return dbPath, nil
+	}
+	return NewValidator().GetDefaultDatabasePath()
+}
+
+// openStore opens a database, encrypted when a database key is configured.
+// Databases that predate the key are opened as plaintext with a warning so
+// they stay usable until they are converted with 'mem db encrypt'.
+func openStore(database string) (*storage.SQLiteStore, error) {
+	redactor, err := storeRedactor()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := openStoreWithKey(database)
+	if err != nil {
+		return nil, err
+	}
+	store.SetRedactor(redactor)
+	return store, nil
+}
+
+// openDefaultStore opens the database given with --db, or else the database
+// of all imported conversations
+func openDefaultStore() (*storage.SQLiteStore, error) {
+	database := dbPath
+	if database == "" {
+		database = settings().Storage.Database
+	}
+
+	store, err := openStore(database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return store, nil
+}
+
+// openStoreWithKey opens the database, encrypted if a key is configured
+func openStoreWithKey(database string) (*storage.SQLiteStore, error) {
+	cipher, err := databaseCipher()
+	if err != nil {
+		return nil, err
+	}
+	if cipher == nil {
+		return storage.NewSQLiteStore(database)
+	}
+
+	store, err := storage.NewEncryptedSQLiteStore(database, cipher)
+	if errors.Is(err, storage.ErrNotEncrypted) {
+		fmt.Fprintf(os.Stderr, "⚠️  %s is not encrypted; run 'mem db encrypt --db %s'\n", database, database)
+		return storage.NewSQLiteStore(database)
+	}
+	return store, err
+}
+
+// databaseCipher returns the cipher for the configured database key, or nil
+// if no key is configured
+func databaseCipher() (*storage.Cipher, error) {
+	material, err := readDatabaseKey()
+	if err != nil || material == nil {
+		return nil, err
+	}
+	return storage.NewCipher(material)
+}
+
+// readDatabaseKey returns the key material from the key command, the key
+// file, or the default key file if it exists
+func readDatabaseKey() ([]byte, error) {
+	if command := databaseKeyCmd(); command != "" {
+		out, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to run database key command: %w", err)
+		}
+		return out, nil
+	}
+
+	keyFile := databaseKeyFile()
+	if keyFile == "" {
+		keyFile = defaultDatabaseKeyFile()
+		if _, err := os.Stat(keyFile); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database key: %w", err)
+	}
+	return data, nil
+}
+
+// createDatabaseKey writes a new random key to the configured or default key file
+func createDatabaseKey() (*storage.Cipher, error) {
+	keyFile := databaseKeyFile()
+	if keyFile == "" {
+		keyFile = defaultDatabaseKeyFile()
+	}
+
+	material, err := storage.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(keyFile, append(material, '\n'), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	fmt.Printf("🔑 Created key file %s (back it up: without it the database cannot be read)\n", keyFile)
+	return storage.NewCipher(material)
+}
+
+// flagOrEnv returns value, or the environment variable env if value is empty
+func flagOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return strings.TrimSpace(os.Getenv(env))
+}
+
+// databaseKeyFile returns the key file set by flag, environment or config
+func databaseKeyFile() string {
+	if keyFile := flagOrEnv(dbKeyFile, dbKeyFileEnv); keyFile != "" {
+		return keyFile
+	}
+	return settings().Storage.DBKeyFile
+}
+
+// databaseKeyCmd returns the key command set by flag, environment or config
+func databaseKeyCmd() string {
+	if command := flagOrEnv(dbKeyCmd, dbKeyCmdEnv); command != "" {
+		return command
+	}
+	return settings().Storage.DBKeyCmd
+}
+
+// defaultDatabaseKeyFile returns the database key file used when none is set
+func defaultDatabaseKeyFile() string {
+	return filepath.Join(settings().Storage.DataDir, "db.key")
+}
\ No newline at end of file
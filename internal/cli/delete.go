@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewDeleteCommand() *cobra.Command {
@@ -36,7 +35,7 @@ func NewDeleteCommand() *cobra.Command {
 }
 
 func runDelete(id int64, skipConfirm bool) error {
-	store, err := storage.NewSQLiteStore(dbPath)
+	store, err := openStore(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
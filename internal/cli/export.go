@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewExportCommand() *cobra.Command {
@@ -41,7 +40,7 @@ func runExport(id int64, format string) error {
 		return fmt.Errorf("only JSON format is currently supported")
 	}
 
-	store, err := storage.NewSQLiteStore(dbPath)
+	store, err := openStore(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
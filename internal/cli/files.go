@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// accessOrder is the order kinds of file access are listed in
+var accessOrder = []string{models.FileWrite, models.FileEdit, models.FileRead, models.FileCommand, models.FileMention}
+
+func NewFilesCommand() *cobra.Command {
+	var access []string
+	var filter storage.ConversationFilter
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "files <path>",
+		Short: "Find the conversations that worked on a file",
+		Long: `List the conversations that read, edited, wrote, ran or mentioned a file, most
+recent first. Files are recorded from Claude Code tool calls and from paths named
+in messages, relative to the session's working directory.
+
+A path inside a git repository is taken relative to the repository root, so
+'mem files sqlite.go' in internal/storage finds internal/storage/sqlite.go. A
+directory matches every file in it. Access kinds are write, edit, read, command
+and mention. Conversations come from all_conversations.db unless --db is given.`,
+		Example: `  # Which AI sessions edited this file?
+  mem files internal/storage/sqlite.go --access edit,write
+
+  # Everything that touched a package
+  mem files internal/storage/ --project api`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, a := range access {
+				if !validAccess(a) {
+					return fmt.Errorf("unknown access %q (expected %s)", a, strings.Join(accessOrder, ", "))
+				}
+			}
+			return runFiles(repoPath(args[0]), filter, access, limit)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&access, "access", nil, "Only count these kinds of access (write, edit, read, command, mention)")
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Only list conversations of this project")
+	cmd.Flags().StringVar(&filter.Tool, "tool", "", "Only list conversations of this tool")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of conversations to list")
+
+	return withResults(cmd)
+}
+
+func runFiles(path string, filter storage.ConversationFilter, access []string, limit int) error {
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	results, err := store.FileConversations(path, filter, access, limit)
+	if err != nil {
+		return fmt.Errorf("failed to find conversations: %w", err)
+	}
+
+	return printResult(results, func() {
+		if len(results) == 0 {
+			fmt.Printf("No conversations found for %s.\n", path)
+			return
+		}
+
+		fmt.Printf("🔍 %d conversation(s) touched %s:\n\n", len(results), path)
+		for _, r := range results {
+			conv := r.Conversation
+			fmt.Printf("[ID: %d] %s\n", conv.ID, conv.Title)
+			fmt.Printf("  Tool: %s", conv.Tool)
+			if conv.Project != "" {
+				fmt.Printf(" | Project: %s", conv.Project)
+			}
+			if conv.SessionID != "" {
+				fmt.Printf(" | Session: %s", conv.SessionID)
+			}
+			fmt.Printf("\n  Created: %s\n", conv.CreatedAt.Format("2006-01-02 15:04:05"))
+			for _, f := range r.Files {
+				fmt.Printf("  %s: %s\n", f.Path, formatAccess(f.Access))
+			}
+			fmt.Println()
+		}
+	})
+}
+
+// formatAccess lists how many times each kind of access happened
+func formatAccess(access map[string]int) string {
+	var parts []string
+	for _, kind := range accessOrder {
+		if n := access[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s ×%d", kind, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func validAccess(access string) bool {
+	for _, kind := range accessOrder {
+		if access == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// repoPath turns a path given on the command line into the form files are
+// recorded in: relative to the root of the git repository holding it. Paths
+// outside a repository are used as given.
+func repoPath(arg string) string {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return arg
+	}
+	root := findRepoRoot(abs)
+	if root == "" {
+		return arg
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return arg
+	}
+	return filepath.ToSlash(rel)
+}
+
+// findRepoRoot returns the nearest directory at or above path holding a .git
+// directory or file, or "" if there is none
+func findRepoRoot(path string) string {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
\ No newline at end of file
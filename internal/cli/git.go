@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/gitlink"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+func NewGitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git",
+		Short: "Link git commits to the AI sessions that produced them",
+		Long: `Find which AI conversation produced a commit.
+
+'mem git link' matches a repository's commits to conversations that ran in it:
+by a Mem-Session trailer if the commit has one, or else by whether the commit was
+made during or shortly after the conversation and by the files both changed.
+'mem git install-hook' adds that trailer to new commits, naming the session that
+was active in the repository. 'mem git blame' goes from a line of code to its
+commit and then to the conversation.
+
+Conversations come from all_conversations.db unless --db is given.`,
+		Example: `  # Link the last 90 days of commits
+  mem git link --since 90d
+
+  # Name the active session in every new commit
+  mem git install-hook
+
+  # Which conversation wrote this line?
+  mem git blame internal/storage/sqlite.go:120`,
+	}
+
+	cmd.AddCommand(
+		newGitLinkCommand(),
+		newGitBlameCommand(),
+		newGitInstallHookCommand(),
+		newGitTrailerCommand(),
+	)
+
+	return cmd
+}
+
+func newGitLinkCommand() *cobra.Command {
+	var repo, since, window string
+	var minScore float64
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "link",
+		Short: "Match a repository's commits to conversations",
+		Long: `Walk the history of the current branch and record which conversation each
+commit came from. Commits are matched by their Mem-Session trailer, or else
+scored on time and files: a commit made during a conversation scores 0.5, less
+the longer after it was made, up to --window; the share of its files the
+conversation edited or wrote adds up to another 0.5. Matches scoring below
+--min-score are not recorded. Linking again replaces earlier links.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validator := NewValidator()
+			sinceTime, err := validator.ParseSince(since)
+			if err != nil {
+				return err
+			}
+			opts := gitlink.DefaultOptions
+			if opts.Window, err = validator.ParseAge(window); err != nil {
+				return err
+			}
+			if minScore > 0 {
+				opts.MinScore = minScore
+			}
+			return runGitLink(repo, sinceTime, opts, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", ".", "Repository to link")
+	cmd.Flags().StringVar(&since, "since", "", "Only link commits since this age or date (e.g. 90d, 2006-01-02)")
+	cmd.Flags().StringVar(&window, "window", "2h", "How long after a conversation its commits may be made")
+	cmd.Flags().Float64Var(&minScore, "min-score", gitlink.DefaultOptions.MinScore, "Lowest score a match by time or files needs (0-1)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the links without recording them")
+
+	return withResults(cmd)
+}
+
+func newGitBlameCommand() *cobra.Command {
+	return withResults(&cobra.Command{
+		Use:   "blame <file>:<line>",
+		Short: "Find the conversation behind a line of code",
+		Long: `Find the commit that last changed a line with git blame, then the conversation
+it came from. Commits not linked yet are matched on the spot.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			i := strings.LastIndex(args[0], ":")
+			if i < 0 {
+				return fmt.Errorf("expected <file>:<line>, got %q", args[0])
+			}
+			line, err := strconv.Atoi(args[0][i+1:])
+			if err != nil || line < 1 {
+				return fmt.Errorf("invalid line %q", args[0][i+1:])
+			}
+			return runGitBlame(args[0][:i], line)
+		},
+	})
+}
+
+func newGitInstallHookCommand() *cobra.Command {
+	var repo string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "install-hook",
+		Short: "Add a Mem-Session trailer to new commits",
+		Long: `Install a prepare-commit-msg hook that appends a Mem-Session: <session-id>
+trailer to commit messages, naming the latest conversation that ran in the
+repository if its last message is less than --window old (default 2h). Merge,
+squash and amended commits are left alone, and a commit is never stopped if
+no session is found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGitInstallHook(repo, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", ".", "Repository to install the hook in")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an existing prepare-commit-msg hook")
+
+	return cmd
+}
+
+func newGitTrailerCommand() *cobra.Command {
+	var window string
+
+	cmd := &cobra.Command{
+		Use:    "trailer <message-file> [source [sha]]",
+		Short:  "Add a Mem-Session trailer to a commit message (run by the hook)",
+		Hidden: true,
+		Args:   cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Merges and squashes come from other commits; amends keep theirs
+			if len(args) > 1 && (args[1] == "merge" || args[1] == "squash" || args[1] == "commit") {
+				return nil
+			}
+			age, err := NewValidator().ParseAge(window)
+			if err != nil {
+				return err
+			}
+			return runGitTrailer(args[0], age)
+		},
+	}
+
+	cmd.Flags().StringVar(&window, "window", "2h", "How old the last message of the session may be")
+
+	return cmd
+}
+
+func runGitLink(dir string, since time.Time, opts gitlink.Options, dryRun bool) error {
+	root, err := gitlink.RepoRoot(dir)
+	if err != nil {
+		return err
+	}
+
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	commits, err := gitlink.Log(root, since)
+	if err != nil {
+		return err
+	}
+	sessions, err := repoSessions(store, root, since.Add(-opts.Window))
+	if err != nil {
+		return err
+	}
+
+	links := gitlink.Match(root, commits, sessions, opts)
+	if links == nil {
+		links = []models.CommitLink{}
+	}
+	if !dryRun {
+		if err := store.SaveCommitLinks(links); err != nil {
+			return err
+		}
+	}
+
+	return printResult(links, func() {
+		for _, l := range links {
+			fmt.Printf("  %s %s\n", shortSHA(l.SHA), l.Subject)
+			fmt.Printf("    → [ID: %d] %s (%s, score %.2f)\n", l.ConversationID, l.ConversationTitle, l.Method, l.Score)
+		}
+		if len(links) > 0 {
+			fmt.Println()
+		}
+		verb := "Linked"
+		if dryRun {
+			verb = "Would link"
+		}
+		fmt.Printf("✓ %s %d of %d commit(s) to %d conversation(s) in this repository\n", verb, len(links), len(commits), len(sessions))
+	})
+}
+
+// blameResult is a line of code traced to the conversation behind it
+type blameResult struct {
+	File   string             `json:"file"`
+	Line   int                `json:"line"`
+	Commit *gitlink.Commit    `json:"commit"`
+	Link   *models.CommitLink `json:"link"`
+}
+
+func runGitBlame(file string, line int) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", file, err)
+	}
+	root, err := gitlink.RepoRoot(filepath.Dir(abs))
+	if err != nil {
+		return err
+	}
+	// git prints the top directory with symlinks resolved
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", file, err)
+	}
+
+	sha, err := gitlink.BlameLine(root, rel, line)
+	if err != nil {
+		return err
+	}
+	commit, err := gitlink.ReadCommit(root, sha)
+	if err != nil {
+		return err
+	}
+
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	link, err := store.GetCommitLink(root, sha)
+	if errors.Is(err, sql.ErrNoRows) {
+		link, err = matchCommit(store, root, *commit)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up commit: %w", err)
+	}
+
+	result := blameResult{File: filepath.ToSlash(rel), Line: line, Commit: commit, Link: link}
+	return printResult(result, func() {
+		fmt.Printf("%s:%d\n", result.File, line)
+		fmt.Printf("  Commit: %s %s\n", shortSHA(commit.SHA), commit.Subject)
+		fmt.Printf("  Author: %s, %s\n", commit.Author, commit.Time.Format("2006-01-02 15:04"))
+		if link == nil {
+			fmt.Println("\nℹ️  No conversation found for this commit.")
+			return
+		}
+		fmt.Printf("\n[ID: %d] %s\n", link.ConversationID, link.ConversationTitle)
+		if link.SessionID != "" {
+			fmt.Printf("  Session: %s\n", link.SessionID)
+		}
+		fmt.Printf("  Matched by %s (score %.2f)\n", link.Method, link.Score)
+	})
+}
+
+// matchCommit links a single commit, recording the link if one is found
+func matchCommit(store *storage.SQLiteStore, root string, commit gitlink.Commit) (*models.CommitLink, error) {
+	opts := gitlink.DefaultOptions
+	sessions, err := repoSessions(store, root, commit.Time.Add(-opts.Window))
+	if err != nil {
+		return nil, err
+	}
+	links := gitlink.Match(root, []gitlink.Commit{commit}, sessions, opts)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	if err := store.SaveCommitLinks(links); err != nil {
+		return nil, err
+	}
+	return &links[0], nil
+}
+
+func runGitInstallHook(dir string, force bool) error {
+	root, err := gitlink.RepoRoot(dir)
+	if err != nil {
+		return err
+	}
+	memPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the mem executable: %w", err)
+	}
+
+	path, err := gitlink.InstallHook(root, memPath, force)
+	if errors.Is(err, gitlink.ErrHookExists) {
+		return fmt.Errorf("%w; use --force to replace it", err)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Installed %s\n", path)
+	return nil
+}
+
+func runGitTrailer(messageFile string, window time.Duration) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	root, err := gitlink.RepoRoot(wd)
+	if err != nil {
+		return err
+	}
+
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// The latest session is the one being committed from
+	sessions, err := repoSessions(store, root, time.Now().Add(-window))
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if id := s.Span.Conversation.SessionID; id != "" {
+			return gitlink.AddTrailer(root, messageFile, id)
+		}
+	}
+	return nil
+}
+
+// repoSessions returns the conversations that ran in a repository and were
+// active since the given time, latest first, with the files they changed
+func repoSessions(store *storage.SQLiteStore, root string, since time.Time) ([]gitlink.Session, error) {
+	spans, err := store.SessionSpans(storage.ConversationFilter{}, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversations: %w", err)
+	}
+
+	sessions := []gitlink.Session{}
+	for _, span := range spans {
+		if !gitlink.InRepo(span.Conversation, root) {
+			continue
+		}
+		files, err := store.ConversationFiles(span.Conversation.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read files: %w", err)
+		}
+		session := gitlink.Session{Span: span}
+		for _, f := range files {
+			if f.Access[models.FileEdit] > 0 || f.Access[models.FileWrite] > 0 {
+				session.Files = append(session.Files, f.Path)
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
\ No newline at end of file
@@ -8,7 +8,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/jasperwreed/ai-memory/internal/capture"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewImportCommand() *cobra.Command {
@@ -56,7 +55,7 @@ func importSessionFile(filePath string) error {
 		return fmt.Errorf("failed to parse session file: %w", err)
 	}
 
-	store, err := storage.NewSQLiteStore(dbPath)
+	store, err := openStore(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -2,12 +2,10 @@ package cli
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/jasperwreed/ai-memory/internal/storage"
+	"github.com/jasperwreed/ai-memory/internal/models"
 )
 
 func NewListCommand() *cobra.Command {
@@ -48,7 +46,7 @@ func NewListCommand() *cobra.Command {
 	cmd.Flags().StringVar(&filterProject, "project", "", "Filter by project")
 	cmd.Flags().BoolVar(&useAll, "all", false, "List from all imported conversations (all_conversations.db)")
 
-	return cmd
+	return withResults(cmd)
 }
 
 func runList(limit int, filter map[string]string, customDB string, useAll bool) error {
@@ -56,14 +54,10 @@ func runList(limit int, filter map[string]string, customDB string, useAll bool)
 
 	// If --all flag is used and no custom DB specified, use all_conversations.db
 	if useAll && customDB == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		database = filepath.Join(homeDir, ".ai-memory", "all_conversations.db")
+		database = settings().Storage.Database
 	}
 
-	store, err := storage.NewSQLiteStore(database)
+	store, err := openStore(database)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -74,25 +68,28 @@ func runList(limit int, filter map[string]string, customDB string, useAll bool)
 		return fmt.Errorf("failed to list conversations: %w", err)
 	}
 
-	if len(conversations) == 0 {
-		fmt.Println("No conversations found.")
-		return nil
+	if conversations == nil {
+		conversations = []models.Conversation{}
 	}
+	return printResult(conversations, func() {
+		if len(conversations) == 0 {
+			fmt.Println("No conversations found.")
+			return
+		}
 
-	fmt.Printf("Recent conversations:\n\n")
+		fmt.Printf("Recent conversations:\n\n")
 
-	for _, conv := range conversations {
-		fmt.Printf("[ID: %d] %s\n", conv.ID, conv.Title)
-		fmt.Printf("  Tool: %s", conv.Tool)
-		if conv.Project != "" {
-			fmt.Printf(" | Project: %s", conv.Project)
-		}
-		if len(conv.Tags) > 0 {
-			fmt.Printf(" | Tags: %s", strings.Join(conv.Tags, ", "))
+		for _, conv := range conversations {
+			fmt.Printf("[ID: %d] %s\n", conv.ID, conv.Title)
+			fmt.Printf("  Tool: %s", conv.Tool)
+			if conv.Project != "" {
+				fmt.Printf(" | Project: %s", conv.Project)
+			}
+			if len(conv.Tags) > 0 {
+				fmt.Printf(" | Tags: %s", strings.Join(conv.Tags, ", "))
+			}
+			fmt.Printf("\n  Created: %s\n", conv.CreatedAt.Format("2006-01-02 15:04:05"))
+			fmt.Println()
 		}
-		fmt.Printf("\n  Created: %s\n", conv.CreatedAt.Format("2006-01-02 15:04:05"))
-		fmt.Println()
-	}
-
-	return nil
+	})
 }
\ No newline at end of file
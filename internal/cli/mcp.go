@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/mcp"
+)
+
+func NewMCPCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve conversations to AI agents over the Model Context Protocol",
+		Long: `Run a Model Context Protocol server on stdin and stdout, so an agent can
+search and read past conversations and save notes while it works.
+
+Tools: search_conversations, get_conversation, list_recent, find_by_file and
+save_note. Conversations are also resources at mem://conversations/<id>.
+The server uses all_conversations.db unless --db is given. It is started by
+the agent, not run by hand.`,
+		Example: `  # Add to Claude Code
+  claude mcp add mem -- mem mcp
+
+  # Or in an MCP client config
+  {"mcpServers": {"mem": {"command": "mem", "args": ["mcp"]}}}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database := dbPath
+			if database == "" {
+				database = settings().Storage.Database
+			}
+
+			store, err := openStore(database)
+			if err != nil {
+				return fmt.Errorf("failed to open database: %w", err)
+			}
+			defer store.Close()
+
+			// stdout carries the protocol only
+			server := mcp.NewServer(store, cmd.Root().Version)
+			return server.Serve(os.Stdin, os.Stdout)
+		},
+	}
+}
\ No newline at end of file
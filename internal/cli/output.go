@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats accepted by --output
+const (
+	outputText     = "text"
+	outputJSON     = "json"
+	outputJSONL    = "jsonl"
+	outputTSV      = "tsv"
+	outputTemplate = "template"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputJSONL, outputTSV, outputTemplate}
+
+var (
+	outputFormat       string
+	outputTemplateText string
+	outputAsJSON       bool
+)
+
+// printsResults is the annotation marking commands that print their results
+// with printResult, which are the only ones taking other formats than text
+const printsResults = "prints-results"
+
+// withResults marks cmd as printing its results with printResult
+func withResults(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[printsResults] = "true"
+	return cmd
+}
+
+// addOutputFlags adds the flags choosing how commands print their results
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText,
+		"Output format: "+strings.Join(outputFormats, ", "))
+	cmd.PersistentFlags().BoolVar(&outputAsJSON, "json", false, "Shorthand for --output json")
+	cmd.PersistentFlags().StringVar(&outputTemplateText, "template", "",
+		"Go template printed once per record, e.g. '{{.id}} {{.title}}' (implies --output template)")
+}
+
+// validateOutput checks the output flags before a command runs
+func validateOutput(cmd *cobra.Command) error {
+	// mem scan --output used to name the database to import into
+	if cmd.Name() == "scan" && !isOutputFormat(outputFormat) {
+		fmt.Fprintf(os.Stderr, "⚠️  'mem scan --output <database>' is deprecated, use '--db %s'\n", outputFormat)
+		if dbPath == "" {
+			dbPath = outputFormat
+		}
+		outputFormat = outputText
+	}
+
+	if !isOutputFormat(outputFormat) {
+		return fmt.Errorf("unknown output format %q (expected %s)", outputFormat, strings.Join(outputFormats, ", "))
+	}
+	if outputTemplateText != "" && !cmd.Flags().Changed("output") {
+		outputFormat = outputTemplate
+	}
+	if outputAsJSON {
+		if cmd.Flags().Changed("output") && outputFormat != outputJSON {
+			return fmt.Errorf("--json conflicts with --output %s", outputFormat)
+		}
+		outputFormat = outputJSON
+	}
+	if outputFormat != outputText && cmd.Annotations[printsResults] == "" {
+		return fmt.Errorf("'%s' only prints text, it has no --output %s", cmd.CommandPath(), outputFormat)
+	}
+	if outputFormat == outputTemplate {
+		if outputTemplateText == "" {
+			return fmt.Errorf("--output template needs a --template")
+		}
+		if _, err := parseOutputTemplate(outputTemplateText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// machineOutput reports whether results are printed for other programs. Progress
+// and hints then go to stderr so they don't mix with the results.
+func machineOutput() bool {
+	return outputFormat != "" && outputFormat != outputText
+}
+
+// progressf prints progress messages, which are part of the text output but
+// go to stderr when results are printed for other programs
+func progressf(format string, args ...interface{}) {
+	if machineOutput() {
+		fmt.Fprintf(os.Stderr, format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// printResult prints v in the chosen output format. v is a slice of records
+// or a single record, and its field names are its JSON names. The text format
+// calls text, which prints v for people.
+func printResult(v interface{}, text func()) error {
+	if !machineOutput() {
+		text()
+		return nil
+	}
+	return writeResult(os.Stdout, outputFormat, outputTemplateText, v)
+}
+
+// writeResult writes v to w in a machine-readable format
+func writeResult(w io.Writer, format, tmpl string, v interface{}) error {
+	if format == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	records := outputRecords(v)
+	switch format {
+	case outputJSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputTSV:
+		return writeTSV(w, records)
+	case outputTemplate:
+		return writeTemplate(w, tmpl, records)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// outputRecords splits v into records: the elements of a slice, or v itself
+func outputRecords(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+	records := make([]interface{}, rv.Len())
+	for i := range records {
+		records[i] = rv.Index(i).Interface()
+	}
+	return records
+}
+
+// field is a column of a flattened record
+type field struct {
+	name  string
+	value string
+}
+
+// flatten turns a record into columns named after its JSON fields, in the
+// order they are declared. Nested objects become dotted names, lists of plain
+// values are joined with commas and other lists are kept as JSON.
+func flatten(record interface{}) ([]field, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var fields []field
+	if err := flattenValue(dec, "", &fields); err != nil {
+		return nil, fmt.Errorf("failed to flatten record: %w", err)
+	}
+	return fields, nil
+}
+
+func flattenValue(dec *json.Decoder, name string, fields *[]field) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			child := key.(string)
+			if name != "" {
+				child = name + "." + child
+			}
+			if err := flattenValue(dec, child, fields); err != nil {
+				return err
+			}
+		}
+		_, err := dec.Token()
+		return err
+	case json.Delim('['):
+		var items []json.RawMessage
+		for dec.More() {
+			var item json.RawMessage
+			if err := dec.Decode(&item); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		*fields = append(*fields, field{name, joinList(items)})
+		return nil
+	}
+
+	*fields = append(*fields, field{name, scalarString(tok)})
+	return nil
+}
+
+// joinList joins a list of plain values with commas, or returns it as JSON
+func joinList(items []json.RawMessage) string {
+	values := make([]string, len(items))
+	for i, item := range items {
+		var v interface{}
+		if err := json.Unmarshal(item, &v); err != nil {
+			return ""
+		}
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(items)
+			return string(data)
+		}
+		values[i] = scalarString(v)
+	}
+	return strings.Join(values, ",")
+}
+
+func scalarString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// tsvEscaper keeps every value on one line and in one column
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// writeTSV writes a header row and one row per record. Columns appear in the
+// order they are first seen, so optional fields of later records are kept.
+func writeTSV(w io.Writer, records []interface{}) error {
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, len(records))
+	for i, record := range records {
+		fields, err := flatten(record)
+		if err != nil {
+			return err
+		}
+		rows[i] = make(map[string]string, len(fields))
+		for _, f := range fields {
+			if !seen[f.name] {
+				seen[f.name] = true
+				columns = append(columns, f.name)
+			}
+			rows[i][f.name] = f.value
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(strings.Join(columns, "\t") + "\n")
+	for _, row := range rows {
+		for i, column := range columns {
+			if i > 0 {
+				buf.WriteByte('\t')
+			}
+			buf.WriteString(tsvEscaper.Replace(row[column]))
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// parseOutputTemplate parses a --template. Besides the usual functions it has
+// join, for lists, and json.
+func parseOutputTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"join": func(sep string, v interface{}) string {
+			list, _ := v.([]interface{})
+			values := make([]string, len(list))
+			for i, v := range list {
+				values[i] = scalarString(v)
+			}
+			return strings.Join(values, sep)
+		},
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplate executes the template for each record, which it sees by its
+// JSON field names. Each record ends with a newline.
+func writeTemplate(w io.Writer, text string, records []interface{}) error {
+	tmpl, err := parseOutputTemplate(text)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var fields interface{}
+		if err := dec.Decode(&fields); err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, fields); err != nil {
+			return fmt.Errorf("failed to execute --template: %w", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedCounts returns the entries of a count map, largest first and then by
+// name, so text output doesn't depend on map order
+func sortedCounts(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
\ No newline at end of file
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// newGoldenDB creates a database with fixed conversations
+func newGoldenDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "golden.db")
+	store, err := storage.NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	fixtures := []struct {
+		title, tool, project string
+		tags                 []string
+		content              string
+	}{
+		{"Fix flaky migration test", "claude-code", "api", []string{"db", "tests"}, "The migration test fails\twhen run in parallel"},
+		{"Add rate limiting", "aider", "api", nil, "Use a token bucket per client for the rate limit"},
+		{"Explain the migration lock", "claude-code", "worker", []string{"db"}, "The migration takes an advisory lock first"},
+	}
+	for i, f := range fixtures {
+		created := base.Add(time.Duration(i) * time.Hour)
+		conv := &models.Conversation{
+			Title:     f.title,
+			Tool:      f.tool,
+			Project:   f.project,
+			Tags:      f.tags,
+			CreatedAt: created,
+			UpdatedAt: created,
+			Messages: []models.Message{
+				{Role: "user", Content: f.content, Timestamp: created, TokenCount: 10 * (i + 1)},
+				{Role: "assistant", Content: "Done.", Timestamp: created.Add(time.Minute), TokenCount: 2},
+			},
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// resetFlags forgets the flags and config a test command left behind
+func resetFlags() {
+	outputFormat, outputTemplateText, outputAsJSON = outputText, "", false
+	dbPath = ""
+	appConfig, appConfigErr = nil, nil
+}
+
+// runMem runs mem with args and returns what it printed to stdout
+func runMem(t *testing.T, args ...string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(resetFlags)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := NewRootCommand()
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("mem %s: %v", strings.Join(args, " "), err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
+
+// setLocal makes loc the local time zone for the rest of the test
+func setLocal(t *testing.T, loc *time.Location) {
+	old := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = old })
+}
+
+func TestOutputGolden(t *testing.T) {
+	db := newGoldenDB(t)
+	setLocal(t, time.UTC)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"list.txt", []string{"list"}},
+		{"list.json", []string{"list", "--json"}},
+		{"list.jsonl", []string{"list", "-o", "jsonl"}},
+		{"list.tsv", []string{"list", "-o", "tsv"}},
+		{"list.template", []string{"list", "--template", `{{.id}} {{.title}} [{{join "," .tags}}]`}},
+		{"search.json", []string{"search", "migration", "-o", "json"}},
+		{"search.tsv", []string{"search", "migration", "-o", "tsv"}},
+		{"stats.txt", []string{"stats"}},
+		{"stats.json", []string{"stats", "-o", "json"}},
+		{"stats.tsv", []string{"stats", "-o", "tsv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runMem(t, append(tt.args, "--db", db)...)
+
+			golden := filepath.Join("testdata", "output", tt.name+".golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("Output differs from %s:\n--- got\n%s\n--- want\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestOutputLocalTime(t *testing.T) {
+	db := newGoldenDB(t)
+	setLocal(t, time.FixedZone("EST", -5*60*60))
+
+	// Times are stored in UTC and shown in local time
+	got := runMem(t, "list", "--db", db)
+	if !strings.Contains(got, "Created: 2025-03-01 04:30:00") {
+		t.Errorf("Expected the creation time in local time, got:\n%s", got)
+	}
+	got = runMem(t, "list", "--db", db, "--json")
+	if !strings.Contains(got, `"created_at": "2025-03-01T04:30:00-05:00"`) {
+		t.Errorf("Expected the creation time in local time, got:\n%s", got)
+	}
+}
+
+func TestOutputFlags(t *testing.T) {
+	db := newGoldenDB(t)
+	t.Cleanup(resetFlags)
+
+	for _, args := range [][]string{
+		{"list", "-o", "yaml"},
+		{"list", "-o", "template"},
+		{"list", "--json", "-o", "tsv"},
+		{"list", "--template", "{{.id"},
+		// Commands without results to print only print text
+		{"export", "--id", "1", "-o", "json"},
+		{"delete", "--id", "1", "--yes", "--json"},
+		{"audit", "replay", "-o", "tsv"},
+	} {
+		t.Setenv("HOME", t.TempDir())
+		cmd := NewRootCommand()
+		cmd.SetArgs(append(args, "--db", db))
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("mem %s: expected an error", strings.Join(args, " "))
+		}
+	}
+}
+
+func TestAuditGCOutput(t *testing.T) {
+	got := runMem(t, "audit", "gc", "--dry-run", "--audit-dir", t.TempDir(), "-o", "json")
+
+	var result struct {
+		DryRun        bool `json:"dry_run"`
+		ShardsScanned int  `json:"shards_scanned"`
+	}
+	if err := json.Unmarshal([]byte(got), &result); err != nil {
+		t.Fatalf("Expected only JSON on stdout, got %q: %v", got, err)
+	}
+	if !result.DryRun || result.ShardsScanned != 0 {
+		t.Errorf("Unexpected gc result %+v", result)
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	type inner struct {
+		Name string `json:"name"`
+	}
+	type record struct {
+		ID     int               `json:"id"`
+		Note   string            `json:"note"`
+		Inner  inner             `json:"inner"`
+		List   []inner           `json:"list"`
+		Counts map[string]int    `json:"counts"`
+		Extra  string            `json:"extra,omitempty"`
+		Labels map[string]string `json:"-"`
+	}
+
+	var buf bytes.Buffer
+	err := writeResult(&buf, outputTSV, "", []record{
+		{ID: 1, Note: "tab\there\nnewline", Inner: inner{"a"}, Counts: map[string]int{"b": 2, "a": 1}},
+		{ID: 2, List: []inner{{"x"}}, Counts: map[string]int{}, Extra: "late"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id\tnote\tinner.name\tlist\tcounts.a\tcounts.b\textra\n" +
+		"1\ttab\\there\\nnewline\ta\t\t1\t2\t\n" +
+		"2\t\t\t[{\"name\":\"x\"}]\t\t\tlate\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected TSV:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
\ No newline at end of file
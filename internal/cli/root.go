@@ -5,7 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/jasperwreed/ai-memory/internal/storage"
+	"github.com/jasperwreed/ai-memory/internal/audit"
 	"github.com/jasperwreed/ai-memory/internal/tui"
 )
 
@@ -37,9 +37,17 @@ Inside the TUI, use vim-style commands:
 		Version: "0.1.0",
 		Args:    cobra.MaximumNArgs(1),
 		RunE:    runTUI,
+
+		PersistentPreRunE: loadSettings,
 	}
 
+	// Encrypted audit shards are unlocked on first use
+	audit.SetIdentityProvider(unlockAuditIdentities)
+
 	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "Path to database file (overrides default behavior)")
+	rootCmd.PersistentFlags().StringVar(&dbKeyFile, "db-key-file", "", "Database key file (default ~/.ai-memory/db.key if present)")
+	rootCmd.PersistentFlags().StringVar(&dbKeyCmd, "db-key-cmd", "", "Command printing the database key, e.g. a keyring helper")
+	addOutputFlags(rootCmd)
 
 	rootCmd.AddCommand(
 		NewCaptureCommand(),
@@ -52,6 +60,19 @@ Inside the TUI, use vim-style commands:
 		NewImportCommand(),
 		NewScanCommand(),
 		NewDaemonCommand(),
+		NewAuditCommand(),
+		NewDBCommand(),
+		NewConfigCommand(),
+		NewMCPCommand(),
+		NewServeCommand(),
+		NewContextCommand(),
+		NewSnippetsCommand(),
+		NewFilesCommand(),
+		NewShowCommand(),
+		NewGitCommand(),
+		NewShellHookCommand(),
+		NewTimelineCommand(),
+		NewActivityCommand(),
 	)
 
 	return rootCmd
@@ -76,7 +97,7 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	store, err := storage.NewSQLiteStore(database)
+	store, err := openStore(database)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -4,16 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/jasperwreed/ai-memory/internal/audit"
 	"github.com/jasperwreed/ai-memory/internal/scanner"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewScanCommand() *cobra.Command {
-	var outputDB string
 	var verbose bool
 	var dryRun bool
 	var auditOnly bool
@@ -38,7 +35,10 @@ Claude's 30-day purge. Use flags to modify this behavior.`,
   mem scan
 
   # Scan with custom database location
-  mem scan --output ~/my-conversations.db
+  mem scan --db ~/my-conversations.db
+
+  # Report what was imported as JSON
+  mem scan --output json
 
   # Dry run to see what would be imported
   mem scan --dry-run
@@ -61,141 +61,147 @@ Claude's 30-day purge. Use flags to modify this behavior.`,
 			captureAudit := !noAudit        // Capture audit by default, unless --no-audit
 			importToDB := !auditOnly         // Import to DB by default, unless --audit-only
 
-			return runScan(outputDB, auditDir, verbose, dryRun, captureAudit, importToDB)
+			return runScan(dbPath, auditDir, verbose, dryRun, captureAudit, importToDB)
 		},
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	defaultAuditDir := filepath.Join(homeDir, ".ai-memory", "audit")
-
-	cmd.Flags().StringVar(&outputDB, "output", "", "Output database file (default: ~/.ai-memory/all_conversations.db)")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed progress")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without actually importing")
 	cmd.Flags().BoolVar(&auditOnly, "audit-only", false, "Only capture to audit logs (no database import)")
 	cmd.Flags().BoolVar(&noAudit, "no-audit", false, "Skip audit capture (only import to database)")
-	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir, "Directory for audit logs")
+	cmd.Flags().StringVar(&auditDir, "audit-dir", defaultAuditDir(), "Directory for audit logs")
 
-	return cmd
+	return withResults(cmd)
 }
 
 func runScan(outputDB, auditDir string, verbose, dryRun, captureAudit, importToDB bool) error {
 	if outputDB == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		outputDB = filepath.Join(homeDir, ".ai-memory", "all_conversations.db")
+		outputDB = settings().Storage.Database
 	}
 
-	fmt.Println("🔍 Scanning for AI conversation files...")
+	progressf("🔍 Scanning for AI conversation files...\n")
 
 	// Show what will be done
 	if dryRun {
-		fmt.Println("   Mode: DRY RUN (no changes will be made)")
+		progressf("   Mode: DRY RUN (no changes will be made)\n")
 	} else {
 		if captureAudit && importToDB {
-			fmt.Println("   Mode: Full capture (audit + database)")
+			progressf("   Mode: Full capture (audit + database)\n")
 		} else if captureAudit {
-			fmt.Println("   Mode: Audit capture only")
+			progressf("   Mode: Audit capture only\n")
 		} else if importToDB {
-			fmt.Println("   Mode: Database import only")
+			progressf("   Mode: Database import only\n")
 		}
 	}
-	fmt.Println()
+	progressf("\n")
 
 	// Initialize audit logger if requested
 	var auditLogger *audit.AuditLogger
 	if captureAudit && !dryRun {
-		var err error
-		auditLogger, err = audit.NewAuditLogger(auditDir, 100*1024*1024, true) // 100MB shards, compressed
+		recipients, err := auditRecipients()
+		if err != nil {
+			return err
+		}
+		auditLogger, err = audit.NewEncryptedAuditLogger(auditDir, 100*1024*1024, true, recipients) // 100MB shards, compressed
 		if err != nil {
 			return fmt.Errorf("failed to create audit logger: %w", err)
 		}
 		defer auditLogger.Close()
 
-		fmt.Printf("📝 Audit logs: %s\n", auditDir)
+		progressf("📝 Audit logs: %s\n", auditDir)
+		if len(recipients) > 0 {
+			progressf("🔒 Audit shards are encrypted\n")
+		}
 	}
 
 	// Show database path if importing
 	if importToDB && !dryRun {
-		fmt.Printf("💾 Database: %s\n", outputDB)
+		progressf("💾 Database: %s\n", outputDB)
 	}
 
 	if (captureAudit || importToDB) && !dryRun {
-		fmt.Println()
+		progressf("\n")
 	}
 
-	// Initialize scanners (extensible design for future tools)
-	scanners := []scanner.Scanner{
-		scanner.NewClaudeScanner(),
-		// Future: scanner.NewAiderScanner(),
-		// Future: scanner.NewCursorScanner(),
-		// Future: scanner.NewGPTCLIScanner(),
-	}
+	// Scanners register themselves; the daemon watches the same set
+	scanners := scanner.Registered()
 
-	totalFound := 0
-	totalImported := 0
-	var results []scanner.ScanResult
+	results := []scanner.ScanResult{}
 
 	// Scan each tool
 	for _, s := range scanners {
+		if !settings().ScannerEnabled(s.Tool()) {
+			if verbose {
+				progressf("Skipping %s (disabled in config)\n", s.Name())
+			}
+			continue
+		}
 		if verbose {
-			fmt.Printf("Scanning %s...\n", s.Name())
+			progressf("Scanning %s...\n", s.Name())
 		}
 
 		sessions, err := s.ScanForSessions()
 		if err != nil {
 			if verbose {
-				fmt.Printf("  ⚠️  Error scanning %s: %v\n", s.Name(), err)
+				progressf("  ⚠️  Error scanning %s: %v\n", s.Name(), err)
 			}
 			continue
 		}
 
 		if len(sessions) == 0 {
 			if verbose {
-				fmt.Printf("  No %s sessions found\n", s.Name())
+				progressf("  No %s sessions found\n", s.Name())
 			}
 			continue
 		}
 
-		fmt.Printf("📁 Found %d %s session(s)\n", len(sessions), s.Name())
-		totalFound += len(sessions)
+		progressf("📁 Found %d %s session(s)\n", len(sessions), s.Name())
+
+		result := scanner.ScanResult{
+			Tool:          s.Name(),
+			SessionsFound: len(sessions),
+		}
 
 		if dryRun {
 			for _, session := range sessions {
-				fmt.Printf("  • %s\n", session.Path)
+				result.Sessions = append(result.Sessions, session.Path)
+				progressf("  • %s\n", session.Path)
 				if verbose {
-					fmt.Printf("    Project: %s, Size: %d bytes, Modified: %s\n",
+					progressf("    Project: %s, Size: %d bytes, Modified: %s\n",
 						session.ProjectName, session.Size, session.ModTime)
 				}
 			}
+			results = append(results, result)
 			continue
 		}
 
 		// Import sessions
-		result := scanner.ScanResult{
-			Tool:          s.Name(),
-			SessionsFound: len(sessions),
-		}
-
-		if !dryRun {
-			if importToDB {
-				imported, failed := importSessions(s, sessions, outputDB, auditLogger, verbose)
-				result.Imported = imported
-				result.Failed = failed
-				totalImported += imported
-			} else if captureAudit {
-				// Audit-only mode: just capture raw files
-				captureSessionsToAudit(s, sessions, auditLogger, verbose)
-				result.Imported = len(sessions)
-				totalImported += len(sessions)
-			}
+		if importToDB {
+			result.Imported, result.Skipped, result.Failed = importSessions(s, sessions, outputDB, auditLogger, verbose)
+		} else if captureAudit {
+			// Audit-only mode: just capture raw files
+			captureSessionsToAudit(s, sessions, auditLogger, verbose)
+			result.Imported = len(sessions)
 		}
 
 		results = append(results, result)
 	}
 
-	// Summary
+	return printResult(results, func() {
+		printScanSummary(results, outputDB, auditDir, dryRun, captureAudit, importToDB)
+	})
+}
+
+// printScanSummary prints the totals of a scan and what to do next
+func printScanSummary(results []scanner.ScanResult, outputDB, auditDir string, dryRun, captureAudit, importToDB bool) {
+	var totalFound, totalImported, totalSkipped, totalFailed int
+	for _, result := range results {
+		totalFound += result.SessionsFound
+		totalImported += result.Imported
+		totalSkipped += result.Skipped
+		totalFailed += result.Failed
+	}
+
 	fmt.Println()
 	fmt.Println("═══════════════════════════════════")
 	fmt.Printf("📊 Scan Complete\n")
@@ -204,8 +210,11 @@ func runScan(outputDB, auditDir string, verbose, dryRun, captureAudit, importToD
 	if !dryRun {
 		if importToDB {
 			fmt.Printf("   Successfully imported to DB: %d\n", totalImported)
-			if totalFound > totalImported {
-				fmt.Printf("   Failed to import: %d\n", totalFound-totalImported)
+			if totalSkipped > 0 {
+				fmt.Printf("   Already imported: %d\n", totalSkipped)
+			}
+			if totalFailed > 0 {
+				fmt.Printf("   Failed to import: %d\n", totalFailed)
 			}
 		}
 		if captureAudit {
@@ -242,27 +251,25 @@ func runScan(outputDB, auditDir string, verbose, dryRun, captureAudit, importToD
 			}
 		}
 	}
-
-	return nil
 }
 
-func importSessions(s scanner.Scanner, sessions []scanner.SessionInfo, dbPath string, auditLogger *audit.AuditLogger, verbose bool) (imported, failed int) {
-	store, err := storage.NewSQLiteStore(dbPath)
+func importSessions(s scanner.Scanner, sessions []scanner.SessionInfo, dbPath string, auditLogger *audit.AuditLogger, verbose bool) (imported, skipped, failed int) {
+	store, err := openStore(dbPath)
 	if err != nil {
-		fmt.Printf("  ❌ Failed to open database: %v\n", err)
-		return 0, len(sessions)
+		progressf("  ❌ Failed to open database: %v\n", err)
+		return 0, 0, len(sessions)
 	}
 	defer store.Close()
 
 	for i, session := range sessions {
 		if verbose {
-			fmt.Printf("  [%d/%d] Importing %s...\n", i+1, len(sessions), filepath.Base(session.Path))
+			progressf("  [%d/%d] Importing %s...\n", i+1, len(sessions), filepath.Base(session.Path))
 		}
 
 		conv, err := s.ParseSession(session.Path)
 		if err != nil {
 			if verbose {
-				fmt.Printf("    ⚠️  Failed to parse: %v\n", err)
+				progressf("    ⚠️  Failed to parse: %v\n", err)
 			}
 			failed++
 			continue
@@ -273,32 +280,13 @@ func importSessions(s scanner.Scanner, sessions []scanner.SessionInfo, dbPath st
 			// Read the raw file for audit
 			rawData, err := os.ReadFile(session.Path)
 			if err == nil {
-				// Write raw JSONL lines to audit
-				lines := splitJSONL(rawData)
 				currentShard := getCurrentShardName(auditLogger)
-
-				for _, line := range lines {
-					if len(line) > 0 {
-						// Add metadata about source
-						event := map[string]interface{}{
-							"type":        "import",
-							"source_path": session.Path,
-							"session_id":  conv.SessionID,
-							"tool":        conv.Tool,
-							"project":     conv.Project,
-							"timestamp":   time.Now().Unix(),
-						}
-
-						// Store raw line
-						auditLogger.WriteRawLine(line)
-						auditLogger.WriteEvent(event)
-					}
-				}
+				writeSessionToAudit(auditLogger, session.Path, conv.SessionID, conv.Tool, rawData)
 
 				// Update conversation with audit shard reference
 				conv.AuditShard = currentShard
 			} else if verbose {
-				fmt.Printf("    ⚠️  Could not read file for audit: %v\n", err)
+				progressf("    ⚠️  Could not read file for audit: %v\n", err)
 			}
 		}
 
@@ -327,14 +315,15 @@ func importSessions(s scanner.Scanner, sessions []scanner.SessionInfo, dbPath st
 
 		if isDuplicate {
 			if verbose {
-				fmt.Printf("    ⏭️  Skipping duplicate\n")
+				progressf("    ⏭️  Skipping duplicate\n")
 			}
+			skipped++
 			continue
 		}
 
 		if err := store.SaveConversation(conv); err != nil {
 			if verbose {
-				fmt.Printf("    ❌ Failed to save: %v\n", err)
+				progressf("    ❌ Failed to save: %v\n", err)
 			}
 			failed++
 			continue
@@ -342,31 +331,48 @@ func importSessions(s scanner.Scanner, sessions []scanner.SessionInfo, dbPath st
 
 		imported++
 		if !verbose && imported%10 == 0 {
-			fmt.Printf("  Imported %d/%d...\n", imported, len(sessions))
+			progressf("  Imported %d/%d...\n", imported, len(sessions))
 		}
 	}
 
-	return imported, failed
+	return imported, skipped, failed
+}
+
+// jsonlLine is a single line of a JSONL file and the byte offset it starts at
+type jsonlLine struct {
+	offset int64
+	data   []byte
 }
 
-// splitJSONL splits raw data into individual JSONL lines
-func splitJSONL(data []byte) [][]byte {
-	var lines [][]byte
+// splitJSONL splits raw data into individual JSONL lines. Each line keeps its
+// trailing newline so the original file can be reassembled byte-for-byte.
+func splitJSONL(data []byte) []jsonlLine {
+	var lines []jsonlLine
 	start := 0
 	for i, b := range data {
 		if b == '\n' {
-			if i > start {
-				lines = append(lines, data[start:i])
-			}
+			lines = append(lines, jsonlLine{offset: int64(start), data: data[start : i+1]})
 			start = i + 1
 		}
 	}
 	if start < len(data) {
-		lines = append(lines, data[start:])
+		lines = append(lines, jsonlLine{offset: int64(start), data: data[start:]})
 	}
 	return lines
 }
 
+// writeSessionToAudit writes every line of a session file to the audit log
+func writeSessionToAudit(auditLogger *audit.AuditLogger, path, sessionID, tool string, data []byte) {
+	// Blank lines are kept too so the file can be restored byte-for-byte
+	for _, line := range splitJSONL(data) {
+		record := audit.NewRecord(path, sessionID, tool, line.offset, line.data)
+		if err := auditLogger.WriteRecord(record); err != nil {
+			progressf("    ⚠️  Failed to write audit record: %v\n", err)
+			return
+		}
+	}
+}
+
 // getCurrentShardName gets the current active shard name from the audit logger
 func getCurrentShardName(logger *audit.AuditLogger) string {
 	shards, err := logger.GetActiveShards()
@@ -381,14 +387,14 @@ func getCurrentShardName(logger *audit.AuditLogger) string {
 func captureSessionsToAudit(s scanner.Scanner, sessions []scanner.SessionInfo, auditLogger *audit.AuditLogger, verbose bool) {
 	for i, session := range sessions {
 		if verbose {
-			fmt.Printf("  [%d/%d] Capturing %s to audit...\n", i+1, len(sessions), filepath.Base(session.Path))
+			progressf("  [%d/%d] Capturing %s to audit...\n", i+1, len(sessions), filepath.Base(session.Path))
 		}
 
 		// Read the raw file for audit
 		rawData, err := os.ReadFile(session.Path)
 		if err != nil {
 			if verbose {
-				fmt.Printf("    ⚠️  Could not read file: %v\n", err)
+				progressf("    ⚠️  Could not read file: %v\n", err)
 			}
 			continue
 		}
@@ -396,37 +402,17 @@ func captureSessionsToAudit(s scanner.Scanner, sessions []scanner.SessionInfo, a
 		// Parse just to get metadata
 		conv, _ := s.ParseSession(session.Path)
 		sessionID := ""
-		tool := s.Name()
-		project := session.ProjectName
+		tool := session.Tool
 
 		if conv != nil {
 			sessionID = conv.SessionID
 			tool = conv.Tool
-			project = conv.Project
-		}
-
-		// Write raw JSONL lines to audit
-		lines := splitJSONL(rawData)
-		for _, line := range lines {
-			if len(line) > 0 {
-				// Store raw line
-				auditLogger.WriteRawLine(line)
-
-				// Add metadata event
-				event := map[string]interface{}{
-					"type":        "capture",
-					"source_path": session.Path,
-					"session_id":  sessionID,
-					"tool":        tool,
-					"project":     project,
-					"timestamp":   time.Now().Unix(),
-				}
-				auditLogger.WriteEvent(event)
-			}
 		}
 
+		writeSessionToAudit(auditLogger, session.Path, sessionID, tool, rawData)
+
 		if verbose {
-			fmt.Printf("    ✅ Captured to audit\n")
+			progressf("    ✅ Captured to audit\n")
 		}
 	}
 }
\ No newline at end of file
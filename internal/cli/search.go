@@ -2,13 +2,11 @@ package cli
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
 	"github.com/jasperwreed/ai-memory/internal/search"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewSearchCommand() *cobra.Command {
@@ -42,7 +40,7 @@ func NewSearchCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&showContext, "context", false, "Show full message context")
 	cmd.Flags().BoolVar(&useAll, "all", false, "Search in all imported conversations (all_conversations.db)")
 
-	return cmd
+	return withResults(cmd)
 }
 
 func runSearch(query string, limit int, showContext bool, customDB string, useAll bool) error {
@@ -50,14 +48,10 @@ func runSearch(query string, limit int, showContext bool, customDB string, useAl
 
 	// If --all flag is used and no custom DB specified, use all_conversations.db
 	if useAll && customDB == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		database = filepath.Join(homeDir, ".ai-memory", "all_conversations.db")
+		database = settings().Storage.Database
 	}
 
-	store, err := storage.NewSQLiteStore(database)
+	store, err := openStore(database)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -69,32 +63,35 @@ func runSearch(query string, limit int, showContext bool, customDB string, useAl
 		return fmt.Errorf("search failed: %w", err)
 	}
 
-	if len(results) == 0 {
-		fmt.Println("No results found.")
-		return nil
+	if results == nil {
+		results = []models.SearchResult{}
 	}
+	return printResult(results, func() {
+		if len(results) == 0 {
+			fmt.Println("No results found.")
+			return
+		}
 
-	fmt.Printf("Found %d result(s) for '%s':\n\n", len(results), query)
+		fmt.Printf("Found %d result(s) for '%s':\n\n", len(results), query)
 
-	for i, result := range results {
-		fmt.Printf("%d. [ID: %d] %s\n", i+1, result.Conversation.ID, result.Conversation.Title)
-		fmt.Printf("   Tool: %s", result.Conversation.Tool)
-		if result.Conversation.Project != "" {
-			fmt.Printf(" | Project: %s", result.Conversation.Project)
-		}
-		fmt.Printf(" | %s\n", result.Conversation.CreatedAt.Format("2006-01-02 15:04"))
-
-		if showContext {
-			fmt.Printf("\n   %s\n", strings.ReplaceAll(result.Snippet, "\n", "\n   "))
-		} else {
-			snippet := result.Snippet
-			if len(snippet) > 100 {
-				snippet = snippet[:100] + "..."
+		for i, result := range results {
+			fmt.Printf("%d. [ID: %d] %s\n", i+1, result.Conversation.ID, result.Conversation.Title)
+			fmt.Printf("   Tool: %s", result.Conversation.Tool)
+			if result.Conversation.Project != "" {
+				fmt.Printf(" | Project: %s", result.Conversation.Project)
 			}
-			fmt.Printf("   %s\n", snippet)
+			fmt.Printf(" | %s\n", result.Conversation.CreatedAt.Format("2006-01-02 15:04"))
+
+			if showContext {
+				fmt.Printf("\n   %s\n", strings.ReplaceAll(result.Snippet, "\n", "\n   "))
+			} else {
+				snippet := result.Snippet
+				if len(snippet) > 100 {
+					snippet = snippet[:100] + "..."
+				}
+				fmt.Printf("   %s\n", snippet)
+			}
+			fmt.Println()
 		}
-		fmt.Println()
-	}
-
-	return nil
+	})
 }
\ No newline at end of file
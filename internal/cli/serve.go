@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/api"
+)
+
+func NewServeCommand() *cobra.Command {
+	var addr string
+	var ui bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve conversations over a local HTTP/JSON API",
+		Long: `Serve conversations over an HTTP/JSON API, for editor plugins and scripts.
+
+Endpoints, all returning JSON:
+  GET    /api/conversations                  ?tool= &project= &tag= &limit= &cursor=
+  GET    /api/conversations/{id}
+  GET    /api/conversations/{id}/messages    ?limit= &cursor=
+  GET    /api/conversations/{id}/export
+  GET    /api/conversations/{id}/tags
+  PUT    /api/conversations/{id}/tags        {"tags": [...]} replaces the tags
+  POST   /api/conversations/{id}/tags        {"tags": [...]} adds tags
+  DELETE /api/conversations/{id}/tags/{tag}
+  GET    /api/tags
+  GET    /api/search                         ?q= &tool= &project= &tag= &limit= &cursor=
+  GET    /api/stats
+
+Lists are paged: pass the next_cursor of a response as cursor to get the next
+page. GET responses carry an ETag; send it back in If-None-Match to get
+304 Not Modified when nothing changed. Request bodies must be
+application/json.
+
+With --ui, a read-only web UI for browsing and searching conversations is
+served at / as well. It is built into mem and works offline.
+
+The API has no authentication. On a loopback address it only answers requests
+addressed to localhost; listening on another address exposes your
+conversations to the network.`,
+		Example: `  # Serve the default database on 127.0.0.1:7777
+  mem serve
+
+  # Browse in a web browser at http://127.0.0.1:7777/
+  mem serve --ui
+
+  # Search from a script
+  curl -s 'http://127.0.0.1:7777/api/search?q=migration&tool=claude-code'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(addr, ui)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:7777", "Address to listen on")
+	cmd.Flags().BoolVar(&ui, "ui", false, "Also serve the web UI at /")
+
+	return cmd
+}
+
+func runServe(addr string, ui bool) error {
+	database := dbPath
+	if database == "" {
+		database = settings().Storage.Database
+	}
+
+	store, err := openStore(database)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	var handler http.Handler = api.NewServer(store, settings().Pricing.USDPerMillionTokens).Handler()
+	if ui {
+		mux := http.NewServeMux()
+		mux.Handle("/api/", handler)
+		mux.Handle("/", api.UI())
+		handler = mux
+	}
+	if tcpAddr, ok := listener.Addr().(*net.TCPAddr); ok && tcpAddr.IP.IsLoopback() {
+		handler = api.LocalOnly(handler)
+	} else {
+		fmt.Fprintf(os.Stderr, "⚠️  Listening on %s without authentication; anyone who can reach it can read your conversations\n", listener.Addr())
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Requests in flight finish before the store is closed
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("✓ Serving %s on http://%s/api/\n", database, listener.Addr())
+	if ui {
+		fmt.Printf("🌐 Web UI at http://%s/\n", listener.Addr())
+	}
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	<-drained
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// shellHooks print each command with its directory, exit code and start time
+// or duration to mem shell-hook record, in the background so the prompt does
+// not wait. %[1]s is the quoted path of mem.
+var shellHooks = map[string]string{
+	"zsh": `# mem: record interactive commands
+zmodload zsh/datetime 2>/dev/null
+_mem_preexec() {
+  _mem_cmd=$1
+  _mem_dir=$PWD
+  _mem_start=$EPOCHREALTIME
+}
+_mem_precmd() {
+  local exit_code=$?
+  [[ -n $_mem_cmd ]] || return
+  %[1]s shell-hook record --shell zsh --exit "$exit_code" --start "$_mem_start" --dir "$_mem_dir" -- "$_mem_cmd" >/dev/null 2>&1 &!
+  _mem_cmd=
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec _mem_preexec
+add-zsh-hook precmd _mem_precmd
+`,
+	"bash": `# mem: record interactive commands
+_mem_preexec() {
+  [[ -n $_mem_prompt || -n $_mem_start || -n $COMP_LINE || $BASH_COMMAND == _mem_precmd ]] && return
+  _mem_start=${EPOCHREALTIME:-$(date +%%s)}
+  _mem_dir=$PWD
+}
+_mem_precmd() {
+  local exit_code=$? start=$_mem_start entry
+  _mem_prompt=1
+  _mem_start=
+  entry=$(HISTTIMEFORMAT= builtin history 1)
+  [[ $entry =~ ^\ *([0-9]+)[*\ ]\ (.*)$ ]] || return
+  # Commands kept out of the history leave its last number as it was
+  if [[ -n $start && ${BASH_REMATCH[1]} != "$_mem_hist" ]]; then
+    ( %[1]s shell-hook record --shell bash --exit "$exit_code" --start "$start" --dir "$_mem_dir" -- "${BASH_REMATCH[2]}" >/dev/null 2>&1 & )
+  fi
+  _mem_hist=${BASH_REMATCH[1]}
+}
+_mem_prompt_done() {
+  _mem_prompt=
+}
+# Commands run before the first prompt come from startup files
+_mem_prompt=1
+trap '_mem_preexec' DEBUG
+PROMPT_COMMAND="_mem_precmd${PROMPT_COMMAND:+; $PROMPT_COMMAND}; _mem_prompt_done"
+`,
+	"fish": `# mem: record interactive commands
+function _mem_preexec --on-event fish_preexec
+    set -g _mem_dir $PWD
+end
+function _mem_postexec --on-event fish_postexec
+    set -l exit_code $status
+    test -n "$argv[1]"; or return
+    %[1]s shell-hook record --shell fish --exit $exit_code --duration-ms $CMD_DURATION --dir "$_mem_dir" -- "$argv[1]" >/dev/null 2>&1 &
+    disown 2>/dev/null
+end
+`,
+}
+
+func NewShellHookCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shell-hook <zsh|bash|fish>",
+		Short: "Print a shell hook that records the commands you run",
+		Long: `Print a hook for your shell's startup file that records each interactive
+command with the directory it ran in, its exit code and how long it took.
+
+'mem show' and the TUI list the commands run in a conversation's project while
+it was going on and up to an hour after, between its messages, and
+'mem timeline' shows them by day. Commands are stored in all_conversations.db,
+redacted like messages and encrypted with them if the database is. Commands
+starting with a space are not recorded.
+
+The bash hook uses the DEBUG trap and PROMPT_COMMAND, and replaces another
+DEBUG trap.`,
+		Example: `  # zsh: add to ~/.zshrc
+  eval "$(mem shell-hook zsh)"
+
+  # bash: add to ~/.bashrc
+  eval "$(mem shell-hook bash)"
+
+  # fish: add to ~/.config/fish/config.fish
+  mem shell-hook fish | source`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"zsh", "bash", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShellHook(args[0])
+		},
+	}
+
+	cmd.AddCommand(newShellRecordCommand())
+
+	return cmd
+}
+
+func newShellRecordCommand() *cobra.Command {
+	var shell, dir, start string
+	var exitCode int
+	var durationMS int64
+
+	cmd := &cobra.Command{
+		Use:    "record [flags] -- <command>",
+		Short:  "Record a shell command (run by the hook)",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command := strings.Join(args, " ")
+			// Like HISTCONTROL=ignorespace, a leading space keeps a command out
+			if strings.TrimSpace(command) == "" || strings.HasPrefix(command, " ") {
+				return nil
+			}
+
+			record := &models.ShellCommand{
+				Command:    strings.TrimRight(command, "\n"),
+				Dir:        dir,
+				ExitCode:   exitCode,
+				DurationMS: durationMS,
+				Shell:      shell,
+			}
+			now := time.Now()
+			if start != "" {
+				started, err := parseEpoch(start)
+				if err != nil {
+					return err
+				}
+				record.StartedAt = started
+				record.DurationMS = max(now.Sub(started).Milliseconds(), 0)
+			} else {
+				record.StartedAt = now.Add(-time.Duration(durationMS) * time.Millisecond)
+			}
+			if record.Dir == "" {
+				wd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+				record.Dir = wd
+			}
+
+			store, err := openDefaultStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+			return store.SaveShellCommand(record)
+		},
+	}
+
+	cmd.Flags().StringVar(&shell, "shell", "", "Shell the command ran in")
+	cmd.Flags().StringVar(&dir, "dir", "", "Directory the command ran in (default the current directory)")
+	cmd.Flags().IntVar(&exitCode, "exit", 0, "Exit code of the command")
+	cmd.Flags().StringVar(&start, "start", "", "When the command started, in seconds since the epoch")
+	cmd.Flags().Int64Var(&durationMS, "duration-ms", 0, "How long the command took, if --start is not given")
+
+	return cmd
+}
+
+func runShellHook(shell string) error {
+	hook, ok := shellHooks[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (supported: zsh, bash, fish)", shell)
+	}
+	memPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the mem executable: %w", err)
+	}
+
+	quote := shellQuote
+	if shell == "fish" {
+		quote = fishQuote
+	}
+	fmt.Printf(hook, quote(memPath))
+	return nil
+}
+
+// parseEpoch parses seconds since the epoch with an optional fraction, which
+// some locales write with a comma
+func parseEpoch(value string) (time.Time, error) {
+	seconds, fraction, _ := strings.Cut(strings.Replace(value, ",", ".", 1), ".")
+	secs, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start time %q", value)
+	}
+	var nanos int64
+	if fraction != "" {
+		fraction = (fraction + "000000000")[:9]
+		if nanos, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid start time %q", value)
+		}
+	}
+	return time.Unix(secs, nanos), nil
+}
+
+// shellQuote quotes s as a single word for sh, bash and zsh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s as a single word for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
\ No newline at end of file
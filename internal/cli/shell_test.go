@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEpoch(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{value: "1748772090", expected: time.Unix(1748772090, 0)},
+		{value: "1748772090.25", expected: time.Unix(1748772090, 250000000)},
+		{value: "1748772090,123456", expected: time.Unix(1748772090, 123456000)},
+		{value: "1748772090.1234567891", expected: time.Unix(1748772090, 123456789)},
+		{value: "yesterday", wantErr: true},
+		{value: "1748772090.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseEpoch(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEpoch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.expected) {
+				t.Errorf("parseEpoch() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShellHookQuoting(t *testing.T) {
+	if got := shellQuote("/opt/it's/mem"); got != `'/opt/it'\''s/mem'` {
+		t.Errorf("shellQuote() = %s", got)
+	}
+	if got := fishQuote(`/opt/it's\mem`); got != `'/opt/it\'s\\mem'` {
+		t.Errorf("fishQuote() = %s", got)
+	}
+
+	for shell, hook := range shellHooks {
+		// Every hook runs mem once and has no stray format verbs
+		script := strings.ReplaceAll(hook, "%[1]s", "MEM")
+		if strings.Count(script, "MEM shell-hook record --shell "+shell) != 1 || strings.Contains(strings.ReplaceAll(script, "%%", ""), "%") {
+			t.Errorf("Unexpected %s hook:\n%s", shell, hook)
+		}
+	}
+}
\ No newline at end of file
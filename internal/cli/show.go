@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+func NewShowCommand() *cobra.Command {
+	var showFiles bool
+
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a conversation",
+		Long: `Print a conversation with its messages and the commits 'mem git link' linked
+to it, and between the messages the shell commands 'mem shell-hook' recorded in
+its project while it went on and up to an hour after. With --files, list the
+files it read, edited, wrote, ran or mentioned instead of its messages.
+Conversations come from all_conversations.db unless --db is given.`,
+		Example: `  # Read a conversation
+  mem show 42
+
+  # Which files did it touch?
+  mem show 42 --files`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid conversation ID %q", args[0])
+			}
+			return runShow(id, showFiles)
+		},
+	}
+
+	cmd.Flags().BoolVar(&showFiles, "files", false, "List the files the conversation touched instead of its messages")
+
+	return withResults(cmd)
+}
+
+// showResult is a conversation with the shell commands run alongside it
+type showResult struct {
+	*models.Conversation
+	ShellCommands []models.ShellCommand `json:"shell_commands,omitempty"`
+}
+
+func runShow(id int64, showFiles bool) error {
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	conv, err := store.GetConversation(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("conversation %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	commits, err := store.ConversationCommits(id)
+	if err != nil {
+		return fmt.Errorf("failed to get commits: %w", err)
+	}
+
+	if !showFiles {
+		commands, err := store.ConversationShellCommands(id, storage.ShellWindow)
+		if err != nil {
+			return fmt.Errorf("failed to get shell commands: %w", err)
+		}
+		result := showResult{Conversation: conv, ShellCommands: commands}
+		return printResult(result, func() {
+			printConversationHeader(conv)
+			printConversationCommits(commits)
+			// Commands are printed before the first message sent after they started
+			next := 0
+			for _, msg := range conv.Messages {
+				for ; next < len(commands) && !msg.Timestamp.IsZero() && commands[next].StartedAt.Before(msg.Timestamp); next++ {
+					printShellCommand(commands[next])
+				}
+				fmt.Printf("\n%s:\n%s\n", roleLabel(msg.Role), strings.TrimSpace(msg.Content))
+			}
+			for ; next < len(commands); next++ {
+				printShellCommand(commands[next])
+			}
+		})
+	}
+
+	files, err := store.ConversationFiles(id)
+	if err != nil {
+		return fmt.Errorf("failed to get files: %w", err)
+	}
+	return printResult(files, func() {
+		printConversationHeader(conv)
+		printConversationCommits(commits)
+		if len(files) == 0 {
+			fmt.Println("\nNo files recorded.")
+			return
+		}
+
+		width := 0
+		for _, f := range files {
+			width = max(width, len(f.Path))
+		}
+		fmt.Printf("\n📁 %d file(s):\n", len(files))
+		for _, f := range files {
+			fmt.Printf("  %-*s  %s\n", width, f.Path, formatAccess(f.Access))
+		}
+	})
+}
+
+func printConversationHeader(conv *models.Conversation) {
+	fmt.Printf("[ID: %d] %s\n", conv.ID, conv.Title)
+	fmt.Printf("  Tool: %s", conv.Tool)
+	if conv.Project != "" {
+		fmt.Printf(" | Project: %s", conv.Project)
+	}
+	if len(conv.Tags) > 0 {
+		fmt.Printf(" | Tags: %s", strings.Join(conv.Tags, ", "))
+	}
+	if conv.SessionID != "" {
+		fmt.Printf("\n  Session: %s", conv.SessionID)
+	}
+	fmt.Printf("\n  Created: %s\n", conv.CreatedAt.Format("2006-01-02 15:04:05"))
+}
+
+// printConversationCommits lists the commits linked to a conversation by mem git link
+func printConversationCommits(commits []models.CommitLink) {
+	for _, c := range commits {
+		fmt.Printf("  Commit: %s %s\n", shortSHA(c.SHA), c.Subject)
+	}
+}
+
+func printShellCommand(cmd models.ShellCommand) {
+	fmt.Printf("\nShell (%s):\n$ %s\n", shellCommandInfo(cmd), cmd.Command)
+}
+
+// shellCommandInfo describes when and where a shell command ran, how long it
+// took and its exit code if it failed
+func shellCommandInfo(cmd models.ShellCommand) string {
+	info := fmt.Sprintf("%s in %s, %s", cmd.StartedAt.Local().Format("15:04:05"), cmd.Dir,
+		(time.Duration(cmd.DurationMS) * time.Millisecond).Round(100*time.Millisecond))
+	if cmd.ExitCode != 0 {
+		info += fmt.Sprintf(", exit %d", cmd.ExitCode)
+	}
+	return info
+}
+
+func roleLabel(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	}
+	return role
+}
\ No newline at end of file
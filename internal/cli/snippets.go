@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// snippetPreviewLines is how many lines of each search result are shown
+const snippetPreviewLines = 3
+
+func NewSnippetsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snippets",
+		Short: "Search code blocks from past answers",
+		Long: `Search and print the fenced code blocks of assistant messages. Code blocks are
+extracted when conversations are saved, with the language of their fence, and
+indexed apart from the conversations so a search matches code only.
+
+Snippets come from all_conversations.db unless --db is given. In the browser,
+:yank <id> copies a snippet to the clipboard.`,
+		Example: `  # Find Go code that used errgroup
+  mem snippets search errgroup --lang go
+
+  # Print a snippet, e.g. to save it
+  mem snippets show 42 > retry.go`,
+	}
+
+	cmd.AddCommand(
+		newSnippetsSearchCommand(),
+		newSnippetsShowCommand(),
+	)
+
+	return cmd
+}
+
+func newSnippetsSearchCommand() *cobra.Command {
+	var language string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search code snippets",
+		Long: `Search code snippets with the full-text syntax of mem search. --lang accepts
+common aliases such as golang, js, py and sh.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSnippetsSearch(strings.Join(args, " "), language, limit)
+		},
+	}
+
+	cmd.Flags().StringVar(&language, "lang", "", "Only show snippets in this language")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of snippets to show")
+
+	return withResults(cmd)
+}
+
+func newSnippetsShowCommand() *cobra.Command {
+	return withResults(&cobra.Command{
+		Use:   "show <id>",
+		Short: "Print a code snippet",
+		Long: `Print the code of a snippet. Only the code goes to stdout, so it can be
+redirected to a file; where it comes from goes to stderr.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid snippet ID %q", args[0])
+			}
+			return runSnippetsShow(id)
+		},
+	})
+}
+
+func runSnippetsSearch(query, language string, limit int) error {
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	snippets, err := store.SearchCodeSnippets(query, language, limit)
+	if err != nil {
+		return fmt.Errorf("failed to search snippets: %w", err)
+	}
+
+	return printResult(snippets, func() {
+		if len(snippets) == 0 {
+			fmt.Println("No snippets found.")
+			return
+		}
+
+		fmt.Printf("🔍 Found %d snippet(s):\n\n", len(snippets))
+		for _, s := range snippets {
+			fmt.Printf("[#%d] %s\n", s.ID, snippetSummary(s))
+			lines := strings.Split(s.Code, "\n")
+			for i, line := range lines {
+				if i == snippetPreviewLines {
+					fmt.Printf("    … %d more line(s)\n", len(lines)-i)
+					break
+				}
+				fmt.Printf("    %s\n", line)
+			}
+			fmt.Println()
+		}
+	})
+}
+
+func runSnippetsShow(id int64) error {
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	snippet, err := store.GetCodeSnippet(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("snippet %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get snippet: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📝 %s\n", snippetSummary(*snippet))
+	return printResult(snippet, func() {
+		fmt.Println(snippet.Code)
+	})
+}
+
+// snippetSummary describes a snippet and where it comes from on one line
+func snippetSummary(s models.CodeSnippet) string {
+	language := s.Language
+	if language == "" {
+		language = "text"
+	}
+	lines := fmt.Sprintf("line %d", s.StartLine)
+	if s.EndLine > s.StartLine {
+		lines = fmt.Sprintf("lines %d-%d", s.StartLine, s.EndLine)
+	}
+	return fmt.Sprintf("%s · %s · %s (conversation %d)", language, lines, s.ConversationTitle, s.ConversationID)
+}
\ No newline at end of file
@@ -2,11 +2,8 @@ package cli
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
 func NewStatsCommand() *cobra.Command {
@@ -31,7 +28,7 @@ func NewStatsCommand() *cobra.Command {
 
 	cmd.Flags().BoolVar(&useAll, "all", false, "Show stats for all imported conversations (all_conversations.db)")
 
-	return cmd
+	return withResults(cmd)
 }
 
 func runStats(customDB string, useAll bool) error {
@@ -39,14 +36,10 @@ func runStats(customDB string, useAll bool) error {
 
 	// If --all flag is used and no custom DB specified, use all_conversations.db
 	if useAll && customDB == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		database = filepath.Join(homeDir, ".ai-memory", "all_conversations.db")
+		database = settings().Storage.Database
 	}
 
-	store, err := storage.NewSQLiteStore(database)
+	store, err := openStore(database)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -56,27 +49,28 @@ func runStats(customDB string, useAll bool) error {
 	if err != nil {
 		return fmt.Errorf("failed to get statistics: %w", err)
 	}
-
-	fmt.Println("AI Memory Statistics")
-	fmt.Println("====================")
-	fmt.Printf("\nTotal Conversations: %d\n", stats.TotalConversations)
-	fmt.Printf("Total Messages: %d\n", stats.TotalMessages)
-	fmt.Printf("Total Tokens: %d\n", stats.TotalTokens)
-	fmt.Printf("Estimated Cost: $%.4f\n", stats.EstimatedCost)
-
-	if len(stats.ToolBreakdown) > 0 {
-		fmt.Println("\nConversations by Tool:")
-		for tool, count := range stats.ToolBreakdown {
-			fmt.Printf("  %s: %d\n", tool, count)
+	stats.EstimatedCost = float64(stats.TotalTokens) * settings().Pricing.USDPerMillionTokens / 1e6
+
+	return printResult(stats, func() {
+		fmt.Println("AI Memory Statistics")
+		fmt.Println("====================")
+		fmt.Printf("\nTotal Conversations: %d\n", stats.TotalConversations)
+		fmt.Printf("Total Messages: %d\n", stats.TotalMessages)
+		fmt.Printf("Total Tokens: %d\n", stats.TotalTokens)
+		fmt.Printf("Estimated Cost: $%.4f\n", stats.EstimatedCost)
+
+		if len(stats.ToolBreakdown) > 0 {
+			fmt.Println("\nConversations by Tool:")
+			for _, tool := range sortedCounts(stats.ToolBreakdown) {
+				fmt.Printf("  %s: %d\n", tool, stats.ToolBreakdown[tool])
+			}
 		}
-	}
 
-	if len(stats.ProjectBreakdown) > 0 {
-		fmt.Println("\nConversations by Project:")
-		for project, count := range stats.ProjectBreakdown {
-			fmt.Printf("  %s: %d\n", project, count)
+		if len(stats.ProjectBreakdown) > 0 {
+			fmt.Println("\nConversations by Project:")
+			for _, project := range sortedCounts(stats.ProjectBreakdown) {
+				fmt.Printf("  %s: %d\n", project, stats.ProjectBreakdown[project])
+			}
 		}
-	}
-
-	return nil
+	})
 }
\ No newline at end of file
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// Kinds of timeline entries
+const (
+	timelineSession = "session"
+	timelineCommand = "command"
+)
+
+// timelineEntry is a conversation or a shell command, at the time it started
+type timelineEntry struct {
+	Time    time.Time            `json:"time"`
+	Kind    string               `json:"kind"`
+	Session *models.SessionSpan  `json:"session,omitempty"`
+	Command *models.ShellCommand `json:"command,omitempty"`
+}
+
+func NewTimelineCommand() *cobra.Command {
+	var since, projectFilter string
+	var noCommands bool
+
+	cmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Show conversations and shell commands day by day",
+		Long: `Show the conversations and the shell commands recorded by 'mem shell-hook'
+since a given age or date, oldest first and grouped by day. Each conversation
+is shown with how long it went on, from its first message to its last, its
+message count and tool; each day with its totals. With --project, only the
+commands run in the directories of the project's conversations are shown.
+Conversations come from all_conversations.db unless --db is given.`,
+		Example: `  # The last week
+  mem timeline
+
+  # This project since the start of the month, without shell commands
+  mem timeline --project . --since 2025-06-01 --no-commands`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validator := NewValidator()
+			sinceTime, err := validator.ParseSince(since)
+			if err != nil {
+				return err
+			}
+			project, err := validator.ParseProject(projectFilter)
+			if err != nil {
+				return err
+			}
+			return runTimeline(storage.ConversationFilter{Project: project}, sinceTime, !noCommands)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "7d", "Show activity since this age or date (e.g. 7d, 12h, 2006-01-02)")
+	cmd.Flags().StringVar(&projectFilter, "project", "", "Only show this project (. for the current directory)")
+	cmd.Flags().BoolVar(&noCommands, "no-commands", false, "Leave out shell commands")
+
+	return withResults(cmd)
+}
+
+func runTimeline(filter storage.ConversationFilter, since time.Time, withCommands bool) error {
+	store, err := openDefaultStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	spans, err := store.SessionSpans(filter, since)
+	if err != nil {
+		return fmt.Errorf("failed to read conversations: %w", err)
+	}
+	entries := []timelineEntry{}
+	for i := range spans {
+		entries = append(entries, timelineEntry{Time: spans[i].Start, Kind: timelineSession, Session: &spans[i]})
+	}
+
+	if withCommands {
+		commands, err := store.ShellCommands(storage.ShellFilter{Since: since})
+		if err != nil {
+			return fmt.Errorf("failed to read shell commands: %w", err)
+		}
+		for i := range commands {
+			if filter.Project != "" && !coveredBySession(spans, commands[i].Dir) {
+				continue
+			}
+			entries = append(entries, timelineEntry{Time: commands[i].StartedAt, Kind: timelineCommand, Command: &commands[i]})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	activity, err := store.Activity(filter, since, time.Local)
+	if err != nil {
+		return fmt.Errorf("failed to read activity: %w", err)
+	}
+	days := make(map[string]models.DayActivity)
+	for _, day := range activity.Days {
+		days[day.Date] = day
+	}
+
+	return printResult(entries, func() {
+		if len(entries) == 0 {
+			fmt.Println("No activity found.")
+			return
+		}
+
+		for i := 0; i < len(entries); {
+			date := entries[i].Time.Local().Format("2006-01-02")
+			end := i
+			for end < len(entries) && entries[end].Time.Local().Format("2006-01-02") == date {
+				end++
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			printTimelineDay(entries[i:end], days[date])
+			i = end
+		}
+	})
+}
+
+// printTimelineDay prints the entries of a day under a heading with its totals
+func printTimelineDay(entries []timelineEntry, day models.DayActivity) {
+	var sessionTime time.Duration
+	commands := 0
+	for _, e := range entries {
+		if e.Kind == timelineSession {
+			sessionTime += e.Session.End.Sub(e.Session.Start)
+		} else {
+			commands++
+		}
+	}
+
+	fmt.Printf("📅 %s — %d conversation(s), %d message(s)", entries[0].Time.Local().Format("Monday, 2006-01-02"),
+		day.Conversations, day.Messages)
+	if sessionTime > 0 {
+		fmt.Printf(", %s in sessions", formatSpan(sessionTime))
+	}
+	if commands > 0 {
+		fmt.Printf(", %d command(s)", commands)
+	}
+	fmt.Println()
+
+	for _, e := range entries {
+		at := e.Time.Local().Format("15:04")
+		switch e.Kind {
+		case timelineSession:
+			span := e.Session
+			fmt.Printf("  %s  [ID: %d] %s\n", at, span.Conversation.ID, span.Conversation.Title)
+			fmt.Printf("         %s–%s (%s) · %d message(s) · %s\n", at, span.End.Local().Format("15:04"),
+				formatSpan(span.End.Sub(span.Start)), span.MessageCount, span.Conversation.Tool)
+		case timelineCommand:
+			fmt.Printf("  %s  $ %s", at, e.Command.Command)
+			if e.Command.ExitCode != 0 {
+				fmt.Printf("  (exit %d)", e.Command.ExitCode)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// coveredBySession reports whether dir is the directory one of the sessions
+// ran in or one below it
+func coveredBySession(spans []models.SessionSpan, dir string) bool {
+	for _, span := range spans {
+		if span.Conversation.Covers(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSpan formats how long a session went on in hours and minutes
+func formatSpan(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
\ No newline at end of file
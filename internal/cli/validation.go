@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Validator provides methods for validating CLI inputs
@@ -82,11 +85,7 @@ func (v *Validator) ResolvePath(path string) (string, error) {
 
 // GetDefaultDatabasePath returns the default database path
 func (v *Validator) GetDefaultDatabasePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(homeDir, ".ai-memory", "all_conversations.db"), nil
+	return settings().Storage.Database, nil
 }
 
 // GetProjectDatabasePath returns the database path for a specific project
@@ -101,4 +100,97 @@ func (v *Validator) GetProjectDatabasePath(projectDir string) (string, error) {
 	}
 
 	return filepath.Join(resolvedDir, ".ai-memory", "conversations.db"), nil
+}
+
+// ParseProject resolves a --project value, where "." names the project of the
+// current directory
+func (v *Validator) ParseProject(value string) (string, error) {
+	if value != "." {
+		return value, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Base(wd), nil
+}
+
+// ParseSince parses a --since value, either a relative age such as "7d",
+// "12h" or "30m", or an absolute date in YYYY-MM-DD or RFC3339 form
+func (v *Validator) ParseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days >= 0 {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 7d, 12h or 2006-01-02)", value)
+}
+
+// ParseAge parses a retention age such as "90d", "12h" or "30m"
+func (v *Validator) ParseAge(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days >= 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("invalid age %q (use e.g. 90d or 12h)", value)
+}
+
+// ParseSize parses a byte size such as "500MB", "2GB" or a plain byte count
+func (v *Validator) ParseSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix)), 64)
+			if err != nil || n < 0 {
+				break
+			}
+			return int64(n * float64(unit.factor)), nil
+		}
+	}
+
+	if n, err := strconv.ParseInt(upper, 10, 64); err == nil && n >= 0 {
+		return n, nil
+	}
+
+	return 0, fmt.Errorf("invalid size %q (use e.g. 500MB or 2GB)", value)
 }
\ No newline at end of file
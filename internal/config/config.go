@@ -0,0 +1,341 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jasperwreed/ai-memory/internal/scanner"
+)
+
+// PathEnv overrides where the config file is read from
+const PathEnv = "AI_MEMORY_CONFIG"
+
+// envPrefix starts the environment variables that override single settings,
+// e.g. AI_MEMORY_DAEMON_BATCH_SIZE for daemon.batch_size
+const envPrefix = "AI_MEMORY_"
+
+// Config holds the settings read from ~/.ai-memory/config.toml
+type Config struct {
+	Storage   StorageConfig   `toml:"storage"`
+	Daemon    DaemonConfig    `toml:"daemon"`
+	Scanners  ScannersConfig  `toml:"scanners"`
+	Redaction RedactionConfig `toml:"redaction"`
+	Pricing   PricingConfig   `toml:"pricing"`
+}
+
+// StorageConfig sets where data is kept. Paths left empty are placed in DataDir.
+type StorageConfig struct {
+	DataDir      string `toml:"data_dir"`
+	Database     string `toml:"database"`
+	AuditDir     string `toml:"audit_dir"`
+	AuditKeyFile string `toml:"audit_key_file"`
+	DBKeyFile    string `toml:"db_key_file"`
+	DBKeyCmd     string `toml:"db_key_cmd"`
+}
+
+// DaemonConfig holds the capture daemon settings
+type DaemonConfig struct {
+	// WatchDirs adds directories to the trees of the enabled scanners. It is
+	// the one setting a running daemon reloads.
+	WatchDirs      []string `toml:"watch_dirs"`
+	FlushInterval  string   `toml:"flush_interval"`
+	BatchSize      int      `toml:"batch_size"`
+	MaxShardSize   int64    `toml:"max_shard_size"`
+	CompressShards bool     `toml:"compress_shards"`
+	Ingest         bool     `toml:"ingest"`
+	QueueMaxBytes  int64    `toml:"queue_max_bytes"`
+	LogFile        string   `toml:"log_file"`
+	MetricsAddr    string   `toml:"metrics_addr"`
+	Recipients     []string `toml:"recipients"`
+}
+
+// ScannersConfig selects the session scanners used by mem scan and the daemon
+type ScannersConfig struct {
+	// Disabled lists tools, such as "claude-code", whose sessions are ignored
+	Disabled []string `toml:"disabled"`
+}
+
+// RedactionConfig scrubs matching text before conversations are stored
+type RedactionConfig struct {
+	Patterns    []string `toml:"patterns"`
+	Replacement string   `toml:"replacement"`
+}
+
+// PricingConfig sets how token counts are turned into cost estimates
+type PricingConfig struct {
+	USDPerMillionTokens float64 `toml:"usd_per_million_tokens"`
+}
+
+// ValidationError lists every problem found in a config
+type ValidationError struct {
+	Source   string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config %s:", e.Source)
+	for _, problem := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(problem)
+	}
+	return b.String()
+}
+
+// Default returns the settings used when nothing is configured
+func Default() *Config {
+	home, _ := os.UserHomeDir()
+	return &Config{
+		Storage: StorageConfig{
+			DataDir: filepath.Join(home, ".ai-memory"),
+		},
+		Daemon: DaemonConfig{
+			FlushInterval:  "5s",
+			BatchSize:      100,
+			MaxShardSize:   100 * 1024 * 1024,
+			CompressShards: true,
+			Ingest:         true,
+			QueueMaxBytes:  64 * 1024 * 1024,
+		},
+		Redaction: RedactionConfig{
+			Replacement: "[REDACTED]",
+		},
+		Pricing: PricingConfig{
+			USDPerMillionTokens: 3,
+		},
+	}
+}
+
+// Builtin returns the defaults with every path filled in, as Load returns
+// them when there is no config file
+func Builtin() *Config {
+	cfg := Default()
+	cfg.resolve()
+	return cfg
+}
+
+// Path returns the config file path: $AI_MEMORY_CONFIG, or
+// ~/.ai-memory/config.toml
+func Path() string {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ai-memory", "config.toml")
+}
+
+// Load reads the config file at path over the defaults, applies environment
+// overrides and validates the result. A missing file is not an error.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse is Load for config data already read from source
+func Parse(source string, data []byte) (*Config, error) {
+	cfg := Default()
+	var problems []string
+
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return nil, fmt.Errorf("failed to parse %s: %s", source, perr.ErrorWithPosition())
+		}
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	for _, key := range md.Undecoded() {
+		problems = append(problems, unknownKey(key.String()))
+	}
+
+	problems = append(problems, cfg.applyEnv()...)
+	cfg.resolve()
+	problems = append(problems, cfg.problems()...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Source: source, Problems: problems}
+	}
+	return cfg, nil
+}
+
+// Encode writes cfg as TOML
+func (c *Config) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// applyEnv sets fields from AI_MEMORY_<SECTION>_<KEY> variables
+func (c *Config) applyEnv() []string {
+	var problems []string
+	for _, f := range c.fields() {
+		value, ok := os.LookupEnv(f.Env)
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Env, err))
+		}
+	}
+	return problems
+}
+
+// resolve expands ~ in paths and places unset paths in the data directory
+func (c *Config) resolve() {
+	s := &c.Storage
+	s.DataDir = expandHome(s.DataDir)
+	s.Database = orDefault(expandHome(s.Database), filepath.Join(s.DataDir, "all_conversations.db"))
+	s.AuditDir = orDefault(expandHome(s.AuditDir), filepath.Join(s.DataDir, "audit"))
+	s.AuditKeyFile = orDefault(expandHome(s.AuditKeyFile), filepath.Join(s.DataDir, "audit.key"))
+	s.DBKeyFile = expandHome(s.DBKeyFile)
+
+	c.Daemon.LogFile = orDefault(expandHome(c.Daemon.LogFile), filepath.Join(s.DataDir, "daemon.log"))
+	for i, dir := range c.Daemon.WatchDirs {
+		c.Daemon.WatchDirs[i] = expandHome(dir)
+	}
+}
+
+// problems returns what is wrong with a resolved config
+func (c *Config) problems() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !filepath.IsAbs(c.Storage.DataDir) {
+		add("storage.data_dir: %q is not an absolute path; use an absolute path or one starting with ~/", c.Storage.DataDir)
+	}
+
+	d := c.Daemon
+	if interval, err := time.ParseDuration(d.FlushInterval); err != nil || interval <= 0 {
+		add("daemon.flush_interval: %q is not a positive duration; use a value such as \"5s\" or \"1m\"", d.FlushInterval)
+	}
+	if d.BatchSize < 1 {
+		add("daemon.batch_size: must be at least 1, got %d", d.BatchSize)
+	}
+	if d.MaxShardSize < 1024 {
+		add("daemon.max_shard_size: must be at least 1024 bytes, got %d", d.MaxShardSize)
+	}
+	if d.QueueMaxBytes < 1024 {
+		add("daemon.queue_max_bytes: must be at least 1024 bytes, got %d", d.QueueMaxBytes)
+	}
+	for _, dir := range d.WatchDirs {
+		if !filepath.IsAbs(dir) {
+			add("daemon.watch_dirs: %q is not an absolute path; use an absolute path or one starting with ~/", dir)
+		}
+	}
+	if d.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(d.MetricsAddr); err != nil {
+			add("daemon.metrics_addr: %q is not host:port; use a value such as \"127.0.0.1:9464\"", d.MetricsAddr)
+		}
+	}
+
+	known := map[string]bool{}
+	var tools []string
+	for _, s := range scanner.Registered() {
+		known[s.Tool()] = true
+		tools = append(tools, s.Tool())
+	}
+	for _, tool := range c.Scanners.Disabled {
+		if !known[tool] {
+			add("scanners.disabled: unknown scanner %q; known scanners: %s", tool, strings.Join(tools, ", "))
+		}
+	}
+
+	for _, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add("redaction.patterns: %q is not a valid regular expression: %v", pattern, err)
+		}
+	}
+
+	if c.Pricing.USDPerMillionTokens < 0 {
+		add("pricing.usd_per_million_tokens: must not be negative, got %v", c.Pricing.USDPerMillionTokens)
+	}
+
+	return problems
+}
+
+// ScannerEnabled reports whether sessions of tool are scanned and captured
+func (c *Config) ScannerEnabled(tool string) bool {
+	for _, disabled := range c.Scanners.Disabled {
+		if disabled == tool {
+			return false
+		}
+	}
+	return true
+}
+
+// unknownKey describes a key that is not part of the config, suggesting the
+// closest known key
+func unknownKey(key string) string {
+	best, bestDistance := "", 3
+	for _, known := range Keys() {
+		if d := editDistance(key, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("%s: unknown key; did you mean %s?", key, best)
+	}
+	return fmt.Sprintf("%s: unknown key; see 'mem config show' for the known keys", key)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// Keys returns every config key, such as daemon.batch_size, sorted
+func Keys() []string {
+	var keys []string
+	for _, f := range Default().fields() {
+		keys = append(keys, f.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	return path
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
\ No newline at end of file
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDefaults(t *testing.T) {
+	t.Setenv("HOME", "/home/u")
+
+	cfg, err := Parse("test", nil)
+	if err != nil {
+		t.Fatalf("Expected defaults to be valid: %v", err)
+	}
+	if cfg.Storage.Database != "/home/u/.ai-memory/all_conversations.db" {
+		t.Errorf("Unexpected database path %q", cfg.Storage.Database)
+	}
+	if cfg.Daemon.LogFile != "/home/u/.ai-memory/daemon.log" {
+		t.Errorf("Unexpected log file %q", cfg.Daemon.LogFile)
+	}
+
+	// Paths not set follow the data directory
+	cfg, err = Parse("test", []byte("[storage]\ndata_dir = \"~/mem\"\n\n[daemon]\nwatch_dirs = [\"~/logs\"]\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if cfg.Storage.AuditDir != "/home/u/mem/audit" {
+		t.Errorf("Expected audit dir in data dir, got %q", cfg.Storage.AuditDir)
+	}
+	if cfg.Daemon.WatchDirs[0] != "/home/u/logs" {
+		t.Errorf("Expected ~ to be expanded, got %q", cfg.Daemon.WatchDirs[0])
+	}
+}
+
+func TestParseReportsEveryProblem(t *testing.T) {
+	data := []byte(`
+[daemon]
+flush_interval = ""
+batch_size = 0
+batch_sise = 10
+watch_dirs = ["relative/dir"]
+
+[redaction]
+patterns = ["("]
+`)
+	_, err := Parse("test.toml", data)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	for _, want := range []string{
+		"daemon.batch_sise: unknown key; did you mean daemon.batch_size?",
+		`daemon.flush_interval: "" is not a positive duration`,
+		"daemon.batch_size: must be at least 1, got 0",
+		`daemon.watch_dirs: "relative/dir" is not an absolute path`,
+		`redaction.patterns: "(" is not a valid regular expression`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv("AI_MEMORY_DAEMON_BATCH_SIZE", "7")
+	t.Setenv("AI_MEMORY_SCANNERS_DISABLED", "claude-code")
+
+	cfg, err := Parse("test", []byte("[daemon]\nbatch_size = 50\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if cfg.Daemon.BatchSize != 7 {
+		t.Errorf("Expected the environment to win, got %d", cfg.Daemon.BatchSize)
+	}
+	if cfg.ScannerEnabled("claude-code") {
+		t.Error("Expected claude-code to be disabled")
+	}
+
+	t.Setenv("AI_MEMORY_DAEMON_BATCH_SIZE", "many")
+	if _, err := Parse("test", nil); err == nil || !strings.Contains(err.Error(), `AI_MEMORY_DAEMON_BATCH_SIZE: "many" is not a whole number`) {
+		t.Errorf("Expected a bad override to be reported, got %v", err)
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[pricing]\nusd_per_million_tokens = 15.0\n"), 0600)
+
+	if err := SetInFile(path, "daemon.watch_dirs", "/a, /b"); err != nil {
+		t.Fatalf("Failed to set: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if got, _ := cfg.Get("daemon.watch_dirs"); got != "/a\n/b" {
+		t.Errorf("Expected both watch dirs, got %q", got)
+	}
+	if cfg.Pricing.USDPerMillionTokens != 15 {
+		t.Errorf("Expected other settings to be kept, got %v", cfg.Pricing.USDPerMillionTokens)
+	}
+
+	// An invalid value leaves the file alone
+	before, _ := os.ReadFile(path)
+	if err := SetInFile(path, "daemon.flush_interval", "soon"); err == nil {
+		t.Fatal("Expected an invalid value to be rejected")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("Expected the file to be unchanged")
+	}
+
+	// Items with commas are given as a TOML array
+	if err := SetInFile(path, "redaction.patterns", `['sk-\w{16,}', "a,b"]`); err != nil {
+		t.Fatalf("Failed to set list: %v", err)
+	}
+	cfg, _ = Load(path)
+	if len(cfg.Redaction.Patterns) != 2 || cfg.Redaction.Patterns[0] != `sk-\w{16,}` {
+		t.Errorf("Expected the array items, got %q", cfg.Redaction.Patterns)
+	}
+
+	if err := SetInFile(path, "daemon.nope", "1"); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+}
\ No newline at end of file
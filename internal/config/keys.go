@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// field is one setting, addressed as section.key in files and commands
+type field struct {
+	Key   string
+	Env   string
+	value reflect.Value
+}
+
+// fields returns every setting of c, in declaration order
+func (c *Config) fields() []field {
+	var fields []field
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Type().Field(i).Tag.Get("toml")
+		values := sections.Field(i)
+		for j := 0; j < values.NumField(); j++ {
+			name := values.Type().Field(j).Tag.Get("toml")
+			fields = append(fields, field{
+				Key:   section + "." + name,
+				Env:   envPrefix + strings.ToUpper(section+"_"+name),
+				value: values.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+// lookup returns the field for key
+func (c *Config) lookup(key string) (field, error) {
+	for _, f := range c.fields() {
+		if f.Key == key {
+			return f, nil
+		}
+	}
+	return field{}, fmt.Errorf("%s", unknownKey(key))
+}
+
+// set parses value into the field. Lists are comma-separated, or written as
+// a TOML array when items contain commas.
+func (f field) set(value string) error {
+	value = strings.TrimSpace(value)
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		f.value.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		f.value.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		f.value.SetBool(b)
+	case reflect.Slice:
+		if strings.HasPrefix(value, "[") {
+			var list struct {
+				Items []string `toml:"items"`
+			}
+			if _, err := toml.Decode("items = "+value, &list); err != nil {
+				return fmt.Errorf("%s is not a list of strings", value)
+			}
+			f.value.Set(reflect.ValueOf(list.Items))
+			return nil
+		}
+
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("cannot set %s", f.Key)
+	}
+	return nil
+}
+
+// String formats the field's value, one line per item for lists
+func (f field) String() string {
+	if f.value.Kind() == reflect.Slice {
+		items := f.value.Interface().([]string)
+		return strings.Join(items, "\n")
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// Get returns the value of key, such as daemon.batch_size
+func (c *Config) Get(key string) (string, error) {
+	f, err := c.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return f.String(), nil
+}
+
+// SetInFile sets key to value in the config file at path, creating the file
+// if needed. Other settings in the file are kept, but comments are not. The
+// file is only written if the result is valid.
+func SetInFile(path, key, value string) error {
+	f, err := Default().lookup(key)
+	if err != nil {
+		return err
+	}
+	if err := f.set(value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	settings := map[string]map[string]any{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if _, err := toml.Decode(string(data), &settings); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	section, name, _ := strings.Cut(key, ".")
+	if settings[section] == nil {
+		settings[section] = map[string]any{}
+	}
+	settings[section][name] = f.value.Interface()
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(settings); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if _, err := Parse(path, buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+// ErrNotRunning is returned when no daemon answers on the control socket
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Client talks to a running daemon over its control socket
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client for the daemon writing to auditDir
+func NewClient(auditDir string) *Client {
+	socket := ControlSocket(auditDir)
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}
+
+	return &Client{
+		// Shutdown waits for the daemon to flush, which can take a while
+		http: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// Status returns the daemon's status
+func (c *Client) Status() (*DaemonStatus, error) {
+	var status DaemonStatus
+	if err := c.do(http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Metrics returns the daemon's metrics
+func (c *Client) Metrics() (*Metrics, error) {
+	var metrics Metrics
+	if err := c.do(http.MethodGet, "/metrics", nil, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// Sessions returns the session files the daemon is tailing
+func (c *Client) Sessions() ([]watcher.SessionInfo, error) {
+	var sessions []watcher.SessionInfo
+	if err := c.do(http.MethodGet, "/sessions", nil, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Watch adds a directory tree to watch
+func (c *Client) Watch(dir string) error {
+	return c.do(http.MethodPost, "/watch", watchRequest{Dir: dir}, nil)
+}
+
+// Unwatch stops watching a directory tree
+func (c *Client) Unwatch(dir string) error {
+	return c.do(http.MethodPost, "/unwatch", watchRequest{Dir: dir}, nil)
+}
+
+// Flush makes the daemon write everything captured so far
+func (c *Client) Flush() error {
+	return c.do(http.MethodPost, "/flush", nil, nil)
+}
+
+// Pause makes the daemon stop reading session files
+func (c *Client) Pause() error {
+	return c.do(http.MethodPost, "/pause", nil, nil)
+}
+
+// Resume makes a paused daemon read session files again
+func (c *Client) Resume() error {
+	return c.do(http.MethodPost, "/resume", nil, nil)
+}
+
+// Reload makes the daemon reload its config file
+func (c *Client) Reload() error {
+	return c.do(http.MethodPost, "/reload", nil, nil)
+}
+
+// Shutdown stops the daemon and returns once it has flushed everything
+func (c *Client) Shutdown() error {
+	return c.do(http.MethodPost, "/shutdown", nil, nil)
+}
+
+// do sends a request and decodes the reply into out
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, "http://daemon"+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return ErrNotRunning
+		}
+		return fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result controlResult
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Error == "" {
+			return fmt.Errorf("daemon returned %s", resp.Status)
+		}
+		return errors.New(result.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode daemon reply: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+// ControlSocket returns the path of the control socket of an audit directory
+func ControlSocket(auditDir string) string {
+	return filepath.Join(auditDir, "daemon.sock")
+}
+
+// controlResult acknowledges a control command
+type controlResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// watchRequest names a directory to watch or unwatch
+type watchRequest struct {
+	Dir string `json:"dir"`
+}
+
+// serveControl starts serving the control API on the daemon's socket. The
+// API is HTTP with JSON bodies; only the daemon's user can connect.
+func (d *CaptureDaemon) serveControl() error {
+	path := ControlSocket(d.config.AuditDir)
+
+	// The instance lock is held, so a socket left here is stale
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, d.Status())
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, d.Metrics())
+	})
+	mux.HandleFunc("GET /sessions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, d.Sessions())
+	})
+	mux.HandleFunc("POST /watch", d.handleWatch(d.Watch))
+	mux.HandleFunc("POST /unwatch", d.handleWatch(d.Unwatch))
+	mux.HandleFunc("POST /flush", func(w http.ResponseWriter, r *http.Request) {
+		d.Flush()
+		writeJSON(w, http.StatusOK, controlResult{OK: true})
+	})
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, r *http.Request) {
+		d.Pause()
+		writeJSON(w, http.StatusOK, controlResult{OK: true})
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		d.Resume()
+		writeJSON(w, http.StatusOK, controlResult{OK: true})
+	})
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.Reload(); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, controlResult{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, controlResult{OK: true})
+	})
+	mux.HandleFunc("POST /shutdown", func(w http.ResponseWriter, r *http.Request) {
+		// Reply once everything is flushed, so the caller knows nothing was lost
+		go d.Stop()
+		select {
+		case <-d.stopped:
+			writeJSON(w, http.StatusOK, controlResult{OK: true})
+		case <-r.Context().Done():
+		}
+	})
+
+	d.control = &http.Server{Handler: mux}
+	go d.control.Serve(listener)
+	return nil
+}
+
+// handleWatch serves a command taking a directory
+func (d *CaptureDaemon) handleWatch(apply func(dir string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req watchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Dir == "" {
+			writeJSON(w, http.StatusBadRequest, controlResult{Error: "a directory is required"})
+			return
+		}
+
+		if err := apply(req.Dir); err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, watcher.ErrNotWatched) {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, controlResult{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, controlResult{OK: true})
+	}
+}
+
+// closeControl stops serving the control socket, letting in-flight requests finish
+func (d *CaptureDaemon) closeControl() {
+	if d.control == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d.control.Shutdown(ctx)
+	os.Remove(ControlSocket(d.config.AuditDir))
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
\ No newline at end of file
@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestDaemon runs a daemon with its state under a temp HOME
+func startTestDaemon(t *testing.T) (*CaptureDaemon, string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	config := DefaultConfig()
+	config.Ingest = false
+	config.FlushInterval = "1h" // only explicit flushes write
+	d, err := NewCaptureDaemon(config)
+	if err != nil {
+		t.Fatalf("Failed to create daemon: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	t.Cleanup(func() { d.Stop() })
+	return d, home
+}
+
+func TestControlAPI(t *testing.T) {
+	d, home := startTestDaemon(t)
+	client := NewClient(d.config.AuditDir)
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Status != "running" || status.PID != os.Getpid() {
+		t.Errorf("Unexpected status %q for PID %d", status.Status, status.PID)
+	}
+
+	// Watch a directory at runtime and pick up a session in it
+	extra := filepath.Join(home, "transcripts")
+	os.MkdirAll(extra, 0755)
+	os.WriteFile(filepath.Join(extra, "a.jsonl"), []byte(`{"sessionId":"a"}`+"\n"), 0644)
+	if err := client.Watch(extra); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	sessions, err := client.Sessions()
+	if err != nil || len(sessions) != 1 || sessions[0].SessionID != "a" {
+		t.Fatalf("Expected session a, got %+v (%v)", sessions, err)
+	}
+
+	// Flush writes the line to the audit log without waiting for the interval
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	metrics, err := client.Metrics()
+	if err != nil || metrics.EventsProcessed == 0 || metrics.BytesWritten == 0 {
+		t.Errorf("Expected processed events after flush, got %+v (%v)", metrics, err)
+	}
+
+	// Lines written while paused are read on resume
+	if err := client.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if status, _ := client.Status(); status.Status != "paused" {
+		t.Errorf("Expected paused status, got %q", status.Status)
+	}
+	f, _ := os.OpenFile(filepath.Join(extra, "a.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"sessionId":"a","n":2}` + "\n")
+	f.Close()
+	time.Sleep(700 * time.Millisecond)
+	if sessions, _ := client.Sessions(); sessions[0].Offset != sessions[0].Size-int64(len(`{"sessionId":"a","n":2}`)+1) {
+		t.Errorf("Paused daemon read ahead: offset %d of %d", sessions[0].Offset, sessions[0].Size)
+	}
+	if err := client.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if sessions, _ := client.Sessions(); sessions[0].Offset != sessions[0].Size {
+		t.Errorf("Resume did not catch up: offset %d of %d", sessions[0].Offset, sessions[0].Size)
+	}
+
+	if err := client.Unwatch(extra); err != nil {
+		t.Fatalf("Unwatch failed: %v", err)
+	}
+	if sessions, _ := client.Sessions(); len(sessions) != 0 {
+		t.Errorf("Expected no sessions after unwatch, got %d", len(sessions))
+	}
+	if err := client.Unwatch(extra); err == nil || !strings.Contains(err.Error(), "not watched") {
+		t.Errorf("Expected not watched error, got %v", err)
+	}
+
+	// Shutdown is acknowledged once the daemon has flushed, and the lock
+	// goes right after
+	if err := client.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, running := RunningPID(d.config.AuditDir); !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Daemon still holds its lock after shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := client.Status(); err != ErrNotRunning {
+		t.Errorf("Expected ErrNotRunning after shutdown, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	d, home := startTestDaemon(t)
+	client := NewClient(d.config.AuditDir)
+
+	if err := client.Reload(); err == nil {
+		t.Error("Expected reload to fail without a config to reload")
+	}
+
+	extra := filepath.Join(home, "transcripts")
+	os.MkdirAll(extra, 0755)
+	var watchDirs []string
+	var loadErr error
+	d.SetReloader("", func() (*CaptureConfig, error) {
+		config := DefaultConfig()
+		config.WatchDirs = watchDirs
+		return config, loadErr
+	})
+
+	watching := func() bool {
+		for _, root := range d.watcher.WatchedRoots() {
+			if root == extra {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Added watch directories are watched, removed ones are not
+	watchDirs = []string{extra}
+	if err := client.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !watching() {
+		t.Fatal("Expected the added directory to be watched")
+	}
+
+	// A config that fails to load keeps the current settings
+	watchDirs, loadErr = nil, errors.New("bad config")
+	if err := client.Reload(); err == nil || !strings.Contains(err.Error(), "bad config") {
+		t.Errorf("Expected the load error, got %v", err)
+	}
+	if !watching() {
+		t.Fatal("Expected a failed reload to keep watching")
+	}
+
+	loadErr = nil
+	if err := client.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if watching() {
+		t.Error("Expected the removed directory not to be watched")
+	}
+}
+
+func TestNewCaptureDaemonValidates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	config := DefaultConfig()
+	config.FlushInterval = ""
+	if _, err := NewCaptureDaemon(config); err == nil || !strings.Contains(err.Error(), "flush_interval") {
+		t.Errorf("Expected an empty flush interval to be rejected, got %v", err)
+	}
+}
\ No newline at end of file
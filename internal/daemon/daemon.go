@@ -2,9 +2,10 @@ package daemon
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,6 +14,8 @@ import (
 	"time"
 
 	"github.com/jasperwreed/ai-memory/internal/audit"
+	"github.com/jasperwreed/ai-memory/internal/scanner"
+	"github.com/jasperwreed/ai-memory/internal/storage"
 	"github.com/jasperwreed/ai-memory/internal/watcher"
 )
 
@@ -21,10 +24,33 @@ type CaptureConfig struct {
 	AuditDir       string   `json:"audit_dir"`
 	MaxShardSize   int64    `json:"max_shard_size"`
 	CompressShards bool     `json:"compress_shards"`
+	// WatchDirs adds directories to the trees of the registered scanners
 	WatchDirs      []string `json:"watch_dirs"`
 	BatchSize      int      `json:"batch_size"`
 	FlushInterval  string   `json:"flush_interval"`
 	EnableMetrics  bool     `json:"enable_metrics"`
+
+	// Ingest feeds captured lines into Database as they arrive, so new
+	// sessions are searchable without a scan
+	Ingest   bool   `json:"ingest"`
+	Database string `json:"database"`
+
+	// AuditRecipients encrypts shards to these public keys (see mem audit keygen)
+	AuditRecipients []string `json:"audit_recipients,omitempty"`
+
+	// LogFile receives the output of a daemon started in the background
+	LogFile string `json:"log_file"`
+
+	// MetricsAddr, if set, serves Prometheus metrics and a health check over
+	// HTTP, e.g. "127.0.0.1:9464"
+	MetricsAddr string `json:"metrics_addr,omitempty"`
+
+	// QueueMaxBytes bounds the captured lines waiting to be written. When it
+	// is reached, reading session files waits instead of dropping lines.
+	QueueMaxBytes int64 `json:"queue_max_bytes"`
+
+	// DisabledScanners lists tools whose session directories are not watched
+	DisabledScanners []string `json:"disabled_scanners,omitempty"`
 }
 
 // DefaultConfig returns default daemon configuration
@@ -34,15 +60,31 @@ func DefaultConfig() *CaptureConfig {
 		AuditDir:       filepath.Join(home, ".ai-memory", "audit"),
 		MaxShardSize:   100 * 1024 * 1024, // 100MB shards
 		CompressShards: true,
-		WatchDirs: []string{
-			"~/.claude-code/sessions",
-			"~/.aider",
-			"~/.cursor/sessions",
-		},
 		BatchSize:      100,
 		FlushInterval:  "5s",
 		EnableMetrics:  true,
+		Ingest:         true,
+		Database:       filepath.Join(home, ".ai-memory", "all_conversations.db"),
+		LogFile:        filepath.Join(home, ".ai-memory", "daemon.log"),
+		QueueMaxBytes:  64 * 1024 * 1024,
+	}
+}
+
+// Validate reports settings the daemon cannot run with
+func (c *CaptureConfig) Validate() error {
+	if interval, err := time.ParseDuration(c.FlushInterval); err != nil || interval <= 0 {
+		return fmt.Errorf("invalid flush_interval %q: use a duration such as \"5s\"", c.FlushInterval)
 	}
+	if c.BatchSize < 1 {
+		return fmt.Errorf("invalid batch_size %d: must be at least 1", c.BatchSize)
+	}
+	if c.MaxShardSize < 1 {
+		return fmt.Errorf("invalid max_shard_size %d: must be positive", c.MaxShardSize)
+	}
+	if c.AuditDir == "" {
+		return errors.New("audit_dir is required")
+	}
+	return nil
 }
 
 // CaptureDaemon manages the auto-capture process
@@ -50,25 +92,45 @@ type CaptureDaemon struct {
 	config       *CaptureConfig
 	watcher      *watcher.SessionWatcher
 	auditLogger  *audit.AuditLogger
-	eventQueue   chan watcher.Event
+	checkpoints  *watcher.CheckpointStore
+	ingester     *Ingester
+	openStore    func(path string) (*storage.SQLiteStore, error)
+	queue        *WAL
+	audited      uint64 // last queued line written to the audit log, so a retried batch is not written twice
 	metrics      *Metrics
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
+	flushCh      chan chan struct{} // asks the consumer to write everything queued
+	control      *http.Server
+	metricsServer *http.Server
+	metricsAddr  string
+	stopOnce     sync.Once
+	stopped      chan struct{} // closed once Stop has flushed everything
+	lock         *os.File
 	pidFile      string
-	statusFile   string
+	configMu     sync.Mutex // guards config.WatchDirs, which Reload changes
+	configPath   string
+	loadConfig   func() (*CaptureConfig, error)
 }
 
 // Metrics tracks daemon performance
 type Metrics struct {
 	EventsReceived  int64     `json:"events_received"`
 	EventsProcessed int64     `json:"events_processed"`
-	EventsDropped   int64     `json:"events_dropped"`
+	EventsDropped   int64     `json:"events_dropped"` // not queued while stopping; read again on the next start
 	BytesWritten    int64     `json:"bytes_written"`
 	ActiveSessions  int       `json:"active_sessions"`
+	LinesIngested   int64     `json:"lines_ingested"`
+	LinesFailed     int64     `json:"lines_failed"`
 	StartTime       time.Time `json:"start_time"`
 	LastEventTime   time.Time `json:"last_event_time"`
+	ToolEvents      map[string]int64 `json:"tool_events,omitempty"` // events received per tool
 	mu              sync.RWMutex
+
+	// Latencies, exported through the Prometheus endpoint only
+	ingestLatency  *Histogram // from reading a line to committing it to the database
+	ingestDuration *Histogram // of writing one session's lines
 }
 
 // NewCaptureDaemon creates a new capture daemon
@@ -76,23 +138,72 @@ func NewCaptureDaemon(config *CaptureConfig) (*CaptureDaemon, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var recipients []*audit.Recipient
+	for _, value := range config.AuditRecipients {
+		recipient, err := audit.ParseRecipient(value)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	// Only one daemon may write to an audit directory
+	if err := os.MkdirAll(config.AuditDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	lock, err := acquireLock(lockPath(config.AuditDir))
+	if err != nil {
+		return nil, err
+	}
 
 	// Create audit logger
-	auditLogger, err := audit.NewAuditLogger(
+	auditLogger, err := audit.NewEncryptedAuditLogger(
 		config.AuditDir,
 		config.MaxShardSize,
 		config.CompressShards,
+		recipients,
 	)
 	if err != nil {
+		lock.Close()
 		return nil, fmt.Errorf("failed to create audit logger: %w", err)
 	}
 
+	// Tailing resumes where the previous run stopped
+	checkpoints, err := watcher.OpenCheckpointStore(filepath.Join(config.AuditDir, "checkpoints.json"))
+	if err != nil {
+		auditLogger.Close()
+		lock.Close()
+		return nil, err
+	}
+
+	// Lines queued before the last shutdown are written first. They are on
+	// disk, so the watcher resumes after them.
+	queue, err := OpenWAL(filepath.Join(config.AuditDir, "queue"), config.QueueMaxBytes)
+	if err != nil {
+		auditLogger.Close()
+		lock.Close()
+		return nil, err
+	}
+	for _, entry := range queue.Peek(queue.Len()) {
+		checkpoints.Commit(entry.Event.Path, entry.Event.Offset, entry.Event.Offset+int64(len(entry.Event.RawLine)))
+	}
+	if err := checkpoints.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save checkpoints: %v\n", err)
+	}
+
 	// Create session watcher
 	sessionWatcher, err := watcher.NewSessionWatcher()
 	if err != nil {
+		queue.Close()
 		auditLogger.Close()
+		lock.Close()
 		return nil, fmt.Errorf("failed to create session watcher: %w", err)
 	}
+	sessionWatcher.SetCheckpoints(checkpoints)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -100,14 +211,21 @@ func NewCaptureDaemon(config *CaptureConfig) (*CaptureDaemon, error) {
 		config:      config,
 		watcher:     sessionWatcher,
 		auditLogger: auditLogger,
-		eventQueue:  make(chan watcher.Event, 1000),
+		checkpoints: checkpoints,
+		queue:       queue,
+		flushCh:     make(chan chan struct{}),
 		metrics: &Metrics{
-			StartTime: time.Now(),
+			StartTime:      time.Now(),
+			ToolEvents:     make(map[string]int64),
+			ingestLatency:  NewHistogram(0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60),
+			ingestDuration: NewHistogram(0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5),
 		},
+		openStore:  storage.NewSQLiteStore,
 		ctx:        ctx,
 		cancel:     cancel,
+		stopped:    make(chan struct{}),
+		lock:       lock,
 		pidFile:    filepath.Join(config.AuditDir, "daemon.pid"),
-		statusFile: filepath.Join(config.AuditDir, "daemon.status"),
 	}
 
 	// Register event handler
@@ -116,11 +234,29 @@ func NewCaptureDaemon(config *CaptureConfig) (*CaptureDaemon, error) {
 	return daemon, nil
 }
 
-// Start starts the capture daemon
+// SetStoreOpener replaces how the ingest database is opened, for example to
+// open it with an encryption key
+func (d *CaptureDaemon) SetStoreOpener(open func(path string) (*storage.SQLiteStore, error)) {
+	d.openStore = open
+}
+
+// Start starts the capture daemon. If it fails, the daemon is released and
+// cannot be started again.
 func (d *CaptureDaemon) Start() error {
-	// Check if already running
-	if d.isRunning() {
-		return fmt.Errorf("daemon already running")
+	if err := d.start(); err != nil {
+		d.release()
+		return err
+	}
+	return nil
+}
+
+func (d *CaptureDaemon) start() error {
+	if d.config.Ingest {
+		store, err := d.openStore(d.config.Database)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		d.ingester = NewIngester(store, d.metrics)
 	}
 
 	// Write PID file
@@ -128,10 +264,24 @@ func (d *CaptureDaemon) Start() error {
 		return fmt.Errorf("failed to write PID file: %w", err)
 	}
 
-	// Start watching directories
+	// Watch what mem scan would import, plus any configured directories
+	disabled := make(map[string]bool)
+	for _, tool := range d.config.DisabledScanners {
+		disabled[tool] = true
+	}
+	for _, s := range scanner.Registered() {
+		if disabled[s.Tool()] {
+			continue
+		}
+		for _, dir := range s.ScanPaths() {
+			if err := d.watcher.WatchTree(dir, s.SessionPattern(), s.Tool()); err != nil {
+				// Log error but continue with other directories
+				fmt.Fprintf(os.Stderr, "Failed to watch %s: %v\n", dir, err)
+			}
+		}
+	}
 	for _, dir := range d.config.WatchDirs {
-		if err := d.watcher.WatchDirectory(dir, "*.jsonl"); err != nil {
-			// Log error but continue with other directories
+		if err := d.watcher.WatchTree(dir, "*.jsonl", ""); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to watch %s: %v\n", dir, err)
 		}
 	}
@@ -141,11 +291,13 @@ func (d *CaptureDaemon) Start() error {
 		return fmt.Errorf("failed to start watcher: %w", err)
 	}
 
-	// Start processing workers
-	workerCount := 3
-	for i := 0; i < workerCount; i++ {
+	// A single consumer writes queued lines in the order they were read
+	d.wg.Add(1)
+	go d.consume()
+
+	if d.loadConfig != nil {
 		d.wg.Add(1)
-		go d.processEvents()
+		go d.watchConfig()
 	}
 
 	// Start metrics updater
@@ -154,39 +306,73 @@ func (d *CaptureDaemon) Start() error {
 		go d.updateMetrics()
 	}
 
-	// Start status writer
-	d.wg.Add(1)
-	go d.writeStatus()
+	if d.config.MetricsAddr != "" {
+		if err := d.serveMetrics(); err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return d.serveControl()
 }
 
-// Stop stops the capture daemon
+// Stop stops the capture daemon. It may be called more than once; later
+// calls wait for the first to finish.
 func (d *CaptureDaemon) Stop() error {
+	d.stopOnce.Do(d.stop)
+	return nil
+}
+
+func (d *CaptureDaemon) stop() {
 	// Signal shutdown
 	d.cancel()
+	d.closeMetrics()
 
-	// Stop watcher
+	// Release a watcher waiting on a full queue, then stop it
+	d.queue.Interrupt()
 	if err := d.watcher.Stop(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error stopping watcher: %v\n", err)
 	}
 
-	// Close event queue
-	close(d.eventQueue)
-
-	// Wait for workers to finish
+	// Wait for the consumer to write out the queue
 	d.wg.Wait()
+	if err := d.queue.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error closing queue: %v\n", err)
+	}
 
 	// Close audit logger
 	if err := d.auditLogger.Close(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error closing audit logger: %v\n", err)
 	}
 
+	// Close the database
+	if d.ingester != nil {
+		if err := d.ingester.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing database: %v\n", err)
+		}
+	}
+
 	// Remove PID file
 	os.Remove(d.pidFile)
-	os.Remove(d.statusFile)
 
-	return nil
+	// Acknowledge a pending shutdown request before the control socket closes
+	close(d.stopped)
+	d.closeControl()
+	d.lock.Close()
+}
+
+// release frees what a daemon that failed to start holds
+func (d *CaptureDaemon) release() {
+	d.cancel()
+	d.queue.Close()
+	d.watcher.Stop()
+	if d.ingester != nil {
+		d.ingester.Close()
+	}
+	d.auditLogger.Close()
+	os.Remove(d.pidFile)
+	d.closeMetrics()
+	d.closeControl()
+	d.lock.Close()
 }
 
 // Run runs the daemon until interrupted
@@ -201,7 +387,8 @@ func (d *CaptureDaemon) Run() error {
 
 	select {
 	case <-d.ctx.Done():
-		// Context cancelled
+		// Stopped through the control socket
+		fmt.Println("Shutdown requested, shutting down...")
 	case sig := <-sigCh:
 		fmt.Printf("Received signal %v, shutting down...\n", sig)
 	}
@@ -209,30 +396,45 @@ func (d *CaptureDaemon) Run() error {
 	return d.Stop()
 }
 
-// handleEvent handles events from the watcher
+// handleEvent queues captured lines from the watcher. It blocks while the
+// queue is full, which holds the watcher back instead of dropping lines.
 func (d *CaptureDaemon) handleEvent(event watcher.Event) error {
 	d.metrics.mu.Lock()
 	d.metrics.EventsReceived++
+	d.metrics.ToolEvents[event.Tool]++
 	d.metrics.LastEventTime = time.Now()
+	if len(event.RawLine) == 0 {
+		// Only raw source lines are preserved; lifecycle events carry no data
+		d.metrics.EventsProcessed++
+	}
 	d.metrics.mu.Unlock()
 
-	select {
-	case d.eventQueue <- event:
+	if len(event.RawLine) == 0 {
 		return nil
-	case <-time.After(100 * time.Millisecond):
-		// Queue full, drop event
-		d.metrics.mu.Lock()
-		d.metrics.EventsDropped++
-		d.metrics.mu.Unlock()
-		return fmt.Errorf("event queue full")
 	}
+
+	if err := d.queue.Append(event); err != nil {
+		if errors.Is(err, ErrQueueClosed) {
+			// Stopping; the line is read again on the next start
+			d.metrics.mu.Lock()
+			d.metrics.EventsDropped++
+			d.metrics.mu.Unlock()
+			return nil
+		}
+		return err
+	}
+
+	// Queued lines are not read again, even after a restart
+	d.checkpoints.Commit(event.Path, event.Offset, event.Offset+int64(len(event.RawLine)))
+	return nil
 }
 
-// processEvents processes events from the queue
-func (d *CaptureDaemon) processEvents() {
+// consume writes queued lines to the audit log and the database in the order
+// they were read. A batch is written when it is full, every flush interval,
+// and on Flush.
+func (d *CaptureDaemon) consume() {
 	defer d.wg.Done()
 
-	batch := make([]watcher.Event, 0, d.config.BatchSize)
 	flushInterval, _ := time.ParseDuration(d.config.FlushInterval)
 	ticker := time.NewTicker(flushInterval)
 	defer ticker.Stop()
@@ -240,70 +442,125 @@ func (d *CaptureDaemon) processEvents() {
 	for {
 		select {
 		case <-d.ctx.Done():
-			// Flush remaining batch
-			d.flushBatch(batch)
+			// Anything queued after this is written on the next start
+			d.writeQueued(1)
 			return
 
-		case event, ok := <-d.eventQueue:
-			if !ok {
-				// Queue closed, flush and exit
-				d.flushBatch(batch)
-				return
-			}
-
-			batch = append(batch, event)
-			if len(batch) >= d.config.BatchSize {
-				d.flushBatch(batch)
-				batch = batch[:0]
-			}
+		case <-d.queue.Notify():
+			d.writeQueued(d.config.BatchSize)
 
 		case <-ticker.C:
-			if len(batch) > 0 {
-				d.flushBatch(batch)
-				batch = batch[:0]
-			}
+			d.writeQueued(1)
+
+		case done := <-d.flushCh:
+			d.writeQueued(1)
+			close(done)
 		}
 	}
 }
 
-// flushBatch writes a batch of events to the audit log
-func (d *CaptureDaemon) flushBatch(batch []watcher.Event) {
-	for _, event := range batch {
-		// Create audit event
-		auditEvent := map[string]interface{}{
-			"type":      event.Type,
-			"tool":      event.Tool,
-			"session":   event.SessionID,
-			"path":      event.Path,
-			"timestamp": event.Timestamp.Unix(),
+// writeQueued writes batches while at least min lines are queued. It stops
+// at the first batch that fails; that batch stays queued and is retried.
+func (d *CaptureDaemon) writeQueued(min int) {
+	for d.queue.Len() >= min && d.queue.Len() > 0 {
+		if !d.writeBatch() {
+			return
 		}
+	}
+}
 
-		// Add raw line if available
-		if len(event.RawLine) > 0 {
-			auditEvent["raw"] = string(event.RawLine)
+// Flush writes every line queued so far to the audit log and the database
+// without waiting for the flush interval
+func (d *CaptureDaemon) Flush() {
+	done := make(chan struct{})
+	select {
+	case d.flushCh <- done:
+		<-done
+	case <-d.ctx.Done():
+	}
+}
 
-			// Also write raw line to preserve original
-			if err := d.auditLogger.WriteRawLine(event.RawLine); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to write raw line: %v\n", err)
-			}
-		}
+// Pause stops reading session files until Resume. Nothing written meanwhile
+// is lost; it is read on Resume.
+func (d *CaptureDaemon) Pause() {
+	d.watcher.Pause()
+}
 
-		// Add parsed data if available
-		if event.Data != nil {
-			auditEvent["data"] = event.Data
-		}
+// Resume reads session files again after Pause
+func (d *CaptureDaemon) Resume() {
+	d.watcher.Resume()
+}
+
+// Watch starts watching another directory tree for session files
+func (d *CaptureDaemon) Watch(dir string) error {
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("watch directory must be an absolute path: %s", dir)
+	}
+	return d.watcher.WatchTree(dir, "*.jsonl", "")
+}
+
+// Unwatch stops watching a directory tree
+func (d *CaptureDaemon) Unwatch(dir string) error {
+	return d.watcher.Unwatch(dir)
+}
+
+// Sessions returns the session files being tailed
+func (d *CaptureDaemon) Sessions() []watcher.SessionInfo {
+	return d.watcher.GetActiveSessions()
+}
 
-		// Write to audit log
-		if err := d.auditLogger.WriteEvent(auditEvent); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to write audit event: %v\n", err)
+// writeBatch writes the oldest queued lines to the audit log, then to the
+// database, and removes them from the queue. It reports whether the batch was
+// written; if not, it stays queued, and lines already in the audit log are
+// only written to the database when it is retried.
+func (d *CaptureDaemon) writeBatch() bool {
+	batch := d.queue.Peek(d.config.BatchSize)
+	if len(batch) == 0 {
+		return true
+	}
+
+	// Checkpoints only cover lines that are safely queued
+	if err := d.queue.Sync(d.checkpoints.Save); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save checkpoints: %v\n", err)
+	}
+
+	var bytes int64
+	for _, entry := range batch {
+		if entry.Seq <= d.audited {
 			continue
 		}
+		event := entry.Event
+		record := audit.NewRecord(event.Path, event.SessionID, event.Tool, event.Offset, event.RawLine)
+		if err := d.auditLogger.WriteRecord(record); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write audit record: %v\n", err)
+			return false
+		}
+		bytes += int64(len(event.RawLine))
+	}
+	if err := d.auditLogger.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to flush audit log: %v\n", err)
+		return false
+	}
+	d.audited = batch[len(batch)-1].Seq
 
-		d.metrics.mu.Lock()
-		d.metrics.EventsProcessed++
-		d.metrics.BytesWritten += int64(len(event.RawLine))
-		d.metrics.mu.Unlock()
+	if d.ingester != nil {
+		for _, entry := range batch {
+			d.ingester.Add(entry.Event)
+		}
+		if err := d.ingester.Flush(); err != nil {
+			return false
+		}
+	}
+
+	d.metrics.mu.Lock()
+	d.metrics.EventsProcessed += int64(len(batch))
+	d.metrics.BytesWritten += bytes
+	d.metrics.mu.Unlock()
+
+	if err := d.queue.Ack(batch[len(batch)-1].Seq); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to acknowledge queue: %v\n", err)
 	}
+	return true
 }
 
 // updateMetrics updates active session count
@@ -326,58 +583,54 @@ func (d *CaptureDaemon) updateMetrics() {
 	}
 }
 
-// writeStatus writes daemon status to file
-func (d *CaptureDaemon) writeStatus() {
-	defer d.wg.Done()
-
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-d.ctx.Done():
-			return
-		case <-ticker.C:
-			d.writeStatusFile()
-		}
-	}
-}
+// Status returns the daemon's current status
+func (d *CaptureDaemon) Status() *DaemonStatus {
+	d.configMu.Lock()
+	config := *d.config
+	d.configMu.Unlock()
 
-// writeStatusFile writes current status to file
-func (d *CaptureDaemon) writeStatusFile() {
-	d.metrics.mu.RLock()
-	status := struct {
-		PID           int       `json:"pid"`
-		Status        string    `json:"status"`
-		Config        *CaptureConfig `json:"config"`
-		Metrics       *Metrics  `json:"metrics"`
-		ActiveShards  []audit.ShardInfo `json:"active_shards"`
-		UpdatedAt     time.Time `json:"updated_at"`
-	}{
+	status := &DaemonStatus{
 		PID:       os.Getpid(),
 		Status:    "running",
-		Config:    d.config,
-		Metrics:   d.metrics,
+		Config:    &config,
+		Metrics:   d.Metrics(),
+		Watching:  d.watcher.WatchedRoots(),
 		UpdatedAt: time.Now(),
 	}
-	d.metrics.mu.RUnlock()
+	if d.watcher.Paused() {
+		status.Status = "paused"
+	}
 
 	// Get active shards
 	if shards, err := d.auditLogger.GetActiveShards(); err == nil {
 		status.ActiveShards = shards
 	}
+	return status
+}
 
-	data, err := json.MarshalIndent(status, "", "  ")
-	if err != nil {
-		return
-	}
+// Metrics returns a snapshot of the daemon's metrics
+func (d *CaptureDaemon) Metrics() *Metrics {
+	activeSessions := len(d.watcher.GetActiveSessions())
 
-	// Write atomically
-	tmpFile := d.statusFile + ".tmp"
-	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
-		return
+	d.metrics.mu.RLock()
+	defer d.metrics.mu.RUnlock()
+
+	toolEvents := make(map[string]int64, len(d.metrics.ToolEvents))
+	for tool, n := range d.metrics.ToolEvents {
+		toolEvents[tool] = n
+	}
+	return &Metrics{
+		EventsReceived:  d.metrics.EventsReceived,
+		EventsProcessed: d.metrics.EventsProcessed,
+		EventsDropped:   d.metrics.EventsDropped,
+		BytesWritten:    d.metrics.BytesWritten,
+		ActiveSessions:  activeSessions,
+		LinesIngested:   d.metrics.LinesIngested,
+		LinesFailed:     d.metrics.LinesFailed,
+		StartTime:       d.metrics.StartTime,
+		LastEventTime:   d.metrics.LastEventTime,
+		ToolEvents:      toolEvents,
 	}
-	os.Rename(tmpFile, d.statusFile)
 }
 
 // writePIDFile writes the process ID to file
@@ -386,44 +639,21 @@ func (d *CaptureDaemon) writePIDFile() error {
 	return os.WriteFile(d.pidFile, []byte(fmt.Sprintf("%d", pid)), 0644)
 }
 
-// isRunning checks if daemon is already running
-func (d *CaptureDaemon) isRunning() bool {
-	data, err := os.ReadFile(d.pidFile)
-	if err != nil {
-		return false
-	}
-
-	var pid int
-	fmt.Sscanf(string(data), "%d", &pid)
-
-	// Check if process exists
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-
-	// Send signal 0 to check if process is alive
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
-}
-
-// GetStatus reads daemon status from file
+// GetStatus asks the daemon writing to auditDir for its status. A PID file
+// left behind by a daemon that no longer holds the instance lock reads as
+// stopped.
 func GetStatus(auditDir string) (*DaemonStatus, error) {
-	statusFile := filepath.Join(auditDir, "daemon.status")
-	data, err := os.ReadFile(statusFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return &DaemonStatus{Status: "stopped"}, nil
-		}
-		return nil, err
+	pid, running := RunningPID(auditDir)
+	if !running {
+		return &DaemonStatus{Status: "stopped"}, nil
 	}
 
-	var status DaemonStatus
-	if err := json.Unmarshal(data, &status); err != nil {
-		return nil, err
+	status, err := NewClient(auditDir).Status()
+	if errors.Is(err, ErrNotRunning) {
+		// Locked but not serving the control socket yet
+		return &DaemonStatus{PID: pid, Status: "starting", UpdatedAt: time.Now()}, nil
 	}
-
-	return &status, nil
+	return status, err
 }
 
 // DaemonStatus represents the daemon status
@@ -432,6 +662,8 @@ type DaemonStatus struct {
 	Status    string             `json:"status"`
 	Config    *CaptureConfig     `json:"config"`
 	Metrics   *Metrics           `json:"metrics"`
+	Watching  []string           `json:"watching"`
+	ActiveShards []audit.ShardInfo `json:"active_shards"`
 	UpdatedAt time.Time          `json:"updated_at"`
 }
 
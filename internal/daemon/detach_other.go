@@ -0,0 +1,13 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"errors"
+	"os"
+)
+
+// Detach is not supported on this platform
+func Detach(args []string, logPath string) (*os.Process, error) {
+	return nil, errors.New("background mode is not supported on this platform")
+}
\ No newline at end of file
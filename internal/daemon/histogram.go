@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"sort"
+	"sync"
+)
+
+// Histogram counts observations into cumulative buckets, as Prometheus
+// histograms do. A nil Histogram ignores observations.
+type Histogram struct {
+	bounds []float64 // upper bounds, ascending
+	counts []uint64  // per bucket, with a final +Inf bucket
+	sum    float64
+	count  uint64
+	mu     sync.Mutex
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds
+func NewHistogram(bounds ...float64) *Histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		bounds: sorted,
+		counts: make([]uint64, len(sorted)+1),
+	}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := sort.SearchFloat64s(h.bounds, v)
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+// HistogramSnapshot is a histogram's state at one point in time
+type HistogramSnapshot struct {
+	Bounds     []float64 // upper bounds, without +Inf
+	Cumulative []uint64  // observations <= each bound, then the total
+	Sum        float64
+	Count      uint64
+}
+
+// Snapshot returns the histogram's buckets as cumulative counts
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snap := HistogramSnapshot{
+		Bounds:     append([]float64(nil), h.bounds...),
+		Cumulative: make([]uint64, len(h.counts)),
+		Sum:        h.sum,
+		Count:      h.count,
+	}
+	var total uint64
+	for i, n := range h.counts {
+		total += n
+		snap.Cumulative[i] = total
+	}
+	return snap
+}
\ No newline at end of file
@@ -0,0 +1,301 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/capture"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+// Ingester turns captured session lines into conversations in the database.
+// Lines are buffered per source file and written in one transaction per
+// session on each Flush.
+type Ingester struct {
+	store   *storage.SQLiteStore
+	metrics *Metrics
+
+	sources map[string]*ingestSource
+	mu      sync.Mutex // guards sources
+	flushMu sync.Mutex // serializes flushes
+}
+
+// ingestSource tracks one session file being ingested
+type ingestSource struct {
+	path      string
+	sessionID string
+	cwd       string
+	createdAt time.Time
+
+	// reset is set when the file is read again from the start, so the
+	// conversation is rebuilt instead of appended to
+	reset bool
+	lines map[int64]pendingLine
+}
+
+// pendingLine is a captured line waiting to be written
+type pendingLine struct {
+	data   []byte
+	readAt time.Time
+}
+
+// NewIngester creates an ingester writing to store
+func NewIngester(store *storage.SQLiteStore, metrics *Metrics) *Ingester {
+	return &Ingester{
+		store:   store,
+		metrics: metrics,
+		sources: make(map[string]*ingestSource),
+	}
+}
+
+// Add buffers a captured line. Only Claude Code session lines are ingested.
+func (i *Ingester) Add(event watcher.Event) {
+	if event.Tool != "claude-code" || len(strings.TrimSpace(string(event.RawLine))) == 0 {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	source, ok := i.sources[event.Path]
+	if !ok {
+		source = &ingestSource{path: event.Path, lines: make(map[int64]pendingLine)}
+		i.sources[event.Path] = source
+	}
+	if event.Offset == 0 {
+		source.reset = true
+	}
+	if source.sessionID == "" {
+		source.sessionID = event.SessionID
+	}
+	source.lines[event.Offset] = pendingLine{
+		data:   append([]byte(nil), event.RawLine...),
+		readAt: event.Timestamp,
+	}
+}
+
+// Flush writes buffered lines to the database. Lines that could not be
+// written stay buffered for the next flush, and the first error is returned.
+func (i *Ingester) Flush() error {
+	i.flushMu.Lock()
+	defer i.flushMu.Unlock()
+
+	type batch struct {
+		source *ingestSource
+		lines  map[int64]pendingLine
+		reset  bool
+	}
+
+	i.mu.Lock()
+	var batches []batch
+	for _, source := range i.sources {
+		if len(source.lines) == 0 {
+			continue
+		}
+		batches = append(batches, batch{source: source, lines: source.lines, reset: source.reset})
+		source.lines = make(map[int64]pendingLine)
+		source.reset = false
+	}
+	i.mu.Unlock()
+
+	var firstErr error
+	for _, b := range batches {
+		started := time.Now()
+		ingested, failed, reset, err := i.ingest(b.source, b.lines, b.reset)
+		i.metrics.ingestDuration.Observe(time.Since(started).Seconds())
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to ingest %s: %v\n", b.source.path, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			// Lines read again since are newer copies of the same lines
+			i.mu.Lock()
+			for offset, line := range b.lines {
+				if _, ok := b.source.lines[offset]; !ok {
+					b.source.lines[offset] = line
+				}
+			}
+			b.source.reset = b.source.reset || b.reset
+			i.mu.Unlock()
+			continue
+		}
+
+		// A rebuild that found no messages yet carries over to the next flush
+		if reset {
+			i.mu.Lock()
+			b.source.reset = true
+			i.mu.Unlock()
+		}
+
+		i.metrics.mu.Lock()
+		i.metrics.LinesIngested += int64(ingested)
+		i.metrics.LinesFailed += int64(failed)
+		i.metrics.mu.Unlock()
+	}
+	return firstErr
+}
+
+// ingest parses a source's lines in file order and writes their messages.
+// It reports how many lines were ingested and failed, and whether a pending
+// rebuild still has to happen.
+//
+// Lines can arrive twice, when the daemon stops after writing a batch but
+// before removing it from its queue. The conversation records how far into
+// its file it has been written, and lines before that are skipped.
+func (i *Ingester) ingest(source *ingestSource, lines map[int64]pendingLine, reset bool) (ingested, failed int, pending bool, err error) {
+	offsets := sortedOffsets(lines)
+	last := offsets[len(offsets)-1]
+	end := last + int64(len(lines[last].data))
+
+	messages, messageOffsets, ingested, failed := parseLines(source, lines)
+	if len(messages) == 0 {
+		return ingested, failed, reset, nil
+	}
+
+	sessionID := source.sessionID
+	if sessionID == "" {
+		// Claude Code names session files after the session
+		sessionID = strings.TrimSuffix(filepath.Base(source.path), filepath.Ext(source.path))
+	}
+
+	if !reset {
+		ingestedTo, found, err := i.store.IngestedTo(sessionID)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if found {
+			skip := 0
+			for skip < len(messageOffsets) && messageOffsets[skip] < ingestedTo {
+				skip++
+			}
+			if skip < len(messages) {
+				if _, err := i.store.AppendMessages(sessionID, messages[skip:], end); err != nil {
+					return 0, 0, false, err
+				}
+			}
+			i.observeLatency(lines)
+			return ingested, failed, false, nil
+		}
+
+		// The conversation is new but its file was read from part way through,
+		// resuming from a checkpoint, so it is built from the whole file
+		if offsets[0] > 0 {
+			earlier, err := readLinesBefore(source.path, offsets[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Could not read the start of %s, its conversation is partial: %v\n", source.path, err)
+			}
+			if len(earlier) > 0 {
+				for offset, line := range lines {
+					earlier[offset] = line
+				}
+				source.cwd, source.createdAt = "", time.Time{}
+				messages, _, _, _ = parseLines(source, earlier)
+			}
+		}
+	}
+
+	conv := capture.NewClaudeCodeParserWithPath(source.path).NewConversation(sessionID, source.cwd, source.createdAt, messages)
+	conv.IngestedTo = end
+	if _, err := i.store.ReplaceConversationBySessionID(conv); err != nil {
+		return 0, 0, false, err
+	}
+	i.observeLatency(lines)
+	return ingested, failed, false, nil
+}
+
+// parseLines parses a source's lines in file order. It returns their messages
+// with the offsets of the lines they came from, and how many lines were parsed
+// and failed.
+func parseLines(source *ingestSource, lines map[int64]pendingLine) (messages []models.Message, offsets []int64, parsed, failed int) {
+	parser := capture.NewClaudeCodeParserWithPath(source.path)
+	for _, offset := range sortedOffsets(lines) {
+		line, err := parser.ParseLine(lines[offset].data)
+		if err != nil {
+			failed++
+			continue
+		}
+		parsed++
+
+		if source.sessionID == "" {
+			source.sessionID = line.SessionID
+		}
+		if source.cwd == "" {
+			source.cwd = line.CWD
+		}
+		if source.createdAt.IsZero() {
+			source.createdAt = line.Timestamp
+		}
+		if line.Message != nil {
+			messages = append(messages, *line.Message)
+			offsets = append(offsets, offset)
+		}
+	}
+	return messages, offsets, parsed, failed
+}
+
+// sortedOffsets returns the offsets of lines in file order
+func sortedOffsets(lines map[int64]pendingLine) []int64 {
+	offsets := make([]int64, 0, len(lines))
+	for offset := range lines {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(a, b int) bool { return offsets[a] < offsets[b] })
+	return offsets
+}
+
+// readLinesBefore reads the complete lines of a session file that start
+// before offset
+func readLinesBefore(path string, offset int64) (map[int64]pendingLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := make([]byte, offset)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+	lines := make(map[int64]pendingLine)
+	for start := 0; start < len(data); {
+		n := bytes.IndexByte(data[start:], '\n')
+		if n < 0 {
+			break
+		}
+		if len(bytes.TrimSpace(data[start:start+n])) > 0 {
+			lines[int64(start)] = pendingLine{data: data[start : start+n+1]}
+		}
+		start += n + 1
+	}
+	return lines, nil
+}
+
+// observeLatency records how long written lines took from being read to
+// reaching the database
+func (i *Ingester) observeLatency(lines map[int64]pendingLine) {
+	now := time.Now()
+	for _, line := range lines {
+		if !line.readAt.IsZero() {
+			i.metrics.ingestLatency.Observe(now.Sub(line.readAt).Seconds())
+		}
+	}
+}
+
+// Close flushes buffered lines and closes the database
+func (i *Ingester) Close() error {
+	flushErr := i.Flush()
+	if err := i.store.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
\ No newline at end of file
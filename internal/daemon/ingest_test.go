@@ -0,0 +1,231 @@
+package daemon
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jasperwreed/ai-memory/internal/storage"
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+// sessionLines returns Claude Code lines alternating user and assistant text
+func sessionLines(texts ...string) [][]byte {
+	var lines [][]byte
+	for n, text := range texts {
+		if n%2 == 0 {
+			lines = append(lines, []byte(fmt.Sprintf(
+				`{"type":"user","sessionId":"abc","cwd":"/src/app","timestamp":"2024-01-01T10:00:0%dZ","message":{"role":"user","content":%q}}`+"\n", n, text)))
+		} else {
+			lines = append(lines, []byte(fmt.Sprintf(
+				`{"type":"assistant","sessionId":"abc","timestamp":"2024-01-01T10:00:0%dZ","message":{"role":"assistant","content":[{"type":"text","text":%q}]}}`+"\n", n, text)))
+		}
+	}
+	return lines
+}
+
+// feed adds lines to the ingester as the watcher would, starting at offset
+func feed(ing *Ingester, path string, offset int64, lines [][]byte) int64 {
+	for _, line := range lines {
+		ing.Add(watcher.Event{
+			Type:    "message",
+			Tool:    "claude-code",
+			Path:    path,
+			RawLine: line,
+			Offset:  offset,
+		})
+		offset += int64(len(line))
+	}
+	return offset
+}
+
+func TestIngesterAppendsIncrementally(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "ingest.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	metrics := &Metrics{}
+	ing := NewIngester(store, metrics)
+	defer ing.Close()
+
+	path := "/home/u/.claude/projects/-src-app/abc.jsonl"
+	offset := feed(ing, path, 0, sessionLines("how do I deploy", "use the pipeline"))
+	ing.Flush()
+
+	conv, err := store.GetConversationBySessionID("abc")
+	if err != nil || conv == nil {
+		t.Fatalf("Conversation not created: %v", err)
+	}
+	if conv.Title != "how do I deploy" || conv.Project != "app" {
+		t.Errorf("Unexpected conversation: %q in %q", conv.Title, conv.Project)
+	}
+
+	offset = feed(ing, path, offset, sessionLines("and rollback?", "revert the tag"))
+	feed(ing, path, offset, [][]byte{[]byte("{not json\n")})
+	ing.Flush()
+
+	full, err := store.GetConversation(conv.ID)
+	if err != nil {
+		t.Fatalf("Failed to get conversation: %v", err)
+	}
+	if len(full.Messages) != 4 {
+		t.Errorf("Expected 4 messages after append, got %d", len(full.Messages))
+	}
+
+	results, err := store.Search("rollback", 10)
+	if err != nil || len(results) != 1 {
+		t.Errorf("Appended message not searchable: %d results (%v)", len(results), err)
+	}
+
+	if metrics.LinesIngested != 4 || metrics.LinesFailed != 1 {
+		t.Errorf("Expected 4 ingested and 1 failed, got %d and %d", metrics.LinesIngested, metrics.LinesFailed)
+	}
+
+	// Reading the file again from the start rebuilds instead of duplicating
+	feed(ing, path, 0, sessionLines("how do I deploy", "use the pipeline", "and rollback?", "revert the tag"))
+	ing.Flush()
+
+	full, _ = store.GetConversation(conv.ID)
+	if len(full.Messages) != 4 {
+		t.Errorf("Expected 4 messages after re-read, got %d", len(full.Messages))
+	}
+}
+
+func TestIngesterSkipsOtherTools(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "ingest.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	metrics := &Metrics{}
+	ing := NewIngester(store, metrics)
+	defer ing.Close()
+
+	ing.Add(watcher.Event{Tool: "aider", Path: "/x/.aider/chat.jsonl", RawLine: sessionLines("hi")[0]})
+	ing.Flush()
+
+	stats, _ := store.GetStats()
+	if stats.TotalConversations != 0 || metrics.LinesIngested != 0 {
+		t.Errorf("Expected nothing ingested, got %d conversations", stats.TotalConversations)
+	}
+}
+
+// messageCount returns how many messages the conversation of session abc has
+func messageCount(t *testing.T, store *storage.SQLiteStore) int {
+	t.Helper()
+	conv, err := store.GetConversationBySessionID("abc")
+	if err != nil || conv == nil {
+		t.Fatalf("Conversation not found: %v", err)
+	}
+	full, err := store.GetConversation(conv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(full.Messages)
+}
+
+func TestIngesterReplayAfterCrash(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "ingest.db")
+	store, err := storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ing := NewIngester(store, &Metrics{})
+
+	path := "/home/u/.claude/projects/-src-app/abc.jsonl"
+	offset := feed(ing, path, 0, sessionLines("how do I deploy", "use the pipeline"))
+	ing.Flush()
+	second := sessionLines("and rollback?", "revert the tag")
+	feed(ing, path, offset, second)
+	ing.Flush()
+	ing.Close()
+
+	// The daemon stopped before removing the second batch from its queue, so
+	// the restarted daemon ingests it again
+	store, err = storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	ing = NewIngester(store, &Metrics{})
+	defer ing.Close()
+	offset = feed(ing, path, offset, second)
+	if err := ing.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := messageCount(t, store); n != 4 {
+		t.Errorf("Expected 4 messages after the replay, got %d", n)
+	}
+
+	// Lines after the replayed ones are still appended
+	feed(ing, path, offset, sessionLines("thanks"))
+	ing.Flush()
+	if n := messageCount(t, store); n != 5 {
+		t.Errorf("Expected 5 messages after the replay, got %d", n)
+	}
+}
+
+func TestIngesterKeepsLinesThatFail(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "ingest.db")
+	store, err := storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ing := NewIngester(store, &Metrics{})
+	defer ing.Close()
+
+	path := "/home/u/.claude/projects/-src-app/abc.jsonl"
+	offset := feed(ing, path, 0, sessionLines("how do I deploy", "use the pipeline"))
+	ing.Flush()
+
+	// Another connection makes writing messages fail for a while
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TRIGGER fail BEFORE INSERT ON messages BEGIN SELECT RAISE(ABORT, 'disk full'); END`); err != nil {
+		t.Fatal(err)
+	}
+	feed(ing, path, offset, sessionLines("and rollback?", "revert the tag"))
+	if err := ing.Flush(); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+
+	if _, err := db.Exec(`DROP TRIGGER fail`); err != nil {
+		t.Fatal(err)
+	}
+	if err := ing.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := messageCount(t, store); n != 4 {
+		t.Errorf("Expected 4 messages once the write succeeds, got %d", n)
+	}
+}
+
+func TestIngesterReadsResumedSessionFromStart(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "ingest.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ing := NewIngester(store, &Metrics{})
+	defer ing.Close()
+
+	lines := sessionLines("how do I deploy", "use the pipeline", "and rollback?", "revert the tag")
+	path := filepath.Join(t.TempDir(), "abc.jsonl")
+	if err := os.WriteFile(path, bytes.Join(lines, nil), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resuming from a checkpoint, only the lines after it are read
+	start := int64(len(lines[0]) + len(lines[1]))
+	feed(ing, path, start, lines[2:])
+	if err := ing.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	conv, _ := store.GetConversationBySessionID("abc")
+	if n := messageCount(t, store); n != 4 || conv.Title != "how do I deploy" {
+		t.Errorf("Expected the whole session, got %d messages titled %q", n, conv.Title)
+	}
+}
\ No newline at end of file
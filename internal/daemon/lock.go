@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrAlreadyRunning is returned when another daemon holds the instance lock
+var ErrAlreadyRunning = errors.New("daemon already running")
+
+// lockPath returns the instance lock file of an audit directory
+func lockPath(auditDir string) string {
+	return filepath.Join(auditDir, "daemon.lock")
+}
+
+// RunningPID returns the PID of the daemon writing to auditDir. The PID file
+// is only trusted while the instance lock is held, so a stale file whose PID
+// was reused by another process does not count.
+func RunningPID(auditDir string) (int, bool) {
+	if !lockHeld(lockPath(auditDir)) {
+		return 0, false
+	}
+
+	var pid int
+	if data, err := os.ReadFile(filepath.Join(auditDir, "daemon.pid")); err == nil {
+		fmt.Sscanf(strings.TrimSpace(string(data)), "%d", &pid)
+	}
+	return pid, true
+}
\ No newline at end of file
@@ -0,0 +1,45 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// acquireLock creates the lock file. Without flock a second instance is only
+// detected through the PID it records.
+func acquireLock(path string) (*os.File, error) {
+	if lockHeld(path) {
+		return nil, ErrAlreadyRunning
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	fmt.Fprintf(f, "%d", os.Getpid())
+	return f, nil
+}
+
+// lockHeld reports whether the process recorded in the lock file is alive
+func lockHeld(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var pid int
+	fmt.Sscanf(string(data), "%d", &pid)
+	if pid <= 0 {
+		return false
+	}
+	if pid == os.Getpid() {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
\ No newline at end of file
@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstanceLock(t *testing.T) {
+	auditDir := t.TempDir()
+
+	lock, err := acquireLock(lockPath(auditDir))
+	if err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+	os.WriteFile(filepath.Join(auditDir, "daemon.pid"), []byte("4242"), 0644)
+
+	if _, err := acquireLock(lockPath(auditDir)); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("Expected ErrAlreadyRunning for a second lock, got %v", err)
+	}
+	if pid, running := RunningPID(auditDir); !running || pid != 4242 {
+		t.Errorf("Expected running PID 4242, got %d (running %v)", pid, running)
+	}
+
+	// A PID file without a held lock is stale, whatever process has that PID now
+	lock.Close()
+	os.WriteFile(filepath.Join(auditDir, "daemon.pid"), []byte("1"), 0644)
+	if _, running := RunningPID(auditDir); running {
+		t.Errorf("Expected stale PID file to read as stopped")
+	}
+	status, err := GetStatus(auditDir)
+	if err != nil || status.Status != "stopped" {
+		t.Errorf("Expected stopped status, got %+v (%v)", status, err)
+	}
+}
+
+func TestSystemdUnitQuoting(t *testing.T) {
+	unit := SystemdUnit("/opt/my tools/mem", []string{"daemon", "start", "--db-key-cmd", `pass show "db" $HOME 100%`})
+
+	want := `ExecStart="/opt/my tools/mem" daemon start --db-key-cmd "pass show \"db\" $$HOME 100%%"`
+	if !strings.Contains(unit, want+"\n") {
+		t.Errorf("Expected %q in unit:\n%s", want, unit)
+	}
+}
\ No newline at end of file
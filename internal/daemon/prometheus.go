@@ -0,0 +1,275 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// serveMetrics starts the HTTP listener serving /metrics and /healthz on
+// config.MetricsAddr
+func (d *CaptureDaemon) serveMetrics() error {
+	listener, err := net.Listen("tcp", d.config.MetricsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics address: %w", err)
+	}
+	if host, _, err := net.SplitHostPort(d.config.MetricsAddr); err == nil && !isLoopback(host) {
+		fmt.Fprintf(os.Stderr, "Warning: metrics are served beyond localhost on %s\n", d.config.MetricsAddr)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+		if openMetrics {
+			w.Header().Set("Content-Type", openMetricsContentType)
+		} else {
+			w.Header().Set("Content-Type", prometheusContentType)
+		}
+		d.writeMetrics(w, openMetrics)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if d.ctx.Err() != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "stopping")
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	d.metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	d.metricsAddr = listener.Addr().String()
+	go d.metricsServer.Serve(listener)
+	return nil
+}
+
+// MetricsAddr returns the address metrics are served on, or "" if they are not
+func (d *CaptureDaemon) MetricsAddr() string {
+	return d.metricsAddr
+}
+
+// closeMetrics stops the metrics listener
+func (d *CaptureDaemon) closeMetrics() {
+	if d.metricsServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	d.metricsServer.Shutdown(ctx)
+}
+
+// writeMetrics writes the daemon's metrics in the Prometheus text format, or
+// in OpenMetrics if asked to
+func (d *CaptureDaemon) writeMetrics(w io.Writer, openMetrics bool) {
+	m := &metricsWriter{w: w, openMetrics: openMetrics}
+	metrics := d.Metrics()
+
+	m.gauge("mem_daemon_start_time_seconds", "Unix time the daemon started.",
+		sample{value: float64(metrics.StartTime.Unix())})
+	m.gauge("mem_daemon_paused", "Whether reading session files is paused.",
+		sample{value: boolValue(d.watcher.Paused())})
+
+	m.counter("mem_daemon_events_received", "Events received from the session watcher.",
+		sample{value: float64(metrics.EventsReceived)})
+	m.counter("mem_daemon_events_processed", "Events written to the audit log.",
+		sample{value: float64(metrics.EventsProcessed)})
+	m.counter("mem_daemon_events_dropped", "Lines not queued because the daemon was stopping.",
+		sample{value: float64(metrics.EventsDropped)})
+	m.counter("mem_daemon_bytes_written", "Bytes of captured lines processed.",
+		sample{value: float64(metrics.BytesWritten)})
+
+	var toolSamples []sample
+	for _, tool := range sortedKeys(metrics.ToolEvents) {
+		toolSamples = append(toolSamples, sample{labels: []string{"tool", tool}, value: float64(metrics.ToolEvents[tool])})
+	}
+	m.counter("mem_daemon_tool_events", "Events received per tool.", toolSamples...)
+
+	if !metrics.LastEventTime.IsZero() {
+		m.gauge("mem_daemon_last_event_timestamp_seconds", "Unix time of the last event received.",
+			sample{value: float64(metrics.LastEventTime.UnixNano()) / 1e9})
+	}
+
+	m.gauge("mem_daemon_queue_depth", "Lines waiting in the queue.",
+		sample{value: float64(d.queue.Len())})
+	m.gauge("mem_daemon_queue_bytes", "Size of the lines waiting in the queue.",
+		sample{value: float64(d.queue.Bytes())})
+	m.gauge("mem_daemon_queue_capacity_bytes", "Queued bytes at which reading session files waits.",
+		sample{value: float64(d.config.QueueMaxBytes)})
+	m.gauge("mem_daemon_active_sessions", "Session files being tailed.",
+		sample{value: float64(metrics.ActiveSessions)})
+	m.gauge("mem_daemon_watched_roots", "Directory trees being watched.",
+		sample{value: float64(len(d.watcher.WatchedRoots()))})
+
+	errorCounts := d.watcher.ErrorCounts()
+	var errorSamples []sample
+	for _, kind := range sortedKeys(errorCounts) {
+		errorSamples = append(errorSamples, sample{labels: []string{"kind", kind}, value: float64(errorCounts[kind])})
+	}
+	m.counter("mem_daemon_watcher_errors", "Errors hit by the session watcher, by kind.", errorSamples...)
+
+	stats := d.auditLogger.Stats()
+	m.counter("mem_daemon_audit_bytes_written", "Bytes written to audit shards before compression.",
+		sample{value: float64(stats.BytesWritten)})
+	m.counter("mem_daemon_audit_shard_rotations", "Audit shards closed because they were full.",
+		sample{value: float64(stats.Rotations)})
+	if shards, err := d.auditLogger.GetActiveShards(); err == nil {
+		var size int64
+		for _, shard := range shards {
+			size += shard.Size
+		}
+		m.gauge("mem_daemon_audit_shards", "Audit shards on disk.",
+			sample{value: float64(len(shards))})
+		m.gauge("mem_daemon_audit_shard_bytes", "Size of the audit shards on disk.",
+			sample{value: float64(size)})
+	}
+
+	if d.ingester != nil {
+		m.counter("mem_daemon_lines_ingested", "Lines written to the database.",
+			sample{value: float64(metrics.LinesIngested)})
+		m.counter("mem_daemon_lines_failed", "Lines that could not be ingested.",
+			sample{value: float64(metrics.LinesFailed)})
+		m.histogram("mem_daemon_ingest_latency_seconds", "Time from reading a line to committing it to the database.",
+			d.metrics.ingestLatency)
+		m.histogram("mem_daemon_ingest_write_duration_seconds", "Time to write one session's lines to the database.",
+			d.metrics.ingestDuration)
+	}
+
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
+
+// sample is one value of a metric, with label name/value pairs
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// metricsWriter writes metric families in the Prometheus text format or OpenMetrics
+type metricsWriter struct {
+	w           io.Writer
+	openMetrics bool
+}
+
+// counter writes a counter family. name is given without the _total suffix.
+func (m *metricsWriter) counter(name, help string, samples ...sample) {
+	family := name + "_total"
+	if m.openMetrics {
+		// OpenMetrics names the family without the suffix its samples carry
+		family = name
+	}
+	m.header(family, help, "counter")
+	for _, s := range samples {
+		m.sample(name+"_total", s.labels, s.value)
+	}
+}
+
+// gauge writes a gauge family
+func (m *metricsWriter) gauge(name, help string, samples ...sample) {
+	m.header(name, help, "gauge")
+	for _, s := range samples {
+		m.sample(name, s.labels, s.value)
+	}
+}
+
+// histogram writes a histogram family
+func (m *metricsWriter) histogram(name, help string, h *Histogram) {
+	if h == nil {
+		return
+	}
+	snap := h.Snapshot()
+
+	m.header(name, help, "histogram")
+	for i, bound := range snap.Bounds {
+		m.sample(name+"_bucket", []string{"le", formatValue(bound)}, float64(snap.Cumulative[i]))
+	}
+	m.sample(name+"_bucket", []string{"le", "+Inf"}, float64(snap.Count))
+	m.sample(name+"_sum", nil, snap.Sum)
+	m.sample(name+"_count", nil, float64(snap.Count))
+}
+
+func (m *metricsWriter) header(name, help, kind string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(m.w, "# TYPE %s %s\n", name, kind)
+}
+
+func (m *metricsWriter) sample(name string, labels []string, value float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, `%s="%s"`, labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(m.w, "%s %s\n", b.String(), formatValue(value))
+}
+
+// formatValue formats a sample value as Prometheus expects
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		// Counts and Unix times read better without an exponent
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// isLoopback reports whether host only accepts local connections
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// sortedKeys returns the keys of m in order, for stable output
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
\ No newline at end of file
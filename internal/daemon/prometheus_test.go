@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	h := NewHistogram(1, 0.1, 10)
+	for _, v := range []float64{0.05, 0.1, 0.5, 3, 100} {
+		h.Observe(v)
+	}
+
+	snap := h.Snapshot()
+	want := []uint64{2, 3, 4, 5} // <=0.1, <=1, <=10, +Inf
+	for i, n := range want {
+		if snap.Cumulative[i] != n {
+			t.Errorf("Bucket %d: expected %d, got %d", i, n, snap.Cumulative[i])
+		}
+	}
+	if snap.Count != 5 || snap.Sum != 103.65 {
+		t.Errorf("Expected count 5 and sum 103.65, got %d and %v", snap.Count, snap.Sum)
+	}
+
+	// A nil histogram ignores observations
+	var none *Histogram
+	none.Observe(1)
+}
+
+func TestWriteMetrics(t *testing.T) {
+	d, _ := startTestDaemon(t)
+	d.metrics.mu.Lock()
+	d.metrics.EventsReceived = 7
+	d.metrics.ToolEvents["claude-code"] = 7
+	d.metrics.mu.Unlock()
+
+	var prom bytes.Buffer
+	d.writeMetrics(&prom, false)
+	for _, want := range []string{
+		"# TYPE mem_daemon_events_received_total counter\nmem_daemon_events_received_total 7\n",
+		`mem_daemon_tool_events_total{tool="claude-code"} 7` + "\n",
+		"# TYPE mem_daemon_queue_depth gauge\n",
+		"mem_daemon_queue_capacity_bytes 67108864\n",
+		"# TYPE mem_daemon_audit_shard_rotations_total counter\n",
+	} {
+		if !strings.Contains(prom.String(), want) {
+			t.Errorf("Prometheus output missing %q:\n%s", want, prom.String())
+		}
+	}
+	if strings.Contains(prom.String(), "# EOF") {
+		t.Errorf("Prometheus output must not end with # EOF")
+	}
+
+	// OpenMetrics names counter families without _total and ends with # EOF
+	var om bytes.Buffer
+	d.writeMetrics(&om, true)
+	if !strings.Contains(om.String(), "# TYPE mem_daemon_events_received counter\nmem_daemon_events_received_total 7\n") {
+		t.Errorf("Unexpected OpenMetrics counter:\n%s", om.String())
+	}
+	if !strings.HasSuffix(om.String(), "# EOF\n") {
+		t.Errorf("OpenMetrics output must end with # EOF")
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	config := DefaultConfig()
+	config.Ingest = false
+	config.MetricsAddr = "127.0.0.1:0"
+	d, err := NewCaptureDaemon(config)
+	if err != nil {
+		t.Fatalf("Failed to create daemon: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer d.Stop()
+
+	base := "http://" + d.MetricsAddr()
+	resp, err := http.Get(base + "/healthz")
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "ok" {
+		t.Errorf("Unexpected health check: %d %q", resp.StatusCode, body)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/openmetrics-text") {
+		t.Errorf("Expected OpenMetrics content type, got %q", resp.Header.Get("Content-Type"))
+	}
+}
\ No newline at end of file
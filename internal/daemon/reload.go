@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+// SetReloader makes the daemon reload its config with load on SIGHUP, on a
+// reload request, and whenever the file at path changes. Only the watch
+// directories are applied to a running daemon; other settings need a restart.
+func (d *CaptureDaemon) SetReloader(path string, load func() (*CaptureConfig, error)) {
+	d.configPath = path
+	d.loadConfig = load
+}
+
+// Reload loads the config again and starts or stops watching directories to
+// match it. If the config cannot be loaded, the current settings are kept.
+func (d *CaptureDaemon) Reload() error {
+	if d.loadConfig == nil {
+		return errors.New("daemon was started without a config to reload")
+	}
+	config, err := d.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
+
+	current := make(map[string]bool)
+	for _, dir := range d.config.WatchDirs {
+		current[dir] = true
+	}
+	wanted := make(map[string]bool)
+	var watching []string
+	for _, dir := range config.WatchDirs {
+		wanted[dir] = true
+		if !current[dir] {
+			if err := d.watcher.WatchTree(dir, "*.jsonl", ""); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to watch %s: %v\n", dir, err)
+				continue
+			}
+			fmt.Printf("Watching %s\n", dir)
+		}
+		watching = append(watching, dir)
+	}
+	for _, dir := range d.config.WatchDirs {
+		if wanted[dir] {
+			continue
+		}
+		if err := d.watcher.Unwatch(dir); err != nil && !errors.Is(err, watcher.ErrNotWatched) {
+			fmt.Fprintf(os.Stderr, "Failed to stop watching %s: %v\n", dir, err)
+			continue
+		}
+		fmt.Printf("Stopped watching %s\n", dir)
+	}
+
+	d.config.WatchDirs = watching
+	return nil
+}
+
+// watchConfig reloads the config on SIGHUP and when its file changes. Editors
+// often replace the file rather than write it, so its directory is watched.
+func (d *CaptureDaemon) watchConfig() {
+	defer d.wg.Done()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var changes <-chan fsnotify.Event
+	if d.configPath != "" {
+		fileWatcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			defer fileWatcher.Close()
+			err = fileWatcher.Add(filepath.Dir(d.configPath))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Not watching config file for changes, reload with SIGHUP: %v\n", err)
+		} else {
+			changes = fileWatcher.Events
+		}
+	}
+
+	// A save is often several events; reload once they settle
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-hup:
+			d.reload()
+		case event, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			if filepath.Clean(event.Name) == filepath.Clean(d.configPath) &&
+				event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				settled = time.After(200 * time.Millisecond)
+			}
+		case <-settled:
+			settled = nil
+			d.reload()
+		}
+	}
+}
+
+// reload reloads the config, logging a failure instead of returning it
+func (d *CaptureDaemon) reload() {
+	if err := d.Reload(); err != nil {
+		fmt.Fprintf(os.Stderr, "Config reload failed, keeping the current settings: %v\n", err)
+		return
+	}
+	fmt.Println("Config reloaded")
+}
\ No newline at end of file
@@ -0,0 +1,393 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+// ErrQueueClosed is returned when appending to a queue that is shutting down
+var ErrQueueClosed = errors.New("queue is closed")
+
+const (
+	walSegmentSize = 16 * 1024 * 1024
+	// walMaxRecord bounds a single encoded entry, on write and on read
+	walMaxRecord = 1024 * 1024 * 1024
+)
+
+// WAL is a durable queue of captured lines. Lines are appended to segment
+// files as the watcher reads them and stay there until acknowledged, so
+// nothing is lost if the daemon dies before writing them out. When too much
+// is pending, Append blocks the watcher instead of dropping lines.
+type WAL struct {
+	dir      string
+	maxBytes int64
+
+	mu       sync.Mutex
+	cond     *sync.Cond // signalled when entries are acknowledged
+	segment  *os.File
+	segSize  int64
+	segments []uint64 // first sequence number of each segment, ascending
+	nextSeq  uint64
+	acked    uint64
+	pending  []walEntry // unacknowledged, in append order
+	bytes    int64      // line bytes pending
+	closed   bool
+	notify   chan struct{} // receives a value when entries are appended
+}
+
+// walEntry is a queued event with its sequence number
+type walEntry struct {
+	Seq   uint64
+	Event watcher.Event
+}
+
+// walRecord is the on-disk form of an entry
+type walRecord struct {
+	Seq       uint64    `json:"seq"`
+	Type      string    `json:"type"`
+	Tool      string    `json:"tool"`
+	SessionID string    `json:"session_id"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	Offset    int64     `json:"offset"`
+	Line      []byte    `json:"line"`
+}
+
+// OpenWAL opens the queue in dir, keeping entries that were not acknowledged
+// before the last shutdown. Appends block while maxBytes of lines are pending.
+func OpenWAL(dir string, maxBytes int64) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultConfig().QueueMaxBytes
+	}
+
+	q := &WAL{
+		dir:      dir,
+		maxBytes: maxBytes,
+		nextSeq:  1,
+		notify:   make(chan struct{}, 1),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	if data, err := os.ReadFile(q.ackPath()); err == nil {
+		q.acked, _ = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read queue position: %w", err)
+	}
+	if q.nextSeq <= q.acked {
+		q.nextSeq = q.acked + 1
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load reads every segment, keeps unacknowledged entries and reopens the
+// last segment for appending. A torn record at the end of the last segment,
+// left by a crash mid-write, is cut off; a bad record in an earlier segment
+// is an error, since entries after it would be lost.
+func (q *WAL) load() error {
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*.wal"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		first, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), ".wal"), 10, 64)
+		if err == nil {
+			q.segments = append(q.segments, first)
+		}
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+
+	for i, first := range q.segments {
+		last := i == len(q.segments)-1
+		good, torn, err := q.readSegment(first)
+		if err != nil {
+			return err
+		}
+		if !last {
+			if torn {
+				return fmt.Errorf("queue segment %s is corrupt at byte %d", q.segmentPath(first), good)
+			}
+			continue
+		}
+
+		file, err := os.OpenFile(q.segmentPath(first), os.O_RDWR, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open queue segment: %w", err)
+		}
+		if err := file.Truncate(good); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to repair queue segment: %w", err)
+		}
+		if _, err := file.Seek(good, io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+		q.segment = file
+		q.segSize = good
+	}
+
+	if q.segment == nil {
+		return q.rotate()
+	}
+	return nil
+}
+
+// readSegment loads the entries of one segment and returns the length of its
+// readable prefix, and whether anything follows it
+func (q *WAL) readSegment(first uint64) (int64, bool, error) {
+	file, err := os.Open(q.segmentPath(first))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open queue segment: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open queue segment: %w", err)
+	}
+
+	var good int64
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			return good, err != io.EOF, nil
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		sum := binary.BigEndian.Uint32(header[4:])
+		if size > walMaxRecord || size > info.Size()-good-int64(len(header)) {
+			return good, true, nil
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(file, payload); err != nil || crc32.ChecksumIEEE(payload) != sum {
+			return good, true, nil
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return good, true, nil
+		}
+		good += int64(len(header) + len(payload))
+
+		if rec.Seq >= q.nextSeq {
+			q.nextSeq = rec.Seq + 1
+		}
+		if rec.Seq > q.acked {
+			q.pending = append(q.pending, walEntry{Seq: rec.Seq, Event: watcher.Event{
+				Type:      rec.Type,
+				Tool:      rec.Tool,
+				SessionID: rec.SessionID,
+				Path:      rec.Path,
+				Timestamp: rec.Timestamp,
+				Offset:    rec.Offset,
+				RawLine:   rec.Line,
+			}})
+			q.bytes += int64(len(rec.Line))
+		}
+	}
+}
+
+// Append queues an event. It blocks while the queue is full and fails once
+// the queue is closed.
+func (q *WAL) Append(event watcher.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.bytes >= q.maxBytes {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	rec := walRecord{
+		Seq:       q.nextSeq,
+		Type:      event.Type,
+		Tool:      event.Tool,
+		SessionID: event.SessionID,
+		Path:      event.Path,
+		Timestamp: event.Timestamp,
+		Offset:    event.Offset,
+		Line:      event.RawLine,
+	}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode queue entry: %w", err)
+	}
+	if len(payload) > walMaxRecord {
+		return fmt.Errorf("line of %d bytes in %s is too large to queue", len(event.RawLine), event.Path)
+	}
+
+	if q.segSize > 0 && q.segSize+int64(len(payload)) > walSegmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+
+	frame := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(payload))
+	frame = append(frame, payload...)
+	if _, err := q.segment.Write(frame); err != nil {
+		return fmt.Errorf("failed to write queue entry: %w", err)
+	}
+	q.segSize += int64(len(frame))
+
+	q.pending = append(q.pending, walEntry{Seq: rec.Seq, Event: event})
+	q.bytes += int64(len(event.RawLine))
+	q.nextSeq++
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Notify returns a channel receiving a value after entries are appended
+func (q *WAL) Notify() <-chan struct{} {
+	return q.notify
+}
+
+// Peek returns up to max of the oldest unacknowledged entries
+func (q *WAL) Peek(max int) []walEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if max > len(q.pending) {
+		max = len(q.pending)
+	}
+	return append([]walEntry(nil), q.pending[:max]...)
+}
+
+// Len returns the number of unacknowledged entries
+func (q *WAL) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Bytes returns the size of the lines waiting in the queue
+func (q *WAL) Bytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.bytes
+}
+
+// Sync makes appended entries durable, then calls then before anything else
+// is appended. State saved by then can rely on every entry so far being on disk.
+func (q *WAL) Sync(then func() error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.segment != nil {
+		if err := q.segment.Sync(); err != nil {
+			return fmt.Errorf("failed to sync queue: %w", err)
+		}
+	}
+	if then == nil {
+		return nil
+	}
+	return then()
+}
+
+// Ack removes the entries up to and including seq and frees their space
+func (q *WAL) Ack(seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if seq <= q.acked {
+		return nil
+	}
+
+	n := 0
+	for n < len(q.pending) && q.pending[n].Seq <= seq {
+		q.bytes -= int64(len(q.pending[n].Event.RawLine))
+		n++
+	}
+	q.pending = q.pending[n:]
+	q.acked = seq
+	q.cond.Broadcast()
+
+	// Write atomically
+	tmpFile := q.ackPath() + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(strconv.FormatUint(seq, 10)), 0600); err != nil {
+		return fmt.Errorf("failed to save queue position: %w", err)
+	}
+	if err := os.Rename(tmpFile, q.ackPath()); err != nil {
+		return fmt.Errorf("failed to save queue position: %w", err)
+	}
+
+	// Segments followed by one starting at or before the next entry are done
+	for len(q.segments) > 1 && q.segments[1] <= q.acked+1 {
+		os.Remove(q.segmentPath(q.segments[0]))
+		q.segments = q.segments[1:]
+	}
+	return nil
+}
+
+// Interrupt makes blocked and later appends fail, so the watcher can stop.
+// Entries already queued can still be read and acknowledged.
+func (q *WAL) Interrupt() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// Close closes the queue. Unacknowledged entries are kept for the next open.
+func (q *WAL) Close() error {
+	q.Interrupt()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.segment == nil {
+		return nil
+	}
+	err := q.segment.Close()
+	q.segment = nil
+	return err
+}
+
+// rotate starts a new segment. The caller must hold mu, or be opening the queue.
+func (q *WAL) rotate() error {
+	if q.segment != nil {
+		if err := q.segment.Close(); err != nil {
+			return fmt.Errorf("failed to close queue segment: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(q.segmentPath(q.nextSeq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create queue segment: %w", err)
+	}
+	q.segment = file
+	q.segSize = 0
+	q.segments = append(q.segments, q.nextSeq)
+	return nil
+}
+
+func (q *WAL) segmentPath(first uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d.wal", first))
+}
+
+func (q *WAL) ackPath() string {
+	return filepath.Join(q.dir, "acked")
+}
\ No newline at end of file
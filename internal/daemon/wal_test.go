@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/watcher"
+)
+
+func walEvent(line string) watcher.Event {
+	return watcher.Event{Type: "new_line", Tool: "claude-code", SessionID: "s1", Path: "/s1.jsonl", RawLine: []byte(line)}
+}
+
+func TestWALReplay(t *testing.T) {
+	dir := t.TempDir()
+	q, err := OpenWAL(dir, 1024)
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	for _, line := range []string{"one", "two", "three"} {
+		if err := q.Append(walEvent(line)); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	if err := q.Ack(q.Peek(1)[0].Seq); err != nil {
+		t.Fatalf("Failed to ack: %v", err)
+	}
+	q.Close()
+
+	// Unacknowledged entries come back in order after a restart
+	q, err = OpenWAL(dir, 1024)
+	if err != nil {
+		t.Fatalf("Failed to reopen queue: %v", err)
+	}
+	defer q.Close()
+	entries := q.Peek(10)
+	if len(entries) != 2 || string(entries[0].Event.RawLine) != "two" || string(entries[1].Event.RawLine) != "three" {
+		t.Fatalf("Expected two and three to be replayed, got %+v", entries)
+	}
+	if q.Bytes() != int64(len("twothree")) {
+		t.Errorf("Expected %d queued bytes, got %d", len("twothree"), q.Bytes())
+	}
+
+	// New entries continue the sequence
+	q.Append(walEvent("four"))
+	if last := q.Peek(10)[2]; last.Seq != entries[1].Seq+1 {
+		t.Errorf("Expected seq %d, got %d", entries[1].Seq+1, last.Seq)
+	}
+}
+
+func TestWALTornTail(t *testing.T) {
+	dir := t.TempDir()
+	q, _ := OpenWAL(dir, 1024)
+	q.Append(walEvent("one"))
+	q.Close()
+
+	// A crash mid-write leaves part of a record behind
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	file, _ := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0600)
+	file.Write([]byte{0, 0, 0, 40, 1, 2})
+	file.Close()
+
+	q, err := OpenWAL(dir, 1024)
+	if err != nil {
+		t.Fatalf("Failed to reopen queue: %v", err)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Expected the intact entry to survive, got %d entries", q.Len())
+	}
+	q.Append(walEvent("two"))
+	q.Close()
+
+	q, _ = OpenWAL(dir, 1024)
+	defer q.Close()
+	if q.Len() != 2 {
+		t.Errorf("Expected appends after the repair to be readable, got %d entries", q.Len())
+	}
+}
+
+func TestWALLargeLines(t *testing.T) {
+	dir := t.TempDir()
+	q, _ := OpenWAL(dir, 64*1024*1024)
+	large := strings.Repeat("x", walSegmentSize+1)
+	for _, line := range []string{"one", large, "three"} {
+		if err := q.Append(walEvent(line)); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	q.Close()
+
+	// A line larger than a segment gets one of its own and survives a restart
+	q, err := OpenWAL(dir, 64*1024*1024)
+	if err != nil {
+		t.Fatalf("Failed to reopen queue: %v", err)
+	}
+	entries := q.Peek(10)
+	if len(entries) != 3 || len(entries[1].Event.RawLine) != len(large) || string(entries[2].Event.RawLine) != "three" {
+		t.Fatalf("Expected all three lines after a restart, got %d", len(entries))
+	}
+	q.Close()
+
+	// Damage before the last segment is reported rather than skipped
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if len(segments) != 3 {
+		t.Fatalf("Expected 3 segments, got %d", len(segments))
+	}
+	data, _ := os.ReadFile(segments[0])
+	data[len(data)-2] ^= 0xff
+	os.WriteFile(segments[0], data, 0600)
+	if q, err := OpenWAL(dir, 64*1024*1024); err == nil {
+		q.Close()
+		t.Error("Expected a corrupt earlier segment to fail the open")
+	}
+}
+
+func TestWALBackpressure(t *testing.T) {
+	q, _ := OpenWAL(t.TempDir(), 4)
+	defer q.Close()
+	q.Append(walEvent("full"))
+
+	// A full queue holds the writer back until space is acknowledged
+	done := make(chan error, 1)
+	go func() { done <- q.Append(walEvent("next")) }()
+	select {
+	case <-done:
+		t.Fatal("Expected append to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Ack(q.Peek(1)[0].Seq)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected append to resume after ack")
+	}
+
+	// Interrupting releases a blocked writer
+	go func() { done <- q.Append(walEvent("more")) }()
+	time.Sleep(20 * time.Millisecond)
+	q.Interrupt()
+	if err := <-done; !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
\ No newline at end of file
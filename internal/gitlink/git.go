@@ -0,0 +1,159 @@
+// Package gitlink links git commits to the AI conversations that produced them
+package gitlink
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrailerKey is the commit message trailer naming the session behind a commit
+const TrailerKey = "Mem-Session"
+
+// Commit is a git commit with the files it changed
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Time    time.Time `json:"time"`
+	Author  string    `json:"author"`
+	Subject string    `json:"subject"`
+	// Sessions are the session IDs of its Mem-Session trailers
+	Sessions []string `json:"sessions,omitempty"`
+	Files    []string `json:"files"`
+}
+
+// logFormat prints each commit as fields separated by \x1f, starting with \x1e
+var logFormat = "--format=%x1e%H%x1f%ct%x1f%an%x1f%s%x1f%(trailers:key=" + TrailerKey + ",valueonly,separator=%x2C)"
+
+// RepoRoot returns the top directory of the git repository holding dir
+func RepoRoot(dir string) (string, error) {
+	out, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Log returns the commits of the current branch, newest first, leaving out
+// merges and commits made before since
+func Log(repo string, since time.Time) ([]Commit, error) {
+	args := []string{"log", "--no-merges", "--no-renames", "--name-only", logFormat}
+	if !since.IsZero() {
+		args = append(args, fmt.Sprintf("--since=%d", since.Unix()))
+	}
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+// ReadCommit returns a single commit
+func ReadCommit(repo, rev string) (*Commit, error) {
+	out, err := git(repo, "log", "-1", "--no-renames", "--name-only", logFormat, rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	commits, err := parseLog(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("commit %s not found", rev)
+	}
+	return &commits[0], nil
+}
+
+// BlameLine returns the commit that last changed a line of a file, counting
+// lines from 1
+func BlameLine(repo, file string, line int) (string, error) {
+	out, err := git(repo, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "--", file)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no blame for %s:%d", file, line)
+	}
+	if strings.Trim(fields[0], "0") == "" {
+		return "", fmt.Errorf("line %d of %s is not committed yet", line, file)
+	}
+	return fields[0], nil
+}
+
+// HooksDir returns the directory git runs a repository's hooks from
+func HooksDir(repo string) (string, error) {
+	out, err := git(repo, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return dir, nil
+}
+
+// AddTrailer adds a Mem-Session trailer to a commit message file unless it
+// already names the session
+func AddTrailer(repo, messageFile, sessionID string) error {
+	_, err := git(repo, "interpret-trailers", "--in-place", "--if-exists", "addIfDifferent",
+		"--trailer", TrailerKey+": "+sessionID, messageFile)
+	return err
+}
+
+func parseLog(out string) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		lines := strings.Split(record, "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected git log output %q", lines[0])
+		}
+		unix, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected commit time %q", fields[1])
+		}
+
+		commit := Commit{
+			SHA:     fields[0],
+			Time:    time.Unix(unix, 0),
+			Author:  fields[2],
+			Subject: fields[3],
+			Files:   []string{},
+		}
+		for _, session := range strings.Split(fields[4], ",") {
+			if session = strings.TrimSpace(session); session != "" {
+				commit.Sessions = append(commit.Sessions, session)
+			}
+		}
+		for _, file := range lines[1:] {
+			if file = strings.TrimSpace(file); file != "" {
+				commit.Files = append(commit.Files, file)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// git runs a git command in dir and returns its output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
\ No newline at end of file
@@ -0,0 +1,56 @@
+package gitlink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies prepare-commit-msg hooks written by mem
+const hookMarker = "# Installed by mem"
+
+// ErrHookExists is returned when a repository already has a
+// prepare-commit-msg hook that mem did not install
+var ErrHookExists = errors.New("a prepare-commit-msg hook not installed by mem already exists")
+
+// hookScript runs mem to add the trailer. Finding no session, or any other
+// failure, never stops the commit.
+const hookScript = `#!/bin/sh
+` + hookMarker + `: adds a ` + TrailerKey + ` trailer naming the AI session behind a commit
+%s git trailer "$@" || true
+`
+
+// InstallHook writes a prepare-commit-msg hook running mem at memPath into a
+// repository and returns the hook's path. A hook mem installed before is
+// replaced; any other hook only if force is set.
+func InstallHook(repo, memPath string, force bool) (string, error) {
+	dir, err := HooksDir(repo)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "prepare-commit-msg")
+
+	if existing, err := os.ReadFile(path); err == nil && !force && !strings.Contains(string(existing), hookMarker) {
+		return "", ErrHookExists
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	script := fmt.Sprintf(hookScript, shellQuote(memPath))
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to make hook executable: %w", err)
+	}
+	return path, nil
+}
+
+// shellQuote quotes s as a single word for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package gitlink
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// How a commit was matched to a conversation
+const (
+	MethodTrailer = "trailer"
+	MethodFiles   = "files"
+	MethodTime    = "time"
+)
+
+// Options tune how commits without a trailer are matched
+type Options struct {
+	// Window is how long after a conversation's last message a commit may
+	// still come from it
+	Window time.Duration
+	// MinScore is the score from 0 to 1 a match by files or time needs. A
+	// commit made during a conversation scores 0.5 on time alone; the share of
+	// its files the conversation changed adds up to another 0.5.
+	MinScore float64
+}
+
+// DefaultOptions are the options mem git link uses unless told otherwise
+var DefaultOptions = Options{Window: 2 * time.Hour, MinScore: 0.5}
+
+// Session is a conversation commits may come from, with the files it edited
+// or wrote as recorded
+type Session struct {
+	Span  models.SessionSpan
+	Files []string
+}
+
+// Match links each commit to the session it most likely came from. A
+// Mem-Session trailer decides; otherwise sessions are scored on whether the
+// commit was made during or shortly after them, and on the files both changed.
+// Commits without a match are left out.
+func Match(repo string, commits []Commit, sessions []Session, opts Options) []models.CommitLink {
+	var links []models.CommitLink
+	for _, commit := range commits {
+		var best *Session
+		bestScore, bestMethod := 0.0, ""
+		for i := range sessions {
+			s := &sessions[i]
+			score, method := matchScore(commit, s, opts)
+			if method != MethodTrailer && score < opts.MinScore {
+				continue
+			}
+			if best == nil || score > bestScore || (score == bestScore && s.Span.End.After(best.Span.End)) {
+				best, bestScore, bestMethod = s, score, method
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		conv := best.Span.Conversation
+		links = append(links, models.CommitLink{
+			Repo:              repo,
+			SHA:               commit.SHA,
+			ConversationID:    conv.ID,
+			ConversationTitle: conv.Title,
+			SessionID:         conv.SessionID,
+			Method:            bestMethod,
+			Score:             bestScore,
+			CommittedAt:       commit.Time,
+			Subject:           commit.Subject,
+		})
+	}
+	return links
+}
+
+// matchScore scores how likely a commit is to come from a session
+func matchScore(commit Commit, s *Session, opts Options) (float64, string) {
+	for _, id := range commit.Sessions {
+		if id != "" && id == s.Span.Conversation.SessionID {
+			return 1, MethodTrailer
+		}
+	}
+
+	// A commit from before a session started cannot come from it
+	var timeScore float64
+	switch {
+	case commit.Time.Before(s.Span.Start):
+		return 0, ""
+	case !commit.Time.After(s.Span.End):
+		timeScore = 1
+	case opts.Window > 0 && commit.Time.Sub(s.Span.End) <= opts.Window:
+		timeScore = 1 - float64(commit.Time.Sub(s.Span.End))/float64(opts.Window)
+	default:
+		return 0, ""
+	}
+
+	shared := 0
+	for _, file := range commit.Files {
+		for _, changed := range s.Files {
+			if sameFile(file, changed) {
+				shared++
+				break
+			}
+		}
+	}
+	if shared == 0 {
+		return timeScore / 2, MethodTime
+	}
+	return timeScore/2 + float64(shared)/float64(len(commit.Files))/2, MethodFiles
+}
+
+// sameFile reports whether a path relative to the repository root and a path
+// recorded by a session name the same file. Sessions record paths relative to
+// their working directory, which may be below the root, or absolute.
+func sameFile(repoPath, recorded string) bool {
+	return repoPath == recorded ||
+		strings.HasSuffix(repoPath, "/"+recorded) ||
+		strings.HasSuffix(recorded, "/"+repoPath)
+}
+
+// InRepo reports whether a conversation ran in the repository at root or a
+// directory below it. Conversations captured before their working directory
+// was recorded fall back to their Claude Code project directory, named after
+// the working directory with every other character than letters and digits
+// replaced by '-', which also matches siblings such as root-web. Those from
+// other tools match on the project name.
+func InRepo(conv models.Conversation, root string) bool {
+	if conv.WorkDir != "" {
+		return models.IsWithin(conv.WorkDir, root)
+	}
+	if conv.ProjectPath != "" {
+		project, repo := models.ProjectDirName(conv.ProjectPath), models.ProjectDirName(root)
+		return project == repo || strings.HasPrefix(project, repo+"-")
+	}
+	return conv.Project != "" && conv.Project == filepath.Base(root)
+}
\ No newline at end of file
@@ -0,0 +1,193 @@
+package gitlink
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+var linkBase = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newRepo creates a git repository for a test
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	runGit(t, repo, linkBase, "init", "-q")
+	return repo
+}
+
+// commit writes files and commits them at the given time
+func commit(t *testing.T, repo string, at time.Time, message string, files ...string) string {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(repo, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, repo, at, "add", "-A")
+	runGit(t, repo, at, "commit", "-q", "-m", message)
+	return strings.TrimSpace(runGit(t, repo, at, "rev-parse", "HEAD"))
+}
+
+func runGit(t *testing.T, repo string, at time.Time, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	date := at.Format(time.RFC3339)
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_COMMITTER_DATE="+date,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestLogAndBlame(t *testing.T) {
+	repo := newRepo(t)
+	first := commit(t, repo, linkBase, "Add store", "internal/store.go", "README.md")
+	second := commit(t, repo, linkBase.Add(time.Hour), "Fix store\n\nMem-Session: abc-123", "internal/store.go")
+
+	commits, err := Log(repo, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].SHA != second || commits[1].SHA != first {
+		t.Fatalf("Expected both commits newest first, got %+v", commits)
+	}
+	c := commits[0]
+	if c.Subject != "Fix store" || c.Author != "Test" || !c.Time.Equal(linkBase.Add(time.Hour)) ||
+		len(c.Sessions) != 1 || c.Sessions[0] != "abc-123" || len(c.Files) != 1 || c.Files[0] != "internal/store.go" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	if len(commits[1].Files) != 2 || commits[1].Sessions != nil {
+		t.Errorf("Unexpected commit %+v", commits[1])
+	}
+
+	if recent, _ := Log(repo, linkBase.Add(30*time.Minute)); len(recent) != 1 {
+		t.Errorf("Expected 1 commit since, got %d", len(recent))
+	}
+
+	if sha, err := BlameLine(repo, "README.md", 1); err != nil || sha != first {
+		t.Errorf("BlameLine() = %s, %v, want %s", sha, err, first)
+	}
+	if _, err := BlameLine(repo, "README.md", 5); err == nil {
+		t.Error("Expected an error for a line past the end")
+	}
+	if got, err := ReadCommit(repo, first); err != nil || got.Subject != "Add store" {
+		t.Errorf("ReadCommit() = %+v, %v", got, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	span := func(id int64, sessionID string, start, end time.Duration) models.SessionSpan {
+		return models.SessionSpan{
+			Conversation: models.Conversation{ID: id, SessionID: sessionID},
+			Start:        linkBase.Add(start),
+			End:          linkBase.Add(end),
+		}
+	}
+	sessions := []Session{
+		{Span: span(1, "s1", 0, time.Hour), Files: []string{"internal/store.go", "/home/me/repo/cmd/main.go"}},
+		{Span: span(2, "s2", 3*time.Hour, 4*time.Hour)},
+	}
+	commits := []Commit{
+		{SHA: "during", Time: linkBase.Add(30 * time.Minute), Files: []string{"internal/store.go", "cmd/main.go"}},
+		{SHA: "after", Time: linkBase.Add(90 * time.Minute), Files: []string{"internal/store.go", "docs/a.md"}},
+		{SHA: "late", Time: linkBase.Add(150 * time.Minute), Files: []string{"docs/a.md"}},
+		{SHA: "timeonly", Time: linkBase.Add(210 * time.Minute), Files: []string{"docs/a.md"}},
+		{SHA: "trailer", Time: linkBase.Add(-time.Hour), Sessions: []string{"s2"}},
+	}
+
+	links := Match("/repo", commits, sessions, DefaultOptions)
+	got := make(map[string]models.CommitLink)
+	for _, l := range links {
+		got[l.SHA] = l
+	}
+
+	if l := got["during"]; l.ConversationID != 1 || l.Method != MethodFiles || l.Score != 1 {
+		t.Errorf("Expected a full match by files, got %+v", l)
+	}
+	// Half an hour after the session: 0.375 for time, 0.25 for one of two files
+	if l := got["after"]; l.ConversationID != 1 || l.Method != MethodFiles || l.Score != 0.625 {
+		t.Errorf("Expected a match by files after the session, got %+v", l)
+	}
+	if _, ok := got["late"]; ok {
+		t.Error("Expected no match for a commit long after the session with no shared files")
+	}
+	if l := got["timeonly"]; l.ConversationID != 2 || l.Method != MethodTime || l.Score != 0.5 {
+		t.Errorf("Expected a match by time during session 2, got %+v", l)
+	}
+	if l := got["trailer"]; l.ConversationID != 2 || l.Method != MethodTrailer || l.Repo != "/repo" {
+		t.Errorf("Expected a match by trailer, got %+v", l)
+	}
+}
+
+func TestInRepo(t *testing.T) {
+	tests := []struct {
+		conv     models.Conversation
+		expected bool
+	}{
+		{models.Conversation{ProjectPath: "-home-me-src-ai-memory"}, true},
+		{models.Conversation{ProjectPath: "-home-me-src-ai-memory-internal"}, true},
+		{models.Conversation{ProjectPath: "-home-me-src-ai-memory2"}, false},
+		{models.Conversation{WorkDir: "/home/me/src/ai-memory"}, true},
+		{models.Conversation{WorkDir: "/home/me/src/ai-memory/internal"}, true},
+		{models.Conversation{WorkDir: "/home/me/src/ai-memory-web", ProjectPath: "-home-me-src-ai-memory-web"}, false},
+		{models.Conversation{Project: "ai-memory"}, true},
+		{models.Conversation{Project: "other"}, false},
+	}
+	for _, tt := range tests {
+		if got := InRepo(tt.conv, "/home/me/src/ai-memory"); got != tt.expected {
+			t.Errorf("InRepo(%+v) = %v, want %v", tt.conv, got, tt.expected)
+		}
+	}
+}
+
+func TestInstallHookAndTrailer(t *testing.T) {
+	repo := newRepo(t)
+
+	path, err := InstallHook(repo, "/opt/it's/mem", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if info, _ := os.Stat(path); info.Mode().Perm()&0100 == 0 || !strings.Contains(string(data), `'/opt/it'\''s/mem' git trailer "$@" || true`) {
+		t.Errorf("Unexpected hook %s:\n%s", info.Mode(), data)
+	}
+	if _, err := InstallHook(repo, "/usr/bin/mem", false); err != nil {
+		t.Errorf("Expected a hook from mem to be replaced, got %v", err)
+	}
+
+	os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0755)
+	if _, err := InstallHook(repo, "/usr/bin/mem", false); !errors.Is(err, ErrHookExists) {
+		t.Errorf("Expected ErrHookExists, got %v", err)
+	}
+	if _, err := InstallHook(repo, "/usr/bin/mem", true); err != nil {
+		t.Errorf("Expected --force to replace the hook, got %v", err)
+	}
+
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	os.WriteFile(message, []byte("Fix store\n"), 0644)
+	for i := 0; i < 2; i++ {
+		if err := AddTrailer(repo, message, "abc-123"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(message); string(data) != "Fix store\n\nMem-Session: abc-123\n" {
+		t.Errorf("Unexpected message %q", data)
+	}
+}
\ No newline at end of file
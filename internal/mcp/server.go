@@ -0,0 +1,258 @@
+// Package mcp serves conversation memory to agents over the Model Context
+// Protocol: newline-delimited JSON-RPC 2.0 on stdin and stdout.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jasperwreed/ai-memory/internal/search"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// maxMessageSize is the largest JSON-RPC message the server reads
+const maxMessageSize = 16 * 1024 * 1024
+
+// conversationURI prefixes the ID of a conversation resource
+const conversationURI = "mem://conversations/"
+
+// resourcePageSize is how many conversations resources/list returns at once
+const resourcePageSize = 50
+
+// Server answers MCP requests from a store
+type Server struct {
+	store    *storage.SQLiteStore
+	searcher *search.Searcher
+	version  string
+	tools    []tool
+}
+
+// NewServer creates a server for store; version is reported to clients
+func NewServer(store *storage.SQLiteStore, version string) *Server {
+	s := &Server{
+		store:    store,
+		searcher: search.NewSearcher(store),
+		version:  version,
+	}
+	s.tools = s.registerTools()
+	return s
+}
+
+// Serve reads requests from r and writes responses to w, one per line, until
+// r is closed. Nothing else is written to w.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message, returning nil for notifications
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorResponse(json.RawMessage("null"), &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()})
+	}
+
+	id := req.ID
+	notification := len(id) == 0
+	if notification {
+		id = json.RawMessage("null")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if notification && req.Method == "" {
+			// A response from the client; the server sends no requests
+			return nil
+		}
+		return errorResponse(id, &rpcError{Code: codeInvalidRequest, Message: "invalid request"})
+	}
+
+	result, err := s.dispatch(req.Method, req.Params)
+	if notification {
+		return nil
+	}
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		return errorResponse(id, rerr)
+	}
+	return &response{JSONRPC: "2.0", ID: id, Result: result}
+}
+
+func errorResponse(id json.RawMessage, err *rpcError) *response {
+	return &response{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+// dispatch runs method
+func (s *Server) dispatch(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(params)
+	case "resources/list":
+		return s.listResources(params)
+	case "resources/templates/list":
+		return map[string]interface{}{"resourceTemplates": []resourceTemplate{{
+			URITemplate: conversationURI + "{id}",
+			Name:        "conversation",
+			Title:       "Conversation",
+			Description: "A stored conversation as a Markdown transcript",
+			MimeType:    "text/markdown",
+		}}}, nil
+	case "resources/read":
+		return s.readResource(params)
+	}
+	if strings.HasPrefix(method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + method}
+}
+
+// decodeParams decodes params into v, which may be left empty
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	version := ProtocolVersion
+	if supportedVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":     map[string]interface{}{},
+			"resources": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    "mem",
+			"title":   "AI Memory",
+			"version": s.version,
+		},
+		"instructions": "Search and read past AI coding conversations captured by mem. " +
+			"Use find_by_file to see earlier work on a file, and save_note to remember something for later sessions.",
+	}, nil
+}
+
+func (s *Server) listResources(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Cursor string `json:"cursor"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	offset := 0
+	if p.Cursor != "" {
+		n, err := strconv.Atoi(p.Cursor)
+		if err != nil || n < 0 {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid cursor"}
+		}
+		offset = n
+	}
+
+	conversations, err := s.store.ListConversations(resourcePageSize, offset, map[string]string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	resources := make([]resource, 0, len(conversations))
+	for _, conv := range conversations {
+		description := conv.Tool
+		if conv.Project != "" {
+			description += " · " + conv.Project
+		}
+		description += " · " + conv.CreatedAt.Format("2006-01-02 15:04")
+		resources = append(resources, resource{
+			URI:         conversationURI + strconv.FormatInt(conv.ID, 10),
+			Name:        fmt.Sprintf("conversation-%d", conv.ID),
+			Title:       conv.Title,
+			Description: description,
+			MimeType:    "text/markdown",
+		})
+	}
+
+	result := map[string]interface{}{"resources": resources}
+	if len(conversations) == resourcePageSize {
+		result["nextCursor"] = strconv.Itoa(offset + resourcePageSize)
+	}
+	return result, nil
+}
+
+func (s *Server) readResource(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	notFound := &rpcError{Code: codeResourceNotFound, Message: "resource not found: " + p.URI}
+
+	idText, ok := strings.CutPrefix(p.URI, conversationURI)
+	if !ok {
+		return nil, notFound
+	}
+	id, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil {
+		return nil, notFound
+	}
+	conv, err := s.store.GetConversation(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return map[string]interface{}{"contents": []resourceContents{{
+		URI:      p.URI,
+		MimeType: "text/markdown",
+		Text:     transcript(conv),
+	}}}, nil
+}
\ No newline at end of file
@@ -0,0 +1,279 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+// testResponse is a decoded response from the server
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Now()
+	conv := &models.Conversation{
+		Title:     "Fix the write-ahead queue",
+		Tool:      "claude-code",
+		Project:   "ai-memory",
+		CreatedAt: now,
+		UpdatedAt: now,
+		Messages: []models.Message{
+			{Role: "user", Content: "The queue loses events on crash", Timestamp: now},
+			{Role: "assistant", Content: "[Used tool: Edit /src/internal/daemon/wal.go]", Timestamp: now,
+				Files: []models.FileRef{{Path: "/src/internal/daemon/wal.go", Access: models.FileEdit}}},
+			{Role: "assistant", Content: "Fixed the torn tail handling", Timestamp: now},
+		},
+	}
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(store, "test")
+}
+
+// exchange sends each line to the server over stdio and returns its responses
+func exchange(t *testing.T, s *Server, lines ...string) []testResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var responses []testResponse
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp testResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("Response is not JSON: %q", line)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// call sends one request and returns its result
+func call(t *testing.T, s *Server, method, params string) json.RawMessage {
+	t.Helper()
+	responses := exchange(t, s, `{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":`+params+`}`)
+	if len(responses) != 1 {
+		t.Fatalf("Expected one response, got %d", len(responses))
+	}
+	if responses[0].Error != nil {
+		t.Fatalf("%s failed: %v", method, responses[0].Error)
+	}
+	return responses[0].Result
+}
+
+// callTool calls a tool and decodes its structured result into v
+func callTool(t *testing.T, s *Server, name, args string, v interface{}) toolResult {
+	t.Helper()
+	var result toolResult
+	json.Unmarshal(call(t, s, "tools/call", `{"name":"`+name+`","arguments":`+args+`}`), &result)
+	if !result.IsError && v != nil {
+		if err := json.Unmarshal([]byte(result.Content[0].Text), v); err != nil {
+			t.Fatalf("%s returned invalid JSON: %v", name, err)
+		}
+	}
+	return result
+}
+
+func TestInitialize(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+	)
+	if len(responses) != 3 {
+		t.Fatalf("Expected no response to the notification, got %d responses", len(responses))
+	}
+
+	var init struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+		ServerInfo      struct{ Name string }      `json:"serverInfo"`
+	}
+	json.Unmarshal(responses[0].Result, &init)
+	if init.ProtocolVersion != "2025-03-26" {
+		t.Errorf("Expected the client's version to be accepted, got %q", init.ProtocolVersion)
+	}
+	if init.Capabilities["tools"] == nil || init.Capabilities["resources"] == nil {
+		t.Errorf("Expected tools and resources capabilities, got %v", init.Capabilities)
+	}
+	if init.ServerInfo.Name != "mem" {
+		t.Errorf("Unexpected server name %q", init.ServerInfo.Name)
+	}
+
+	if string(responses[1].ID) != `"two"` {
+		t.Errorf("Expected the request ID to be echoed, got %s", responses[1].ID)
+	}
+	var list struct {
+		Tools []struct {
+			Name        string
+			InputSchema map[string]interface{}
+		}
+	}
+	json.Unmarshal(responses[1].Result, &list)
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("Expected %s to have an object schema", tool.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "search_conversations,get_conversation,list_recent,find_by_file,save_note" {
+		t.Errorf("Unexpected tools %s", got)
+	}
+
+	// An unknown version gets the newest one
+	json.Unmarshal(call(t, s, "initialize", `{"protocolVersion":"1999-01-01"}`), &init)
+	if init.ProtocolVersion != ProtocolVersion {
+		t.Errorf("Expected %s, got %q", ProtocolVersion, init.ProtocolVersion)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := exchange(t, s,
+		`{not json`,
+		`{"jsonrpc":"2.0","id":1,"method":"nope"}`,
+		`{"jsonrpc":"1.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"nope"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"mem://conversations/999"}}`,
+	)
+	want := []int{codeParseError, codeMethodNotFound, codeInvalidRequest, codeInvalidParams, codeResourceNotFound}
+	if len(responses) != len(want) {
+		t.Fatalf("Expected %d responses, got %d", len(want), len(responses))
+	}
+	for i, resp := range responses {
+		if resp.Error == nil || resp.Error.Code != want[i] {
+			t.Errorf("Response %d: expected error %d, got %+v", i, want[i], resp.Error)
+		}
+	}
+	if string(responses[0].ID) != "null" {
+		t.Errorf("Expected a null ID for a parse error, got %s", responses[0].ID)
+	}
+}
+
+func TestTools(t *testing.T) {
+	s := newTestServer(t)
+
+	var search struct{ Results []models.SearchResult }
+	callTool(t, s, "search_conversations", `{"query":"torn","tool":"claude-code"}`, &search)
+	if len(search.Results) != 1 || search.Results[0].Conversation.Title != "Fix the write-ahead queue" {
+		t.Fatalf("Unexpected search results %+v", search.Results)
+	}
+	id := search.Results[0].Conversation.ID
+
+	var byFile struct{ Results []models.FileConversation }
+	callTool(t, s, "find_by_file", `{"path":"daemon/wal.go"}`, &byFile)
+	if len(byFile.Results) != 1 || byFile.Results[0].Conversation.ID != id || len(byFile.Results[0].Files) != 1 {
+		t.Errorf("Expected the conversation that edited wal.go, got %+v", byFile.Results)
+	}
+
+	var page struct {
+		Messages      []models.Message
+		Offset        int `json:"offset"`
+		TotalMessages int `json:"total_messages"`
+	}
+	callTool(t, s, "get_conversation", `{"id":`+jsonInt(id)+`,"offset":1,"limit":1}`, &page)
+	if page.TotalMessages != 3 || page.Offset != 1 || len(page.Messages) != 1 ||
+		!strings.Contains(page.Messages[0].Content, "wal.go") {
+		t.Errorf("Unexpected page %+v", page)
+	}
+
+	var saved struct {
+		ID  int64
+		URI string
+	}
+	callTool(t, s, "save_note", `{"content":"Queue segments are 4MB\nSee wal.go","tags":["daemon"]}`, &saved)
+	if saved.ID == 0 || saved.URI == "" {
+		t.Fatalf("Expected the note's ID, got %+v", saved)
+	}
+
+	var recent struct{ Conversations []models.Conversation }
+	callTool(t, s, "list_recent", `{"tool":"note"}`, &recent)
+	if len(recent.Conversations) != 1 || recent.Conversations[0].Title != "Queue segments are 4MB" {
+		t.Errorf("Expected the note to be listed, got %+v", recent.Conversations)
+	}
+
+	// Tool failures are results the agent can read
+	result := callTool(t, s, "get_conversation", `{"id":999}`, nil)
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "not found") {
+		t.Errorf("Expected a tool error, got %+v", result)
+	}
+	result = callTool(t, s, "save_note", `{"content":" "}`, nil)
+	if !result.IsError {
+		t.Error("Expected an empty note to be rejected")
+	}
+}
+
+func TestNoteTitle(t *testing.T) {
+	if got := noteTitle("Short title\nbody"); got != "Short title" {
+		t.Errorf("noteTitle() = %q, want the first line", got)
+	}
+	long := strings.Repeat("a", 76) + strings.Repeat("é", 10)
+	got := noteTitle(long)
+	if !utf8.ValidString(got) || got != strings.Repeat("a", 76)+"..." {
+		t.Errorf("noteTitle() = %q, want it cut before the split character", got)
+	}
+}
+
+func TestResources(t *testing.T) {
+	s := newTestServer(t)
+
+	var list struct {
+		Resources  []resource
+		NextCursor string
+	}
+	json.Unmarshal(call(t, s, "resources/list", `{}`), &list)
+	if len(list.Resources) != 1 || list.NextCursor != "" {
+		t.Fatalf("Expected one resource, got %+v", list)
+	}
+	uri := list.Resources[0].URI
+
+	var read struct{ Contents []resourceContents }
+	json.Unmarshal(call(t, s, "resources/read", `{"uri":"`+uri+`"}`), &read)
+	if len(read.Contents) != 1 || read.Contents[0].MimeType != "text/markdown" {
+		t.Fatalf("Unexpected contents %+v", read.Contents)
+	}
+	text := read.Contents[0].Text
+	for _, want := range []string{"# Fix the write-ahead queue", "- Project: ai-memory", "## user", "Fixed the torn tail handling"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected transcript to contain %q, got:\n%s", want, text)
+		}
+	}
+
+	var templates struct{ ResourceTemplates []resourceTemplate }
+	json.Unmarshal(call(t, s, "resources/templates/list", `{}`), &templates)
+	if len(templates.ResourceTemplates) != 1 || templates.ResourceTemplates[0].URITemplate != "mem://conversations/{id}" {
+		t.Errorf("Unexpected templates %+v", templates.ResourceTemplates)
+	}
+}
+
+func jsonInt(n int64) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
\ No newline at end of file
@@ -0,0 +1,369 @@
+package mcp
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jasperwreed/ai-memory/internal/capture"
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// NoteTool is the tool recorded for notes saved with save_note
+const NoteTool = "note"
+
+// maxLimit caps the limit argument of every tool
+const maxLimit = 100
+
+// tool is an MCP tool and the function that runs it
+type tool struct {
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	run         func(args json.RawMessage) (interface{}, error)
+}
+
+// schema builds an object schema from its properties and required names
+func schema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func property(kind, description string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "description": description}
+}
+
+func (s *Server) registerTools() []tool {
+	return []tool{
+		{
+			Name:        "search_conversations",
+			Title:       "Search conversations",
+			Description: "Full-text search over every captured conversation. Supports FTS5 syntax: phrases in double quotes, AND, OR, NOT and prefix*.",
+			InputSchema: schema(map[string]interface{}{
+				"query":   property("string", "Search query"),
+				"limit":   property("integer", "Maximum number of results (default 10)"),
+				"tool":    property("string", "Only conversations from this tool, e.g. claude-code"),
+				"project": property("string", "Only conversations in this project"),
+			}, "query"),
+			run: s.searchConversations,
+		},
+		{
+			Name:        "get_conversation",
+			Title:       "Get conversation",
+			Description: "Get a conversation and its messages by ID. Long conversations are paged with offset and limit.",
+			InputSchema: schema(map[string]interface{}{
+				"id":     property("integer", "Conversation ID"),
+				"offset": property("integer", "Index of the first message to return (default 0)"),
+				"limit":  property("integer", "Maximum number of messages (default 50)"),
+			}, "id"),
+			run: s.getConversation,
+		},
+		{
+			Name:        "list_recent",
+			Title:       "List recent conversations",
+			Description: "List the most recent conversations, newest first, without their messages.",
+			InputSchema: schema(map[string]interface{}{
+				"limit":   property("integer", "Maximum number of conversations (default 10)"),
+				"tool":    property("string", "Only conversations from this tool"),
+				"project": property("string", "Only conversations in this project"),
+			}),
+			run: s.listRecent,
+		},
+		{
+			Name:        "find_by_file",
+			Title:       "Find conversations by file",
+			Description: "Find conversations that read, edited or mentioned a file. A partial path such as internal/daemon/wal.go also matches.",
+			InputSchema: schema(map[string]interface{}{
+				"path":  property("string", "File path"),
+				"limit": property("integer", "Maximum number of conversations (default 10)"),
+			}, "path"),
+			run: s.findByFile,
+		},
+		{
+			Name:        "save_note",
+			Title:       "Save note",
+			Description: "Save a note to memory so it can be found in later sessions. It is stored as a conversation from the note tool.",
+			InputSchema: schema(map[string]interface{}{
+				"content": property("string", "Note text"),
+				"title":   property("string", "Title (default: the first line of the note)"),
+				"project": property("string", "Project the note belongs to"),
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]string{"type": "string"},
+					"description": "Tags",
+				},
+			}, "content"),
+			run: s.saveNote,
+		},
+	}
+}
+
+// callTool runs a tool. Failures of the tool itself are returned as a result
+// with isError set; an unknown tool or malformed call is a protocol error.
+func (s *Server) callTool(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	for _, t := range s.tools {
+		if t.Name != p.Name {
+			continue
+		}
+		args := p.Arguments
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+
+		value, err := t.run(args)
+		if err != nil {
+			return toolResult{
+				Content: []textContent{{Type: "text", Text: err.Error()}},
+				IsError: true,
+			}, nil
+		}
+		var text strings.Builder
+		encoder := json.NewEncoder(&text)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+		return toolResult{
+			Content:           []textContent{{Type: "text", Text: strings.TrimSuffix(text.String(), "\n")}},
+			StructuredContent: value,
+		}, nil
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+}
+
+// decodeArgs decodes tool arguments into v
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// clampLimit applies the default to an unset limit and caps it at maxLimit
+func clampLimit(limit, fallback int) int {
+	if limit <= 0 {
+		return fallback
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+func (s *Server) searchConversations(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		Query   string `json:"query"`
+		Limit   int    `json:"limit"`
+		Tool    string `json:"tool"`
+		Project string `json:"project"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(a.Query) == "" {
+		return nil, errors.New("query is required")
+	}
+
+	results, err := s.searcher.SearchWithFilters(a.Query, clampLimit(a.Limit, 10), map[string]interface{}{
+		"tool":    a.Tool,
+		"project": a.Project,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if results == nil {
+		results = []models.SearchResult{}
+	}
+	return map[string]interface{}{"results": results}, nil
+}
+
+// conversationPage is a conversation with one page of its messages
+type conversationPage struct {
+	*models.Conversation
+	Offset        int `json:"offset"`
+	TotalMessages int `json:"total_messages"`
+}
+
+func (s *Server) getConversation(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		ID     *int64 `json:"id"`
+		Offset int    `json:"offset"`
+		Limit  int    `json:"limit"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if a.ID == nil {
+		return nil, errors.New("id is required")
+	}
+
+	conv, err := s.store.GetConversation(*a.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("conversation %d not found", *a.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	total := len(conv.Messages)
+	start := min(max(a.Offset, 0), total)
+	end := min(start+clampLimit(a.Limit, 50), total)
+	conv.Messages = conv.Messages[start:end]
+	if conv.Messages == nil {
+		conv.Messages = []models.Message{}
+	}
+
+	return conversationPage{Conversation: conv, Offset: start, TotalMessages: total}, nil
+}
+
+func (s *Server) listRecent(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		Limit   int    `json:"limit"`
+		Tool    string `json:"tool"`
+		Project string `json:"project"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+
+	filter := make(map[string]string)
+	if a.Tool != "" {
+		filter["tool"] = a.Tool
+	}
+	if a.Project != "" {
+		filter["project"] = a.Project
+	}
+	conversations, err := s.store.ListConversations(clampLimit(a.Limit, 10), 0, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	if conversations == nil {
+		conversations = []models.Conversation{}
+	}
+	return map[string]interface{}{"conversations": conversations}, nil
+}
+
+func (s *Server) findByFile(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		Path  string `json:"path"`
+		Limit int    `json:"limit"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(a.Path) == "" {
+		return nil, errors.New("path is required")
+	}
+
+	results, err := s.searcher.FindByFile(a.Path, clampLimit(a.Limit, 10))
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if results == nil {
+		results = []models.FileConversation{}
+	}
+	return map[string]interface{}{"results": results}, nil
+}
+
+func (s *Server) saveNote(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		Content string   `json:"content"`
+		Title   string   `json:"title"`
+		Project string   `json:"project"`
+		Tags    []string `json:"tags"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(a.Content)
+	if content == "" {
+		return nil, errors.New("content is required")
+	}
+
+	title := strings.TrimSpace(a.Title)
+	if title == "" {
+		title = noteTitle(content)
+	}
+
+	now := time.Now()
+	conv := &models.Conversation{
+		Title:     title,
+		Tool:      NoteTool,
+		Project:   a.Project,
+		Tags:      a.Tags,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Messages: []models.Message{{
+			Role:       "assistant",
+			Content:    content,
+			Timestamp:  now,
+			TokenCount: capture.NewSimpleTokenEstimator().EstimateTokens(content),
+		}},
+	}
+	if err := s.store.SaveConversation(conv); err != nil {
+		return nil, fmt.Errorf("failed to save note: %w", err)
+	}
+
+	return map[string]interface{}{
+		"id":    conv.ID,
+		"title": conv.Title,
+		"uri":   fmt.Sprintf("%s%d", conversationURI, conv.ID),
+	}, nil
+}
+
+// noteTitle makes a title from the first line of a note, cut to 80 bytes
+// without splitting a character
+func noteTitle(content string) string {
+	title, _, _ := strings.Cut(content, "\n")
+	if len(title) <= 80 {
+		return title
+	}
+	end := 77
+	for end > 0 && !utf8.RuneStart(title[end]) {
+		end--
+	}
+	return strings.TrimSpace(title[:end]) + "..."
+}
+
+// transcript renders a conversation as Markdown
+func transcript(conv *models.Conversation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", conv.Title)
+	fmt.Fprintf(&b, "- Tool: %s\n", conv.Tool)
+	if conv.Project != "" {
+		fmt.Fprintf(&b, "- Project: %s\n", conv.Project)
+	}
+	if len(conv.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(conv.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "- Created: %s\n", conv.CreatedAt.Format(time.RFC3339))
+
+	for _, msg := range conv.Messages {
+		fmt.Fprintf(&b, "\n## %s", msg.Role)
+		if !msg.Timestamp.IsZero() {
+			fmt.Fprintf(&b, " (%s)", msg.Timestamp.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Fprintf(&b, "\n\n%s\n", strings.TrimSpace(msg.Content))
+	}
+	return b.String()
+}
\ No newline at end of file
@@ -11,6 +11,8 @@ type Conversation struct {
 	Project     string    `json:"project"`
 	ProjectID   int64     `json:"project_id,omitempty"`
 	ProjectPath string    `json:"project_path,omitempty"`
+	WorkDir     string    `json:"work_dir,omitempty"` // Directory the session ran in
+	IngestedTo  int64     `json:"-"`                  // How far into its session file the capture daemon has written it
 	Tags        []string  `json:"tags"`
 	SessionID   string    `json:"session_id,omitempty"`
 	SourcePath  string    `json:"source_path,omitempty"`
@@ -28,6 +30,23 @@ type Message struct {
 	Content        string    `json:"content"`
 	Timestamp      time.Time `json:"timestamp"`
 	TokenCount     int       `json:"token_count,omitempty"`
+	Files          []FileRef `json:"files,omitempty"`
+}
+
+// Kinds of access a conversation has to a file
+const (
+	FileRead    = "read"
+	FileEdit    = "edit"
+	FileWrite   = "write"
+	FileCommand = "command" // named in a shell command
+	FileMention = "mention" // named in message text
+)
+
+// FileRef is a file a message works on or mentions. Path is relative to the
+// session's working directory for files inside it.
+type FileRef struct {
+	Path   string `json:"path"`
+	Access string `json:"access"`
 }
 
 type SearchResult struct {
@@ -36,6 +55,92 @@ type SearchResult struct {
 	Score        float64      `json:"score"`
 }
 
+// MessageMatch is a message found by a search, with its conversation. Score
+// is the bm25 rank, lower for better matches.
+type MessageMatch struct {
+	Conversation Conversation `json:"conversation"`
+	Message      Message      `json:"message"`
+	Score        float64      `json:"score"`
+}
+
+// CodeSnippet is a fenced code block of an assistant message. StartLine and
+// EndLine are the lines of the code within the message, counting from 1.
+type CodeSnippet struct {
+	ID                int64  `json:"id"`
+	ConversationID    int64  `json:"conversation_id"`
+	MessageID         int64  `json:"message_id"`
+	Language          string `json:"language"`
+	Code              string `json:"code"`
+	StartLine         int    `json:"start_line"`
+	EndLine           int    `json:"end_line"`
+	ConversationTitle string `json:"conversation_title"`
+}
+
+// ConversationFile is a file a conversation worked on, with how many of its
+// messages accessed the file each way
+type ConversationFile struct {
+	ConversationID int64          `json:"conversation_id"`
+	Path           string         `json:"path"`
+	Access         map[string]int `json:"access"`
+	LastAt         time.Time      `json:"last_at"`
+}
+
+// FileConversation is a conversation that worked on the files matching a path
+type FileConversation struct {
+	Conversation Conversation       `json:"conversation"`
+	Files        []ConversationFile `json:"files"`
+}
+
+// SessionSpan is a conversation with the times of its first and last message
+type SessionSpan struct {
+	Conversation Conversation `json:"conversation"`
+	Start        time.Time    `json:"start"`
+	End          time.Time    `json:"end"`
+	MessageCount int          `json:"message_count"`
+}
+
+// DayActivity counts the conversations with messages on a day and the
+// messages and tokens sent that day
+type DayActivity struct {
+	Date          string `json:"date"` // 2006-01-02
+	Conversations int    `json:"conversations"`
+	Messages      int    `json:"messages"`
+	Tokens        int    `json:"tokens"`
+}
+
+// Activity buckets messages by day and by hour of the day
+type Activity struct {
+	Days  []DayActivity `json:"days"`  // Days with messages, oldest first
+	Hours [24]int       `json:"hours"` // Messages sent in each hour of the day
+}
+
+// CommitLink ties a git commit to the conversation that produced it. Method
+// says how they were matched: by a Mem-Session trailer, by files the
+// conversation changed, or by time alone.
+type CommitLink struct {
+	Repo              string    `json:"repo"`
+	SHA               string    `json:"sha"`
+	ConversationID    int64     `json:"conversation_id"`
+	ConversationTitle string    `json:"conversation_title"`
+	SessionID         string    `json:"session_id,omitempty"`
+	Method            string    `json:"method"`
+	Score             float64   `json:"score"`
+	CommittedAt       time.Time `json:"committed_at"`
+	Subject           string    `json:"subject"`
+}
+
+// ShellCommand is a command run in an interactive shell, as recorded by the
+// hook mem shell-hook prints
+type ShellCommand struct {
+	ID         int64     `json:"id"`
+	Command    string    `json:"command"`
+	Dir        string    `json:"dir"`
+	ExitCode   int       `json:"exit_code"`
+	DurationMS int64     `json:"duration_ms"`
+	StartedAt  time.Time `json:"started_at"`
+	Shell      string    `json:"shell"`
+}
+
 type Project struct {
 	ID          int64  `json:"id"`
 	ProjectPath string `json:"project_path"`
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ProjectDirName returns the name Claude Code gives the project directory of
+// sessions started in dir: the path with every character other than a letter
+// or digit replaced by '-'
+func ProjectDirName(dir string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '-'
+	}, dir)
+}
+
+// IsWithin reports whether path is dir or a path below it
+func IsWithin(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Covers reports whether dir is the directory the conversation ran in or one
+// below it. Conversations captured before their working directory was
+// recorded match on their Claude Code project directory, which cannot tell
+// a subdirectory from a sibling whose name starts the same, and failing that
+// on their project name.
+func (c Conversation) Covers(dir string) bool {
+	if c.WorkDir != "" {
+		return IsWithin(dir, c.WorkDir)
+	}
+	if c.ProjectPath != "" {
+		name, project := ProjectDirName(dir), ProjectDirName(c.ProjectPath)
+		return name == project || strings.HasPrefix(name, project+"-")
+	}
+	if c.Project == "" {
+		return false
+	}
+	return filepath.Base(dir) == c.Project || strings.Contains(filepath.ToSlash(dir)+"/", "/"+c.Project+"/")
+}
\ No newline at end of file
@@ -12,6 +12,10 @@ import (
 
 type ClaudeScanner struct{}
 
+func init() {
+	Register(func() Scanner { return NewClaudeScanner() })
+}
+
 func NewClaudeScanner() *ClaudeScanner {
 	return &ClaudeScanner{}
 }
@@ -20,6 +24,16 @@ func (s *ClaudeScanner) Name() string {
 	return "Claude Code"
 }
 
+func (s *ClaudeScanner) Tool() string {
+	return "claude-code"
+}
+
+// SessionPattern matches the session files Claude Code keeps in
+// ~/.claude/projects/<encoded-path>/
+func (s *ClaudeScanner) SessionPattern() string {
+	return "*.jsonl"
+}
+
 func (s *ClaudeScanner) ScanPaths() []string {
 	home, err := GetHomeDir()
 	if err != nil {
@@ -67,7 +81,7 @@ func (s *ClaudeScanner) ScanForSessions() ([]SessionInfo, error) {
 
 					sessions = append(sessions, SessionInfo{
 						Path:        fullPath,
-						Tool:        "claude-code",
+						Tool:        s.Tool(),
 						ProjectName: projectName,
 						Size:        info.Size(),
 						ModTime:     info.ModTime().Format("2006-01-02 15:04"),
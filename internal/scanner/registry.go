@@ -0,0 +1,28 @@
+package scanner
+
+import "sync"
+
+var (
+	registryMu sync.Mutex
+	registry   []func() Scanner
+)
+
+// Register adds a scanner to the set used by mem scan and watched by the
+// daemon. Scanners register themselves from an init function.
+func Register(factory func() Scanner) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, factory)
+}
+
+// Registered returns a new instance of every registered scanner
+func Registered() []Scanner {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	scanners := make([]Scanner, 0, len(registry))
+	for _, factory := range registry {
+		scanners = append(scanners, factory())
+	}
+	return scanners
+}
\ No newline at end of file
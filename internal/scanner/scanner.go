@@ -11,7 +11,12 @@ import (
 
 type Scanner interface {
 	Name() string
+	// Tool returns the tool identifier stored with the scanner's sessions
+	Tool() string
+	// ScanPaths returns the directory trees holding session files
 	ScanPaths() []string
+	// SessionPattern returns the file name pattern of session files in ScanPaths
+	SessionPattern() string
 	ScanForSessions() ([]SessionInfo, error)
 	ParseSession(path string) (*models.Conversation, error)
 }
@@ -25,11 +30,14 @@ type SessionInfo struct {
 }
 
 type ScanResult struct {
-	Tool         string
-	SessionsFound int
-	Imported     int
-	Failed       int
-	Errors       []string
+	Tool          string   `json:"tool"`
+	SessionsFound int      `json:"sessions_found"`
+	Imported      int      `json:"imported"`
+	Skipped       int      `json:"skipped"` // already in the database
+	Failed        int      `json:"failed"`
+	Errors        []string `json:"errors,omitempty"`
+	// Sessions lists the session files a dry run would import
+	Sessions []string `json:"sessions,omitempty"`
 }
 
 func GetHomeDir() (string, error) {
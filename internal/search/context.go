@@ -0,0 +1,355 @@
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jasperwreed/ai-memory/internal/capture"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+const (
+	// contextCandidates bounds the messages ranked for a context pack
+	contextCandidates = 200
+	// contextConversations bounds the conversations read when there is no query
+	contextConversations = 20
+	// recencyHalfLife is how long it takes a message to lose half its recency
+	recencyHalfLife = 30 * 24 * time.Hour
+	// relevanceWeight is the share of relevance in the rank of a search match;
+	// recency makes up the rest
+	relevanceWeight = 0.7
+
+	// Tokens taken by the heading of a conversation and of a message
+	sourceOverhead  = 12
+	messageOverhead = 8
+	// minTrimmed is the smallest part of a message worth including
+	minTrimmed = 64
+)
+
+// ContextOptions chooses the past messages put into a context pack. Messages
+// come from the conversations in IDs, or else from those matching Query and
+// Filter; without a query the most recent conversations matching Filter are used.
+type ContextOptions struct {
+	Query  string
+	Filter storage.ConversationFilter
+	IDs    []int64
+	// Budget is the most tokens the pack may take
+	Budget int
+	// Now is the time recency is measured from
+	Now time.Time
+}
+
+// ContextPack is a selection of past messages that fits a token budget
+type ContextPack struct {
+	Query   string          `json:"query,omitempty"`
+	Budget  int             `json:"budget"`
+	Tokens  int             `json:"tokens"`
+	Sources []ContextSource `json:"sources"`
+}
+
+// ContextSource is a conversation quoted in a context pack
+type ContextSource struct {
+	ID        int64            `json:"id"`
+	Title     string           `json:"title"`
+	Tool      string           `json:"tool"`
+	Project   string           `json:"project"`
+	CreatedAt time.Time        `json:"created_at"`
+	Messages  []ContextMessage `json:"messages"`
+}
+
+// ContextMessage is a message quoted in a context pack. Trimmed messages end
+// early to fit the budget.
+type ContextMessage struct {
+	ID        int64     `json:"id"`
+	Role      string    `json:"role"`
+	Timestamp time.Time `json:"timestamp"`
+	Content   string    `json:"content"`
+	Trimmed   bool      `json:"trimmed,omitempty"`
+}
+
+// candidate is a message that may go into a context pack
+type candidate struct {
+	conv *models.Conversation
+	msg  models.Message
+	rank float64
+}
+
+// Context selects the most relevant and recent past messages that fit in
+// opts.Budget tokens, as estimated by estimator
+func (s *Searcher) Context(opts ContextOptions, estimator capture.TokenEstimator) (*ContextPack, error) {
+	candidates, err := s.contextCandidates(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].rank != candidates[j].rank {
+			return candidates[i].rank > candidates[j].rank
+		}
+		return candidates[i].msg.ID > candidates[j].msg.ID
+	})
+
+	pack := &ContextPack{Query: opts.Query, Budget: opts.Budget, Sources: []ContextSource{}}
+	sources := make(map[int64]int)
+	// overhead is what quoting a message of conv takes besides its content
+	overhead := func(conv *models.Conversation) int {
+		if _, quoted := sources[conv.ID]; quoted {
+			return messageOverhead
+		}
+		return messageOverhead + sourceOverhead + estimator.EstimateTokens(conv.Title)
+	}
+	best := make(map[int64]float64)
+	add := func(c candidate, msg ContextMessage, cost int) {
+		conv := c.conv
+		if rank, ok := best[conv.ID]; !ok || c.rank > rank {
+			best[conv.ID] = c.rank
+		}
+		index, quoted := sources[conv.ID]
+		if !quoted {
+			index = len(pack.Sources)
+			sources[conv.ID] = index
+			pack.Sources = append(pack.Sources, ContextSource{
+				ID:        conv.ID,
+				Title:     conv.Title,
+				Tool:      conv.Tool,
+				Project:   conv.Project,
+				CreatedAt: conv.CreatedAt,
+			})
+		}
+		pack.Sources[index].Messages = append(pack.Sources[index].Messages, msg)
+		pack.Tokens += cost
+	}
+
+	// Whole messages come first; the best one left out then fills what
+	// remains of the budget, cut short
+	var skipped []candidate
+	for _, c := range candidates {
+		cost := overhead(c.conv) + estimator.EstimateTokens(c.msg.Content)
+		if pack.Tokens+cost > opts.Budget {
+			skipped = append(skipped, c)
+			continue
+		}
+		add(c, ContextMessage{ID: c.msg.ID, Role: c.msg.Role, Timestamp: c.msg.Timestamp, Content: c.msg.Content}, cost)
+	}
+	for _, c := range skipped {
+		room := opts.Budget - pack.Tokens - overhead(c.conv)
+		if room < minTrimmed {
+			continue
+		}
+		content := trimToTokens(c.msg.Content, room, estimator)
+		if content == "" {
+			continue
+		}
+		msg := ContextMessage{ID: c.msg.ID, Role: c.msg.Role, Timestamp: c.msg.Timestamp, Content: content, Trimmed: true}
+		add(c, msg, overhead(c.conv)+estimator.EstimateTokens(content))
+		break
+	}
+
+	// Sources are in order of their best message; each reads in order
+	sort.SliceStable(pack.Sources, func(i, j int) bool {
+		return best[pack.Sources[i].ID] > best[pack.Sources[j].ID]
+	})
+	for i := range pack.Sources {
+		messages := pack.Sources[i].Messages
+		sort.SliceStable(messages, func(a, b int) bool {
+			if !messages[a].Timestamp.Equal(messages[b].Timestamp) {
+				return messages[a].Timestamp.Before(messages[b].Timestamp)
+			}
+			return messages[a].ID < messages[b].ID
+		})
+	}
+	return pack, nil
+}
+
+// contextCandidates returns the messages to choose from, ranked by relevance
+// and recency
+func (s *Searcher) contextCandidates(opts ContextOptions) ([]candidate, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if len(opts.IDs) == 0 && opts.Query != "" {
+		matches, err := s.store.SearchMessages(opts.Query, opts.Filter, contextCandidates)
+		if err != nil {
+			return nil, err
+		}
+
+		// bm25 is negative, and lower for better matches
+		best := 0.0
+		for _, m := range matches {
+			best = math.Min(best, m.Score)
+		}
+		convs := make(map[int64]*models.Conversation)
+		var candidates []candidate
+		for _, m := range matches {
+			if strings.TrimSpace(m.Message.Content) == "" {
+				continue
+			}
+			conv, ok := convs[m.Conversation.ID]
+			if !ok {
+				conv = &m.Conversation
+				convs[conv.ID] = conv
+			}
+			relevance := 0.0
+			if best < 0 {
+				relevance = m.Score / best
+			}
+			rank := relevanceWeight*relevance + (1-relevanceWeight)*recency(m.Message, conv, now)
+			candidates = append(candidates, candidate{conv: conv, msg: m.Message, rank: rank})
+		}
+		return candidates, nil
+	}
+
+	ids := opts.IDs
+	if len(ids) == 0 {
+		convs, err := s.store.ListConversationsPage(opts.Filter, 0, contextConversations)
+		if err != nil {
+			return nil, err
+		}
+		for _, conv := range convs {
+			ids = append(ids, conv.ID)
+		}
+	}
+
+	var candidates []candidate
+	for _, id := range ids {
+		conv, err := s.store.GetConversation(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("conversation %d not found", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation %d: %w", id, err)
+		}
+		for _, msg := range conv.Messages {
+			if strings.TrimSpace(msg.Content) == "" {
+				continue
+			}
+			candidates = append(candidates, candidate{conv: conv, msg: msg, rank: recency(msg, conv, now)})
+		}
+	}
+	return candidates, nil
+}
+
+// recency is 1 for a message sent now, halving every recencyHalfLife
+func recency(msg models.Message, conv *models.Conversation, now time.Time) float64 {
+	sent := msg.Timestamp
+	if sent.IsZero() {
+		sent = conv.CreatedAt
+	}
+	age := now.Sub(sent)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+}
+
+// trimToTokens shortens text to its first words that fit in tokens, keeping
+// its line breaks
+func trimToTokens(text string, tokens int, estimator capture.TokenEstimator) string {
+	// ends holds the offset after each word
+	var ends []int
+	inWord := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if inWord && space {
+			ends = append(ends, i)
+		}
+		inWord = !space
+	}
+	if inWord {
+		ends = append(ends, len(text))
+	}
+
+	words := sort.Search(len(ends), func(n int) bool {
+		return estimator.EstimateTokens(text[:ends[n]]) > tokens
+	})
+	if words == 0 {
+		return ""
+	}
+	return text[:ends[words-1]]
+}
+
+// Markdown renders the pack as a Markdown section. Every message cites its
+// source as [mem:<conversation>#<message>].
+func (p *ContextPack) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Context from past conversations\n")
+	for _, src := range p.Sources {
+		fmt.Fprintf(&b, "\n## %s\n\n", src.Title)
+		fmt.Fprintf(&b, "_%s_\n", strings.Join(src.details(), " · "))
+		for _, msg := range src.Messages {
+			fmt.Fprintf(&b, "\n**%s** [mem:%d#%d]", roleName(msg.Role), src.ID, msg.ID)
+			if !msg.Timestamp.IsZero() {
+				fmt.Fprintf(&b, " %s", msg.Timestamp.Format("2006-01-02 15:04"))
+			}
+			b.WriteString("\n\n" + strings.TrimSpace(msg.Content))
+			if msg.Trimmed {
+				b.WriteString(" […]")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// XML renders the pack as a <context> element, for prompts that separate
+// sources with tags
+func (p *ContextPack) XML() string {
+	var b strings.Builder
+	b.WriteString("<context source=\"mem\"")
+	if p.Query != "" {
+		fmt.Fprintf(&b, " query=\"%s\"", xmlEscape(p.Query))
+	}
+	b.WriteString(">\n")
+	for _, src := range p.Sources {
+		fmt.Fprintf(&b, "<conversation id=\"%d\" title=\"%s\" tool=\"%s\"", src.ID, xmlEscape(src.Title), xmlEscape(src.Tool))
+		if src.Project != "" {
+			fmt.Fprintf(&b, " project=\"%s\"", xmlEscape(src.Project))
+		}
+		fmt.Fprintf(&b, " date=\"%s\">\n", src.CreatedAt.Format("2006-01-02"))
+		for _, msg := range src.Messages {
+			fmt.Fprintf(&b, "<message id=\"%d\" role=\"%s\" cite=\"mem:%d#%d\"", msg.ID, xmlEscape(msg.Role), src.ID, msg.ID)
+			if !msg.Timestamp.IsZero() {
+				fmt.Fprintf(&b, " time=\"%s\"", msg.Timestamp.Format(time.RFC3339))
+			}
+			if msg.Trimmed {
+				b.WriteString(" trimmed=\"true\"")
+			}
+			fmt.Fprintf(&b, ">\n%s\n</message>\n", xmlEscape(strings.TrimSpace(msg.Content)))
+		}
+		b.WriteString("</conversation>\n")
+	}
+	b.WriteString("</context>\n")
+	return b.String()
+}
+
+// details describes where a source comes from
+func (src ContextSource) details() []string {
+	details := []string{src.Tool}
+	if src.Project != "" {
+		details = append(details, src.Project)
+	}
+	return append(details, src.CreatedAt.Format("2006-01-02"))
+}
+
+func roleName(role string) string {
+	if role == "" {
+		return "Message"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// xmlEscaper escapes text and attribute values but keeps line breaks readable
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(s)
+}
\ No newline at end of file
@@ -0,0 +1,118 @@
+package search
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/capture"
+	"github.com/jasperwreed/ai-memory/internal/models"
+	"github.com/jasperwreed/ai-memory/internal/storage"
+)
+
+var contextNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newContextSearcher(t *testing.T) *Searcher {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	for _, c := range []struct {
+		title, project string
+		age            time.Duration
+		messages       []string
+	}{
+		{"Old migration work", "api", 180 * 24 * time.Hour, []string{
+			"How should the migration handle locks?",
+			"Take an advisory <lock> before the migration & release it after",
+		}},
+		{"Recent migration work", "api", 24 * time.Hour, []string{
+			"The migration failed again",
+			"Run the migration with --verbose",
+		}},
+		{"Unrelated", "web", time.Hour, []string{
+			"Center the logo",
+			strings.Repeat("flexbox grid layout ", 200),
+		}},
+	} {
+		created := contextNow.Add(-c.age)
+		conv := &models.Conversation{Title: c.title, Tool: "claude-code", Project: c.project, CreatedAt: created, UpdatedAt: created}
+		for i, content := range c.messages {
+			role := "user"
+			if i%2 == 1 {
+				role = "assistant"
+			}
+			conv.Messages = append(conv.Messages, models.Message{
+				Role:      role,
+				Content:   content,
+				Timestamp: created.Add(time.Duration(i) * time.Minute),
+			})
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewSearcher(store)
+}
+
+func TestContext(t *testing.T) {
+	s := newContextSearcher(t)
+	estimator := capture.NewSimpleTokenEstimator()
+
+	pack, err := s.Context(ContextOptions{Query: "migration", Budget: 1000, Now: contextNow}, estimator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pack.Sources) != 2 || pack.Sources[0].Title != "Recent migration work" {
+		t.Fatalf("Expected the recent conversation first, got %+v", pack.Sources)
+	}
+	if msgs := pack.Sources[1].Messages; len(msgs) != 2 || msgs[0].Timestamp.After(msgs[1].Timestamp) {
+		t.Errorf("Expected both messages in order, got %+v", msgs)
+	}
+
+	md := pack.Markdown()
+	if !strings.Contains(md, "## Recent migration work") || !strings.Contains(md, "[mem:2#3]") {
+		t.Errorf("Expected headings and citations, got:\n%s", md)
+	}
+	xml := pack.XML()
+	if !strings.Contains(xml, `<context source="mem" query="migration">`) ||
+		!strings.Contains(xml, "advisory &lt;lock&gt; before the migration &amp; release") {
+		t.Errorf("Expected escaped XML, got:\n%s", xml)
+	}
+
+	// A budget for one conversation keeps the best one
+	pack, _ = s.Context(ContextOptions{Query: "migration", Budget: 40, Now: contextNow}, estimator)
+	if len(pack.Sources) != 1 || pack.Sources[0].Title != "Recent migration work" || pack.Tokens > 40 {
+		t.Errorf("Expected only the recent conversation within 40 tokens, got %d tokens %+v", pack.Tokens, pack.Sources)
+	}
+}
+
+func TestContextTrims(t *testing.T) {
+	s := newContextSearcher(t)
+	estimator := capture.NewSimpleTokenEstimator()
+
+	pack, err := s.Context(ContextOptions{Filter: storage.ConversationFilter{Project: "web"}, Budget: 200, Now: contextNow}, estimator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pack.Tokens > 200 {
+		t.Errorf("Pack takes %d tokens, over its budget of 200", pack.Tokens)
+	}
+	if len(pack.Sources) != 1 || len(pack.Sources[0].Messages) != 2 {
+		t.Fatalf("Expected both web messages, got %+v", pack.Sources)
+	}
+	long := pack.Sources[0].Messages[1]
+	if !long.Trimmed || !strings.HasPrefix(long.Content, "flexbox grid layout") || estimator.EstimateTokens(long.Content) > 200 {
+		t.Errorf("Expected the long message trimmed, got %d tokens", estimator.EstimateTokens(long.Content))
+	}
+
+	// Chosen conversations are used whatever their age
+	pack, _ = s.Context(ContextOptions{IDs: []int64{1}, Budget: 1000, Now: contextNow}, estimator)
+	if len(pack.Sources) != 1 || pack.Sources[0].ID != 1 {
+		t.Errorf("Expected conversation 1, got %+v", pack.Sources)
+	}
+}
\ No newline at end of file
@@ -17,31 +17,25 @@ func (s *Searcher) Search(query string, limit int) ([]models.SearchResult, error
 	return s.store.Search(query, limit)
 }
 
+// SearchWithFilters searches conversations whose tool, project or tag match
+// the string values of filters under those keys; other values are ignored
 func (s *Searcher) SearchWithFilters(query string, limit int, filters map[string]interface{}) ([]models.SearchResult, error) {
-	results, err := s.store.Search(query, limit)
-	if err != nil {
-		return nil, err
-	}
-
-	if tool, ok := filters["tool"].(string); ok && tool != "" {
-		filtered := []models.SearchResult{}
-		for _, r := range results {
-			if r.Conversation.Tool == tool {
-				filtered = append(filtered, r)
-			}
-		}
-		results = filtered
-	}
-
-	if project, ok := filters["project"].(string); ok && project != "" {
-		filtered := []models.SearchResult{}
-		for _, r := range results {
-			if r.Conversation.Project == project {
-				filtered = append(filtered, r)
-			}
-		}
-		results = filtered
-	}
-
-	return results, nil
+	var filter storage.ConversationFilter
+	filter.Tool, _ = filters["tool"].(string)
+	filter.Project, _ = filters["project"].(string)
+	filter.Tag, _ = filters["tag"].(string)
+
+	return s.store.SearchPage(query, filter, 0, limit)
+}
+
+// SearchPage searches conversations matching filter, skipping the first offset results
+func (s *Searcher) SearchPage(query string, filter storage.ConversationFilter, offset, limit int) ([]models.SearchResult, error) {
+	return s.store.SearchPage(query, filter, offset, limit)
+}
+
+// FindByFile returns conversations that read, edited or mentioned path, with
+// the files they touched, from the index of files each conversation worked
+// on. A partial path such as daemon/wal.go matches any path ending in it.
+func (s *Searcher) FindByFile(path string, limit int) ([]models.FileConversation, error) {
+	return s.store.FileConversations(path, storage.ConversationFilter{}, nil, limit)
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/jasperwreed/ai-memory/internal/models"
 	"github.com/jasperwreed/ai-memory/internal/storage"
 )
 
@@ -104,4 +105,41 @@ func TestSearcher_SearchWithFilters_InvalidTypes(t *testing.T) {
 	if len(results) != 0 {
 		t.Errorf("SearchWithFilters() with invalid filters should return 0 results from empty db, got %d", len(results))
 	}
+}
+
+func TestSearcher_FindByFile(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	save := func(title string, files ...models.FileRef) {
+		conv := &models.Conversation{Title: title, Tool: "claude-code"}
+		for _, file := range files {
+			conv.Messages = append(conv.Messages, models.Message{
+				Role:    "assistant",
+				Content: "[Used tool: " + file.Access + " " + file.Path + "]",
+				Files:   []models.FileRef{file},
+			})
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("wal", models.FileRef{Path: "/src/internal/daemon/wal.go", Access: models.FileRead},
+		models.FileRef{Path: "/src/internal/daemon/wal.go", Access: models.FileEdit})
+	save("other", models.FileRef{Path: "/src/internal/daemon/daemon.go", Access: models.FileRead})
+	// Conversations are found from the file index, not from message text
+	store.SaveConversation(&models.Conversation{Title: "prose", Tool: "claude-code",
+		Messages: []models.Message{{Role: "user", Content: "what does daemon/wal.go do?"}}})
+
+	searcher := NewSearcher(store)
+	results, err := searcher.FindByFile("daemon/wal.go", 10)
+	if err != nil {
+		t.Fatalf("FindByFile() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Conversation.Title != "wal" || len(results[0].Files) != 1 {
+		t.Errorf("FindByFile() = %+v, want only the wal conversation once", results)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// Activity buckets the messages of conversations matching filter sent since a
+// given time by day and by hour of the day in loc. Messages without a time
+// count as sent when their conversation was created.
+func (s *SQLiteStore) Activity(filter ConversationFilter, since time.Time, loc *time.Location) (*models.Activity, error) {
+	conds, args := filter.where()
+	query := querySelectActivity + conds
+	if !since.IsZero() {
+		query += queryActivitySince
+		args = append(args, since.UTC())
+	}
+	rows, err := s.readDB.Query(query+queryActivityGroup, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Zones are whole quarter hours off UTC, so the quarter hours SQLite counts
+	// each fall within one day and hour in loc
+	days := make(map[string]*models.DayActivity)
+	active := make(map[string]map[int64]bool)
+	activity := &models.Activity{Days: []models.DayActivity{}}
+	for rows.Next() {
+		var convID, quarter int64
+		var messages, tokens int
+		if err := rows.Scan(&convID, &quarter, &messages, &tokens); err != nil {
+			return nil, err
+		}
+
+		at := time.Unix(quarter*15*60, 0).In(loc)
+		date := at.Format("2006-01-02")
+		day, ok := days[date]
+		if !ok {
+			day = &models.DayActivity{Date: date}
+			days[date] = day
+			active[date] = make(map[int64]bool)
+		}
+		day.Messages += messages
+		day.Tokens += tokens
+		if !active[date][convID] {
+			active[date][convID] = true
+			day.Conversations++
+		}
+		activity.Hours[at.Hour()] += messages
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, day := range days {
+		activity.Days = append(activity.Days, *day)
+	}
+	sort.Slice(activity.Days, func(i, j int) bool {
+		return activity.Days[i].Date < activity.Days[j].Date
+	})
+	return activity, nil
+}
\ No newline at end of file
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func TestActivity(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := time.Date(2025, 6, 1, 22, 30, 0, 0, time.UTC)
+	save := func(project string, offsets ...time.Duration) {
+		conv := &models.Conversation{Title: "Work", Tool: "claude-code", Project: project, CreatedAt: base, UpdatedAt: base}
+		for _, offset := range offsets {
+			msg := models.Message{Role: "user", Content: "Hello", TokenCount: 10}
+			if offset != -1 {
+				msg.Timestamp = base.Add(offset)
+			}
+			conv.Messages = append(conv.Messages, msg)
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A message without a time counts at creation
+	save("api", -48*time.Hour, 0, time.Hour, -1)
+	save("web", 10*time.Minute)
+
+	// Two hours east, 22:30 UTC is 00:30 on the next day
+	east := time.FixedZone("EET", 2*60*60)
+	activity, err := store.Activity(ConversationFilter{}, base.Add(-time.Hour), east)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.DayActivity{
+		{Date: "2025-06-02", Conversations: 2, Messages: 4, Tokens: 40},
+	}
+	if len(activity.Days) != len(expected) || activity.Days[0] != expected[0] {
+		t.Errorf("Days = %+v, want %+v", activity.Days, expected)
+	}
+	if activity.Hours[0] != 3 || activity.Hours[1] != 1 {
+		t.Errorf("Unexpected hours %v", activity.Hours)
+	}
+
+	activity, err = store.Activity(ConversationFilter{Project: "api"}, time.Time{}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []models.DayActivity{
+		{Date: "2025-05-30", Conversations: 1, Messages: 1, Tokens: 10},
+		{Date: "2025-06-01", Conversations: 1, Messages: 3, Tokens: 30},
+	}
+	if len(activity.Days) != len(expected) {
+		t.Fatalf("Days = %+v, want %+v", activity.Days, expected)
+	}
+	for i := range expected {
+		if activity.Days[i] != expected[i] {
+			t.Errorf("Days[%d] = %+v, want %+v", i, activity.Days[i], expected[i])
+		}
+	}
+
+	// Half an hour off the hour, 22:40 UTC is 04:10 in India
+	india := time.FixedZone("IST", 5*60*60+30*60)
+	activity, err = store.Activity(ConversationFilter{Project: "web"}, time.Time{}, india)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity.Days) != 1 || activity.Days[0].Date != "2025-06-02" || activity.Hours[4] != 1 {
+		t.Errorf("Unexpected activity %+v", activity)
+	}
+}
\ No newline at end of file
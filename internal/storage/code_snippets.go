@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// metaSnippetsIndexed marks databases whose older messages have had their code
+// blocks indexed
+const metaSnippetsIndexed = "code_snippets_indexed"
+
+// codeBlock is a fenced code block found in a message
+type codeBlock struct {
+	language  string
+	code      string
+	startLine int
+	endLine   int
+}
+
+// languageAliases maps common short names of languages to one name
+var languageAliases = map[string]string{
+	"golang": "go",
+	"js":     "javascript",
+	"jsx":    "javascript",
+	"ts":     "typescript",
+	"tsx":    "typescript",
+	"py":     "python",
+	"rb":     "ruby",
+	"rs":     "rust",
+	"sh":     "shell",
+	"bash":   "shell",
+	"zsh":    "shell",
+	"yml":    "yaml",
+	"c++":    "cpp",
+}
+
+// NormalizeLanguage returns the name code snippets are stored under for a
+// language given in a code fence or a filter
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	language = strings.TrimPrefix(strings.Trim(language, "{}"), ".")
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	return language
+}
+
+// extractCodeBlocks returns the fenced code blocks of Markdown content. Fences
+// may be indented, as in lists, and the indentation is removed from the code.
+// A block left open runs to the end of the content. Empty blocks are skipped.
+func extractCodeBlocks(content string) []codeBlock {
+	lines := strings.Split(content, "\n")
+
+	var blocks []codeBlock
+	for i := 0; i < len(lines); i++ {
+		indent, fence, info, ok := openingFence(lines[i])
+		if !ok {
+			continue
+		}
+
+		start := i + 1
+		end := len(lines)
+		for j := start; j < len(lines); j++ {
+			if isClosingFence(lines[j], fence) {
+				end = j
+				break
+			}
+		}
+
+		code := make([]string, 0, end-start)
+		for _, line := range lines[start:end] {
+			code = append(code, strings.TrimPrefix(line, indent))
+		}
+		i = end
+
+		text := strings.TrimRight(strings.Join(code, "\n"), " \t\r\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		var language string
+		if fields := strings.Fields(info); len(fields) > 0 {
+			language = NormalizeLanguage(fields[0])
+		}
+		blocks = append(blocks, codeBlock{
+			language:  language,
+			code:      text,
+			startLine: start + 1,
+			endLine:   start + strings.Count(text, "\n") + 1,
+		})
+	}
+	return blocks
+}
+
+// openingFence parses a line opening a code block: three or more backticks or
+// tildes, followed by an info string naming the language
+func openingFence(line string) (indent, fence, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent = line[:len(line)-len(trimmed)]
+	for _, marker := range []string{"```", "~~~"} {
+		if !strings.HasPrefix(trimmed, marker) {
+			continue
+		}
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, marker[:1]))
+		info = strings.TrimSpace(trimmed[n:])
+		// A backtick fence cannot have backticks in its info string
+		if marker == "```" && strings.Contains(info, "`") {
+			return "", "", "", false
+		}
+		return indent, trimmed[:n], info, true
+	}
+	return "", "", "", false
+}
+
+// isClosingFence reports whether line closes a block opened with fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// insertCodeSnippetsTx indexes the code blocks of an assistant message within tx
+func (s *SQLiteStore) insertCodeSnippetsTx(tx *sql.Tx, convID int64, msg models.Message) error {
+	if msg.Role != "assistant" {
+		return nil
+	}
+	for _, block := range extractCodeBlocks(msg.Content) {
+		result, err := tx.Exec(queryInsertCodeSnippet,
+			convID, msg.ID, block.language, s.cipher.seal(block.code), block.startLine, block.endLine)
+		if err != nil {
+			return fmt.Errorf("failed to save code snippet: %w", err)
+		}
+		if s.cipher != nil {
+			id, _ := result.LastInsertId()
+			if _, err := tx.Exec(queryInsertCodeSnippetBlindTokens, id, s.cipher.blindText(block.code)); err != nil {
+				return fmt.Errorf("failed to index code snippet: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// indexOlderSnippets indexes the code blocks of messages stored before code
+// snippets were, once per database
+func (s *SQLiteStore) indexOlderSnippets() error {
+	done, err := s.meta(metaSnippetsIndexed)
+	if err != nil || done != "" {
+		return err
+	}
+
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Another process may have indexed them since
+	if err := tx.QueryRow(querySelectMeta, metaSnippetsIndexed).Scan(&done); err == nil {
+		return nil
+	}
+
+	// Messages are read in full first; the single write connection is busy
+	// with the transaction once inserts start
+	rows, err := tx.Query(querySelectAssistantMessages)
+	if err != nil {
+		return fmt.Errorf("failed to read messages: %w", err)
+	}
+	var messages []models.Message
+	for rows.Next() {
+		msg := models.Message{Role: "assistant"}
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Content); err != nil {
+			rows.Close()
+			return err
+		}
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if msg.Content, err = s.cipher.open(msg.Content); err != nil {
+			return fmt.Errorf("failed to decrypt message %d: %w", msg.ID, err)
+		}
+		if err := s.insertCodeSnippetsTx(tx, msg.ConversationID, msg); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(queryUpsertMeta, metaSnippetsIndexed, "1"); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SearchCodeSnippets returns up to limit code snippets matching a full-text
+// query, best matches first. A language, if given, restricts the results to it.
+func (s *SQLiteStore) SearchCodeSnippets(query, language string, limit int) ([]models.CodeSnippet, error) {
+	sqlQuery, rank := querySearchCodeSnippets, "bm25(code_snippets_fts)"
+	match := query
+	if s.cipher != nil {
+		sqlQuery, rank = querySearchCodeSnippetsBlind, "bm25(code_snippets_blind_fts)"
+		match = s.cipher.blindQuery(query)
+	}
+	args := []interface{}{match}
+	if language != "" {
+		sqlQuery += " AND s.language = ?"
+		args = append(args, NormalizeLanguage(language))
+	}
+	sqlQuery += " ORDER BY " + rank + ", s.id DESC LIMIT ?"
+	args = append(args, limit)
+	return s.queryCodeSnippets(sqlQuery, args...)
+}
+
+// GetCodeSnippet returns a code snippet by ID
+func (s *SQLiteStore) GetCodeSnippet(id int64) (*models.CodeSnippet, error) {
+	snippets, err := s.queryCodeSnippets(querySelectCodeSnippets+" WHERE s.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(snippets) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &snippets[0], nil
+}
+
+// ConversationCodeSnippets returns the code snippets of a conversation in the
+// order they appear
+func (s *SQLiteStore) ConversationCodeSnippets(convID int64) ([]models.CodeSnippet, error) {
+	return s.queryCodeSnippets(querySelectCodeSnippets+" WHERE s.conversation_id = ? ORDER BY s.message_id, s.start_line", convID)
+}
+
+func (s *SQLiteStore) queryCodeSnippets(query string, args ...interface{}) ([]models.CodeSnippet, error) {
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []models.CodeSnippet{}
+	for rows.Next() {
+		var snippet models.CodeSnippet
+		err := rows.Scan(&snippet.ID, &snippet.ConversationID, &snippet.MessageID, &snippet.Language,
+			&snippet.Code, &snippet.StartLine, &snippet.EndLine, &snippet.ConversationTitle)
+		if err != nil {
+			return nil, err
+		}
+		if snippet.Code, err = s.cipher.open(snippet.Code); err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, snippet)
+	}
+	return snippets, rows.Err()
+}
\ No newline at end of file
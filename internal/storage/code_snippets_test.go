@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+const codeAnswer = "Use a handler:\n\n" +
+	"```golang\n" +
+	"http.HandleFunc(\"/health\", func(w http.ResponseWriter, r *http.Request) {\n" +
+	"\tw.WriteHeader(200)\n" +
+	"})\n" +
+	"```\n\n" +
+	"Then run:\n\n" +
+	"1. Start it\n" +
+	"   ~~~sh\n" +
+	"   go run ./cmd/server\n" +
+	"   ~~~\n" +
+	"```\n\n```\n"
+
+func TestExtractCodeBlocks(t *testing.T) {
+	blocks := extractCodeBlocks(codeAnswer)
+	if len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %+v", blocks)
+	}
+	if b := blocks[0]; b.language != "go" || b.startLine != 4 || b.endLine != 6 || b.code[:16] != "http.HandleFunc(" {
+		t.Errorf("Unexpected first block %+v", b)
+	}
+	if b := blocks[1]; b.language != "shell" || b.code != "go run ./cmd/server" || b.startLine != 13 || b.endLine != 13 {
+		t.Errorf("Unexpected indented block %+v", b)
+	}
+
+	// A block left open runs to the end; inline code is not a block
+	blocks = extractCodeBlocks("Run `make`:\n```python\nprint(1)\n")
+	if len(blocks) != 1 || blocks[0].code != "print(1)" || blocks[0].language != "python" {
+		t.Errorf("Unexpected blocks %+v", blocks)
+	}
+}
+
+func codeConversation(sessionID string) *models.Conversation {
+	return &models.Conversation{
+		Title:     "Health check handler",
+		Tool:      "claude-code",
+		SessionID: sessionID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Messages: []models.Message{
+			{Role: "user", Content: "```go\nuserPastedCode()\n```", Timestamp: time.Now()},
+			{Role: "assistant", Content: codeAnswer, Timestamp: time.Now()},
+		},
+	}
+}
+
+func TestCodeSnippets(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	conv := codeConversation("s1")
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatal(err)
+	}
+
+	snippets, err := store.SearchCodeSnippets("HandleFunc", "", 10)
+	if err != nil || len(snippets) != 1 {
+		t.Fatalf("Expected 1 snippet, got %d (%v)", len(snippets), err)
+	}
+	s := snippets[0]
+	if s.ConversationID != conv.ID || s.MessageID != conv.Messages[1].ID || s.ConversationTitle != conv.Title {
+		t.Errorf("Unexpected snippet %+v", s)
+	}
+	if got, _ := store.SearchCodeSnippets("HandleFunc", "golang", 10); len(got) != 1 {
+		t.Error("Expected the language filter to accept aliases")
+	}
+	if got, _ := store.SearchCodeSnippets("HandleFunc", "python", 10); len(got) != 0 {
+		t.Error("Expected the language filter to exclude other languages")
+	}
+	if got, _ := store.SearchCodeSnippets("userPastedCode", "", 10); len(got) != 0 {
+		t.Error("Expected only assistant code to be indexed")
+	}
+	if got, err := store.GetCodeSnippet(s.ID); err != nil || got.Code != s.Code {
+		t.Errorf("GetCodeSnippet() = %+v, %v", got, err)
+	}
+
+	// Replacing or deleting a conversation replaces or deletes its snippets
+	if _, err := store.ReplaceConversationBySessionID(codeConversation("s1")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.ConversationCodeSnippets(conv.ID); len(got) != 2 || got[0].ID == s.ID {
+		t.Errorf("Expected 2 new snippets after replacing, got %+v", got)
+	}
+	store.DeleteConversation(conv.ID)
+	if got, _ := store.SearchCodeSnippets("HandleFunc", "", 10); len(got) != 0 {
+		t.Errorf("Expected deleted snippets to be gone from the index, got %d", len(got))
+	}
+}
+
+func TestIndexOlderSnippets(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SaveConversation(codeConversation("s1"))
+
+	// A database from before snippets has messages but none indexed
+	store.writeDB.Exec(`DELETE FROM code_snippets`)
+	store.writeDB.Exec(queryDeleteMeta, metaSnippetsIndexed)
+	store.Close()
+
+	for i := 0; i < 2; i++ {
+		store, err = NewSQLiteStore(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := store.SearchCodeSnippets("run", "shell", 10); len(got) != 1 {
+			t.Errorf("Open %d: expected 1 indexed snippet, got %d", i+1, len(got))
+		}
+		store.Close()
+	}
+}
+
+func TestEncryptedCodeSnippets(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "enc.db")
+	key := testCipher(t, "correct horse")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SaveConversation(codeConversation("s1"))
+	store.Close()
+
+	if _, err := EncryptDatabase(dbPath, key); err != nil {
+		t.Fatal(err)
+	}
+	if fileContains(t, dbPath, "ResponseWriter") {
+		t.Error("Plaintext code left in database after encrypting")
+	}
+
+	store, err = NewEncryptedSQLiteStore(dbPath, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SaveConversation(codeConversation("s2"))
+	snippets, err := store.SearchCodeSnippets("ResponseWriter", "go", 10)
+	if err != nil || len(snippets) != 2 || snippets[0].Code[:16] != "http.HandleFunc(" {
+		t.Errorf("Expected 2 decrypted snippets, got %+v (%v)", snippets, err)
+	}
+	store.Close()
+
+	if _, err := DecryptDatabase(dbPath, key); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if got, _ := store.SearchCodeSnippets("ResponseWriter", "", 10); len(got) != 2 {
+		t.Errorf("Expected 2 snippets after decrypting, got %d", len(got))
+	}
+}
\ No newline at end of file
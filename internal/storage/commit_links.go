@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// SaveCommitLinks records which conversations commits came from, replacing
+// earlier links of the same commits
+func (s *SQLiteStore) SaveCommitLinks(links []models.CommitLink) error {
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, l := range links {
+		if _, err := tx.Exec(queryUpsertCommitLink, l.Repo, l.SHA, l.ConversationID, l.Method, l.Score, l.CommittedAt.UTC(), l.Subject); err != nil {
+			return fmt.Errorf("failed to save link of commit %s: %w", l.SHA, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCommitLink returns the link of a commit in a repository, or sql.ErrNoRows
+// if it has none
+func (s *SQLiteStore) GetCommitLink(repo, sha string) (*models.CommitLink, error) {
+	links, err := s.queryCommitLinks(querySelectCommitLinks+" WHERE l.repo = ? AND l.sha = ?", repo, sha)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &links[0], nil
+}
+
+// ConversationCommits returns the commits linked to a conversation, oldest first
+func (s *SQLiteStore) ConversationCommits(convID int64) ([]models.CommitLink, error) {
+	return s.queryCommitLinks(querySelectCommitLinks+" WHERE l.conversation_id = ? ORDER BY l.committed_at, l.sha", convID)
+}
+
+func (s *SQLiteStore) queryCommitLinks(query string, args ...interface{}) ([]models.CommitLink, error) {
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.CommitLink{}
+	for rows.Next() {
+		var l models.CommitLink
+		err := rows.Scan(&l.Repo, &l.SHA, &l.ConversationID, &l.ConversationTitle, &l.SessionID,
+			&l.Method, &l.Score, localTime(&l.CommittedAt), &l.Subject)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
\ No newline at end of file
@@ -28,12 +28,14 @@ func DefaultConfig() *Config {
 
 // pragmas returns SQLite PRAGMA statements based on configuration
 func (c *Config) pragmas() []string {
+	// The busy timeout comes first so switching to WAL waits for other
+	// processes opening the database, such as the shell hook, instead of failing
 	return []string{
+		"PRAGMA busy_timeout = " + formatMilliseconds(c.BusyTimeout),
 		"PRAGMA journal_mode = WAL",
 		"PRAGMA synchronous = NORMAL",
 		"PRAGMA temp_store = memory",
 		"PRAGMA mmap_size = 30000000000",
-		"PRAGMA busy_timeout = " + formatMilliseconds(c.BusyTimeout),
 		"PRAGMA foreign_keys = ON",
 		"PRAGMA cache_size = -" + formatInt(c.CacheSizeKB),
 	}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ConvertResult summarizes an EncryptDatabase or DecryptDatabase run
+type ConvertResult struct {
+	Messages      int
+	Conversations int
+}
+
+// EncryptDatabase encrypts the content of an existing plaintext database in
+// place and replaces its full-text index with a blinded one. Freed pages are
+// zeroed and the file is vacuumed so no plaintext is left behind.
+func EncryptDatabase(dbPath string, cipher *Cipher) (*ConvertResult, error) {
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	return store.convert(nil, cipher)
+}
+
+// DecryptDatabase turns an encrypted database back into a plaintext one and
+// rebuilds its regular full-text index
+func DecryptDatabase(dbPath string, cipher *Cipher) (*ConvertResult, error) {
+	store, err := NewEncryptedSQLiteStore(dbPath, cipher)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	return store.convert(cipher, nil)
+}
+
+// convert re-encodes every message, code snippet, shell command and raw JSON
+// value from one cipher to another (nil meaning plaintext) in a single
+// transaction
+func (s *SQLiteStore) convert(from, to *Cipher) (*ConvertResult, error) {
+	if _, err := s.writeDB.Exec("PRAGMA secure_delete = ON"); err != nil {
+		return nil, fmt.Errorf("failed to enable secure delete: %w", err)
+	}
+
+	messages, err := s.readColumn(`SELECT id, content FROM messages`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	rawJSON, err := s.readColumn(`SELECT id, raw_json FROM conversations WHERE raw_json IS NOT NULL AND raw_json != ''`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversations: %w", err)
+	}
+	snippets, err := s.readColumn(`SELECT id, code FROM code_snippets`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code snippets: %w", err)
+	}
+	commands, err := s.readColumn(`SELECT id, command FROM shell_commands`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shell commands: %w", err)
+	}
+
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Drop the full-text index first so the plaintext triggers do not fire
+	// while content is rewritten
+	drops := []string{
+		`DROP TRIGGER IF EXISTS messages_ai`,
+		`DROP TRIGGER IF EXISTS messages_ad`,
+		`DROP TRIGGER IF EXISTS messages_au`,
+		`DROP TRIGGER IF EXISTS messages_blind_ad`,
+		`DROP TRIGGER IF EXISTS code_snippets_ai`,
+		`DROP TRIGGER IF EXISTS code_snippets_ad`,
+		`DROP TRIGGER IF EXISTS code_snippets_blind_ad`,
+		`DROP TABLE IF EXISTS messages_fts`,
+		`DROP TABLE IF EXISTS messages_blind_fts`,
+		`DROP TABLE IF EXISTS code_snippets_fts`,
+		`DROP TABLE IF EXISTS code_snippets_blind_fts`,
+	}
+	if to != nil {
+		drops = append(drops, ftsQueries(true)...)
+	}
+	for _, query := range drops {
+		if _, err := tx.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to rebuild search index: %w", err)
+		}
+	}
+
+	result := &ConvertResult{}
+	for id, value := range messages {
+		plain, err := from.open(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt message %d: %w", id, err)
+		}
+		if _, err := tx.Exec(`UPDATE messages SET content = ? WHERE id = ?`, to.seal(plain), id); err != nil {
+			return nil, fmt.Errorf("failed to update message %d: %w", id, err)
+		}
+		if to != nil {
+			if _, err := tx.Exec(queryInsertBlindTokens, id, to.blindText(plain)); err != nil {
+				return nil, fmt.Errorf("failed to index message %d: %w", id, err)
+			}
+		}
+		result.Messages++
+	}
+
+	for id, value := range snippets {
+		plain, err := from.open(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt code snippet %d: %w", id, err)
+		}
+		if _, err := tx.Exec(`UPDATE code_snippets SET code = ? WHERE id = ?`, to.seal(plain), id); err != nil {
+			return nil, fmt.Errorf("failed to update code snippet %d: %w", id, err)
+		}
+		if to != nil {
+			if _, err := tx.Exec(queryInsertCodeSnippetBlindTokens, id, to.blindText(plain)); err != nil {
+				return nil, fmt.Errorf("failed to index code snippet %d: %w", id, err)
+			}
+		}
+	}
+
+	for id, value := range commands {
+		plain, err := from.open(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt shell command %d: %w", id, err)
+		}
+		if _, err := tx.Exec(`UPDATE shell_commands SET command = ? WHERE id = ?`, to.seal(plain), id); err != nil {
+			return nil, fmt.Errorf("failed to update shell command %d: %w", id, err)
+		}
+	}
+
+	for id, value := range rawJSON {
+		plain, err := from.open(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt conversation %d: %w", id, err)
+		}
+		if _, err := tx.Exec(`UPDATE conversations SET raw_json = ? WHERE id = ?`, to.seal(plain), id); err != nil {
+			return nil, fmt.Errorf("failed to update conversation %d: %w", id, err)
+		}
+		result.Conversations++
+	}
+
+	if to == nil {
+		queries := append(ftsQueries(false),
+			`INSERT INTO messages_fts(messages_fts) VALUES('rebuild')`,
+			`INSERT INTO code_snippets_fts(code_snippets_fts) VALUES('rebuild')`)
+		for _, query := range queries {
+			if _, err := tx.Exec(query); err != nil {
+				return nil, fmt.Errorf("failed to rebuild search index: %w", err)
+			}
+		}
+		for _, key := range []string{"encryption", "key_check"} {
+			if _, err := tx.Exec(queryDeleteMeta, key); err != nil {
+				return nil, fmt.Errorf("failed to write metadata: %w", err)
+			}
+		}
+	} else {
+		s.cipher = to
+		if err := s.markEncrypted(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit conversion: %w", err)
+	}
+	s.cipher = to
+
+	// Old page images may still sit in the WAL or on the free list
+	if _, err := s.writeDB.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return nil, fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	if _, err := s.writeDB.Exec("VACUUM"); err != nil {
+		return nil, fmt.Errorf("failed to vacuum: %w", err)
+	}
+	if _, err := s.writeDB.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return nil, fmt.Errorf("failed to checkpoint: %w", err)
+	}
+
+	return result, nil
+}
+
+// readColumn reads an id and text column pair into a map
+func (s *SQLiteStore) readColumn(query string) (map[int64]string, error) {
+	rows, err := s.writeDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var value sql.NullString
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+		values[id] = value.String
+	}
+	return values, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// encryptedPrefix marks values sealed by a Cipher
+const encryptedPrefix = "enc:v1:"
+
+// blindTokenBytes is how much of each token's HMAC is stored in the index
+const blindTokenBytes = 12
+
+var (
+	// ErrDatabaseEncrypted is returned when an encrypted database is opened without a key
+	ErrDatabaseEncrypted = errors.New("database is encrypted; a key is required (see mem db --help)")
+	// ErrWrongKey is returned when an encrypted database is opened with the wrong key
+	ErrWrongKey = errors.New("wrong database key")
+	// ErrNotEncrypted is returned when a key is given for a database holding plaintext
+	ErrNotEncrypted = errors.New("database is not encrypted; run 'mem db encrypt' first")
+)
+
+// Cipher encrypts message content and raw JSON at the application layer and
+// derives blinded search tokens, so the full-text index never holds plaintext.
+// A nil Cipher leaves values untouched.
+type Cipher struct {
+	aead     cipher.AEAD
+	tokenKey []byte
+	checkKey []byte
+}
+
+// NewCipher derives a cipher from key material such as the contents of a key
+// file or the secret printed by a keyring helper
+func NewCipher(material []byte) (*Cipher, error) {
+	material = []byte(strings.TrimSpace(string(material)))
+	if len(material) == 0 {
+		return nil, fmt.Errorf("empty database key")
+	}
+
+	derive := func(label string) ([]byte, error) {
+		return hkdf.Key(sha256.New, material, nil, "ai-memory/db/v1 "+label, 32)
+	}
+
+	contentKey, err := derive("content")
+	if err != nil {
+		return nil, err
+	}
+	tokenKey, err := derive("tokens")
+	if err != nil {
+		return nil, err
+	}
+	checkKey, err := derive("check")
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead, tokenKey: tokenKey, checkKey: checkKey}, nil
+}
+
+// GenerateKey returns new random key material suitable for a key file
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(key)), nil
+}
+
+// seal encrypts a value for storage
+func (c *Cipher) seal(value string) string {
+	if c == nil || value == "" {
+		return value
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	rand.Read(nonce)
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// open decrypts a stored value. Values without the encrypted prefix are
+// returned unchanged.
+func (c *Cipher) open(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrDatabaseEncrypted
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", fmt.Errorf("corrupted encrypted value")
+	}
+
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plain), nil
+}
+
+// keyCheck returns a value stored in the database to recognise the right key
+func (c *Cipher) keyCheck() string {
+	mac := hmac.New(sha256.New, c.checkKey)
+	mac.Write([]byte("ai-memory key check"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// blindToken returns the keyed hash stored in the index in place of a token
+func (c *Cipher) blindToken(token string) string {
+	mac := hmac.New(sha256.New, c.tokenKey)
+	mac.Write([]byte(token))
+	return "t" + hex.EncodeToString(mac.Sum(nil)[:blindTokenBytes])
+}
+
+// blindText returns the blinded tokens of text, space separated, for indexing
+func (c *Cipher) blindText(text string) string {
+	tokens := tokenize(text)
+	for i, token := range tokens {
+		tokens[i] = c.blindToken(token)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// blindQuery rewrites an FTS5 query so its terms match blinded tokens.
+// Operators, parentheses and phrase quotes are kept; prefix queries cannot be
+// supported because blinded tokens hide their spelling, so "*" is dropped.
+func (c *Cipher) blindQuery(query string) string {
+	var out []string
+	var word strings.Builder
+	inPhrase := false
+
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		term := word.String()
+		word.Reset()
+
+		if !inPhrase && (term == "AND" || term == "OR" || term == "NOT") {
+			out = append(out, term)
+			return
+		}
+		for _, token := range tokenize(term) {
+			out = append(out, c.blindToken(token))
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			flush()
+			out = append(out, `"`)
+			inPhrase = !inPhrase
+		case !inPhrase && (r == '(' || r == ')'):
+			flush()
+			out = append(out, string(r))
+		case unicode.IsSpace(r) || r == '*':
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return strings.Join(out, " ")
+}
+
+// tokenize splits text into lower-case letter and digit runs, like the FTS5
+// unicode61 tokenizer
+func tokenize(text string) []string {
+	var tokens []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		tokens = append(tokens, strings.ToLower(field))
+	}
+	return tokens
+}
\ No newline at end of file
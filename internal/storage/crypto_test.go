@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func testCipher(t *testing.T, material string) *Cipher {
+	t.Helper()
+	c, err := NewCipher([]byte(material))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	return c
+}
+
+func secretConversation(sessionID string) *models.Conversation {
+	return &models.Conversation{
+		Title:     "Deploy notes",
+		Tool:      "claude",
+		SessionID: sessionID,
+		RawJSON:   `{"secret":"hunter2-raw"}`,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Messages: []models.Message{
+			{Role: "user", Content: "the password is hunter2", Timestamp: time.Now()},
+			{Role: "assistant", Content: "Rotate the kubernetes credentials", Timestamp: time.Now()},
+		},
+	}
+}
+
+// fileContains checks the database and its WAL for a plaintext string
+func fileContains(t *testing.T, dbPath, needle string) bool {
+	t.Helper()
+	for _, path := range []string{dbPath, dbPath + "-wal"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if bytes.Contains(data, []byte(needle)) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEncryptedStore(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "enc.db")
+	key := testCipher(t, "correct horse")
+
+	store, err := NewEncryptedSQLiteStore(dbPath, key)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	conv := secretConversation("s1")
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	got, err := store.GetConversation(conv.ID)
+	if err != nil {
+		t.Fatalf("Failed to get: %v", err)
+	}
+	if got.Messages[0].Content != "the password is hunter2" || got.RawJSON != conv.RawJSON {
+		t.Errorf("Content was not decrypted: %+v", got)
+	}
+
+	for _, query := range []string{"hunter2", "KUBERNETES", `"rotate the"`, "password AND hunter2"} {
+		results, err := store.Search(query, 10)
+		if err != nil {
+			t.Fatalf("Search %q failed: %v", query, err)
+		}
+		if len(results) == 0 {
+			t.Errorf("Search %q found nothing", query)
+		}
+	}
+	results, _ := store.Search("missing", 10)
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+
+	if err := store.DeleteConversation(conv.ID); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	results, _ = store.Search("hunter2", 10)
+	if len(results) != 0 {
+		t.Errorf("Deleted conversation still searchable")
+	}
+
+	store.SaveConversation(secretConversation("s2"))
+	store.Close()
+
+	if fileContains(t, dbPath, "hunter2") {
+		t.Errorf("Plaintext found in database file")
+	}
+
+	info, _ := os.Stat(dbPath)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if _, err := NewSQLiteStore(dbPath); !errors.Is(err, ErrDatabaseEncrypted) {
+		t.Errorf("Expected ErrDatabaseEncrypted, got %v", err)
+	}
+	if _, err := NewEncryptedSQLiteStore(dbPath, testCipher(t, "wrong")); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Expected ErrWrongKey, got %v", err)
+	}
+}
+
+func TestEncryptDecryptDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "convert.db")
+	key := testCipher(t, "correct horse")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store.SaveConversation(secretConversation("s1"))
+	store.SaveShellCommand(&models.ShellCommand{Command: "echo hunter2", Dir: "/tmp", StartedAt: time.Now()})
+	store.Close()
+
+	if _, err := NewEncryptedSQLiteStore(dbPath, key); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("Expected ErrNotEncrypted, got %v", err)
+	}
+
+	result, err := EncryptDatabase(dbPath, key)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if result.Messages != 2 || result.Conversations != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if fileContains(t, dbPath, "hunter2") {
+		t.Errorf("Plaintext left in database after encrypting")
+	}
+
+	store, err = NewEncryptedSQLiteStore(dbPath, key)
+	if err != nil {
+		t.Fatalf("Failed to open encrypted store: %v", err)
+	}
+	results, err := store.Search("kubernetes", 10)
+	if err != nil || len(results) != 1 {
+		t.Errorf("Expected 1 result after encrypting, got %d (%v)", len(results), err)
+	}
+	store.Close()
+
+	if _, err := DecryptDatabase(dbPath, key); err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open decrypted store: %v", err)
+	}
+	defer store.Close()
+
+	results, err = store.Search("kuber*", 10)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected 1 result after decrypting, got %d (%v)", len(results), err)
+	}
+	conv, err := store.GetConversation(results[0].Conversation.ID)
+	if err != nil || len(conv.Messages) != 2 || conv.Messages[0].Content != "the password is hunter2" {
+		t.Errorf("Content not restored: %v", err)
+	}
+	commands, err := store.ShellCommands(ShellFilter{})
+	if err != nil || len(commands) != 1 || commands[0].Command != "echo hunter2" {
+		t.Errorf("Shell command not restored: %+v (%v)", commands, err)
+	}
+}
+
+func TestBlindQuery(t *testing.T) {
+	c := testCipher(t, "key")
+	a, b := c.blindToken("foo"), c.blindToken("bar")
+
+	tests := map[string]string{
+		"Foo":           a,
+		"foo OR bar":    a + " OR " + b,
+		`"foo bar"`:     `" ` + a + " " + b + ` "`,
+		"(foo) NOT bar": "( " + a + " ) NOT " + b,
+		"foo*":          a,
+	}
+	for query, want := range tests {
+		if got := c.blindQuery(query); got != want {
+			t.Errorf("blindQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ConversationFiles returns the files a conversation worked on or mentioned,
+// sorted by path
+func (s *SQLiteStore) ConversationFiles(convID int64) ([]models.ConversationFile, error) {
+	rows, err := s.readDB.Query(querySelectConversationFiles, convID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []models.ConversationFile{}
+	for rows.Next() {
+		var id int64
+		var path, access string
+		var at time.Time
+		if err := rows.Scan(&id, &path, &access, localTime(&at)); err != nil {
+			return nil, err
+		}
+		files = addFileAccess(files, id, path, access, at)
+	}
+	return files, rows.Err()
+}
+
+// FileConversations returns up to limit conversations matching filter that
+// accessed a file, most recent access first. The path matches the same path,
+// the files of a directory, and longer paths ending in it, so a
+// repo-relative path also finds files recorded with an absolute path. Only
+// the given kinds of access count, or all if access is empty.
+func (s *SQLiteStore) FileConversations(path string, filter ConversationFilter, access []string, limit int) ([]models.FileConversation, error) {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "./"), "/")
+	escaped := likeEscaper.Replace(path)
+
+	query := querySelectFileConversations
+	args := []interface{}{path, escaped + "/%", "%/" + escaped}
+	if len(access) > 0 {
+		query += " AND f.access IN (?" + strings.Repeat(", ?", len(access)-1) + ")"
+		for _, a := range access {
+			args = append(args, a)
+		}
+	}
+	conds, filterArgs := filter.where()
+	query += conds + " ORDER BY c.id, f.path, f.id"
+	args = append(args, filterArgs...)
+
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.FileConversation
+	for rows.Next() {
+		var conv models.Conversation
+		var tagsJSON, sessionID sql.NullString
+		var filePath, fileAccess string
+		var at time.Time
+		err := rows.Scan(&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &tagsJSON, &sessionID,
+			localTime(&conv.CreatedAt), localTime(&conv.UpdatedAt), &filePath, &fileAccess, localTime(&at))
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(results); n == 0 || results[n-1].Conversation.ID != conv.ID {
+			conv.SessionID = sessionID.String
+			if tagsJSON.String != "" {
+				json.Unmarshal([]byte(tagsJSON.String), &conv.Tags)
+			}
+			results = append(results, models.FileConversation{Conversation: conv})
+		}
+		last := &results[len(results)-1]
+		last.Files = addFileAccess(last.Files, conv.ID, filePath, fileAccess, at)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return lastAccess(results[i]).After(lastAccess(results[j]))
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []models.FileConversation{}
+	}
+	return results, nil
+}
+
+// addFileAccess counts an access to path in files, which are grouped by path
+// in the order they are added
+func addFileAccess(files []models.ConversationFile, convID int64, path, access string, at time.Time) []models.ConversationFile {
+	n := len(files)
+	if n == 0 || files[n-1].Path != path {
+		files = append(files, models.ConversationFile{ConversationID: convID, Path: path, Access: map[string]int{}})
+		n++
+	}
+	f := &files[n-1]
+	f.Access[access]++
+	if at.After(f.LastAt) {
+		f.LastAt = at
+	}
+	return files
+}
+
+// lastAccess is the time a conversation last accessed one of its files
+func lastAccess(fc models.FileConversation) time.Time {
+	var last time.Time
+	for _, f := range fc.Files {
+		if f.LastAt.After(last) {
+			last = f.LastAt
+		}
+	}
+	return last
+}
\ No newline at end of file
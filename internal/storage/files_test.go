@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func TestConversationFiles(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	save := func(title, sessionID string, age time.Duration, files ...models.FileRef) *models.Conversation {
+		conv := &models.Conversation{Title: title, Tool: "claude-code", Project: "api", SessionID: sessionID, CreatedAt: base, UpdatedAt: base}
+		for _, f := range files {
+			conv.Messages = append(conv.Messages, models.Message{
+				Role: "assistant", Content: "Working on it", Timestamp: base.Add(-age), Files: []models.FileRef{f},
+			})
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+		return conv
+	}
+
+	older := save("Older", "s1", 48*time.Hour,
+		models.FileRef{Path: "internal/storage/sqlite.go", Access: models.FileEdit},
+		models.FileRef{Path: "internal/storage/sqlite.go", Access: models.FileEdit},
+		models.FileRef{Path: "internal/storage/sqlite.go", Access: models.FileRead})
+	newer := save("Newer", "s2", time.Hour,
+		models.FileRef{Path: "/home/me/api/internal/storage/sqlite.go", Access: models.FileMention},
+		models.FileRef{Path: "internal/storage/filter.go", Access: models.FileWrite})
+	save("Unrelated", "s3", time.Minute,
+		models.FileRef{Path: "internal/storage/sqlite.go.orig", Access: models.FileEdit},
+		models.FileRef{Path: "sqlite_go", Access: models.FileEdit})
+
+	files, err := store.ConversationFiles(older.ID)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected 1 file, got %+v (%v)", files, err)
+	}
+	if f := files[0]; f.Access[models.FileEdit] != 2 || f.Access[models.FileRead] != 1 || !f.LastAt.Equal(base.Add(-48*time.Hour)) {
+		t.Errorf("Unexpected file %+v", f)
+	}
+
+	// Absolute paths ending in the path match; longer names do not
+	results, err := store.FileConversations("./internal/storage/sqlite.go", ConversationFilter{}, nil, 10)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Expected 2 conversations, got %+v (%v)", results, err)
+	}
+	if results[0].Conversation.ID != newer.ID || results[0].Conversation.SessionID != "s2" || len(results[0].Files) != 1 {
+		t.Errorf("Expected the newer conversation first with only the matching file, got %+v", results[0])
+	}
+
+	if results, _ := store.FileConversations("sqlite.go", ConversationFilter{}, []string{models.FileEdit, models.FileWrite}, 10); len(results) != 1 || results[0].Conversation.ID != older.ID {
+		t.Errorf("Expected only the conversation that edited the file, got %+v", results)
+	}
+	if results, _ := store.FileConversations("internal/storage/", ConversationFilter{}, nil, 10); len(results) != 3 {
+		t.Errorf("Expected a directory to match every conversation in it, got %d", len(results))
+	}
+	if results, _ := store.FileConversations("sqlite%go", ConversationFilter{}, nil, 10); len(results) != 0 {
+		t.Errorf("Expected LIKE wildcards to be taken literally, got %d", len(results))
+	}
+
+	// Replacing the conversation replaces its files
+	older.Messages = older.Messages[:1]
+	if _, err := store.ReplaceConversationBySessionID(older); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := store.ConversationFiles(older.ID); len(files) != 1 || files[0].Access[models.FileEdit] != 1 || len(files[0].Access) != 1 {
+		t.Errorf("Expected one edit after replacing, got %+v", files)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// ErrStaleCursor is returned when a page is requested after a conversation
+// that no longer exists
+var ErrStaleCursor = errors.New("cursor refers to a deleted conversation")
+
+// ConversationFilter selects conversations by tool, project and tag. Empty
+// fields match every conversation.
+type ConversationFilter struct {
+	Tool    string
+	Project string
+	Tag     string
+}
+
+// where returns SQL conditions for the filter on conversations aliased as c
+func (f ConversationFilter) where() (string, []interface{}) {
+	var conds string
+	var args []interface{}
+	if f.Tool != "" {
+		conds += " AND c.tool = ?"
+		args = append(args, f.Tool)
+	}
+	if f.Project != "" {
+		conds += " AND c.project = ?"
+		args = append(args, f.Project)
+	}
+	if f.Tag != "" {
+		conds += ` AND EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(c.tags) THEN c.tags ELSE '[]' END)
+			WHERE value = ?)`
+		args = append(args, f.Tag)
+	}
+	return conds, args
+}
+
+// ListConversationsPage lists up to limit conversations matching filter, newest
+// first, without their messages. Pages after the first start after the last
+// conversation of the previous page, given as after, so conversations added in
+// the meantime do not shift them.
+func (s *SQLiteStore) ListConversationsPage(filter ConversationFilter, after int64, limit int) ([]models.Conversation, error) {
+	conds, args := filter.where()
+	query := queryListConversations + conds
+	if after > 0 {
+		var exists int
+		if err := s.readDB.QueryRow(queryConversationExists, after).Scan(&exists); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrStaleCursor
+			}
+			return nil, err
+		}
+		query += queryListAfter
+		args = append(args, after, after, after)
+	}
+	query += queryListOrder
+	args = append(args, limit)
+
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+	for rows.Next() {
+		var conv models.Conversation
+		var tagsJSON sql.NullString
+		if err := rows.Scan(&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &tagsJSON, localTime(&conv.CreatedAt), localTime(&conv.UpdatedAt)); err != nil {
+			return nil, err
+		}
+		if tagsJSON.String != "" {
+			json.Unmarshal([]byte(tagsJSON.String), &conv.Tags)
+		}
+		conversations = append(conversations, conv)
+	}
+	return conversations, rows.Err()
+}
+
+// SearchPage runs a full-text search restricted to conversations matching
+// filter, skipping the first offset results
+func (s *SQLiteStore) SearchPage(query string, filter ConversationFilter, offset, limit int) ([]models.SearchResult, error) {
+	sqlQuery := querySearchConversations
+	match := query
+	if s.cipher != nil {
+		sqlQuery = querySearchConversationsBlind
+		match = s.cipher.blindQuery(query)
+	}
+	conds, filterArgs := filter.where()
+	sqlQuery += conds + querySearchOrder
+
+	args := append([]interface{}{match}, filterArgs...)
+	args = append(args, limit, offset)
+	rows, err := s.readDB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var result models.SearchResult
+		var tagsJSON string
+		var content string
+
+		err := rows.Scan(
+			&result.Conversation.ID, &result.Conversation.Title,
+			&result.Conversation.Tool, &result.Conversation.Project,
+			&tagsJSON, localTime(&result.Conversation.CreatedAt),
+			localTime(&result.Conversation.UpdatedAt), &content, &result.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if tagsJSON != "" {
+			json.Unmarshal([]byte(tagsJSON), &result.Conversation.Tags)
+		}
+
+		if content, err = s.cipher.open(content); err != nil {
+			return nil, err
+		}
+		result.Snippet = snippet(content, query)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// SearchMessages returns up to limit messages matching a full-text query in
+// conversations matching filter, best matches first
+func (s *SQLiteStore) SearchMessages(query string, filter ConversationFilter, limit int) ([]models.MessageMatch, error) {
+	sqlQuery := querySearchMessages
+	match := query
+	if s.cipher != nil {
+		sqlQuery = querySearchMessagesBlind
+		match = s.cipher.blindQuery(query)
+	}
+	conds, filterArgs := filter.where()
+	sqlQuery += conds + querySearchMessagesOrder
+
+	args := append([]interface{}{match}, filterArgs...)
+	args = append(args, limit)
+	rows, err := s.readDB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []models.MessageMatch
+	for rows.Next() {
+		var m models.MessageMatch
+		var tagsJSON string
+		err := rows.Scan(
+			&m.Conversation.ID, &m.Conversation.Title, &m.Conversation.Tool,
+			&m.Conversation.Project, &tagsJSON, localTime(&m.Conversation.CreatedAt), localTime(&m.Conversation.UpdatedAt),
+			&m.Message.ID, &m.Message.Role, &m.Message.Content, localTime(&m.Message.Timestamp),
+			&m.Message.TokenCount, &m.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if tagsJSON != "" {
+			json.Unmarshal([]byte(tagsJSON), &m.Conversation.Tags)
+		}
+		if m.Message.Content, err = s.cipher.open(m.Message.Content); err != nil {
+			return nil, err
+		}
+		m.Message.ConversationID = m.Conversation.ID
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
\ No newline at end of file
@@ -18,6 +18,8 @@ const (
 		source_path TEXT,
 		audit_shard TEXT,
 		raw_json TEXT,
+		work_dir TEXT,
+		ingested_to INTEGER,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (project_id) REFERENCES projects(id)
@@ -39,6 +41,31 @@ const (
 		content_rowid=id
 	)`
 
+	queryCreateMetaTable = `CREATE TABLE IF NOT EXISTS storage_meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`
+
+	querySelectMeta = `SELECT value FROM storage_meta WHERE key = ?`
+	queryUpsertMeta = `INSERT INTO storage_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`
+	queryDeleteMeta = `DELETE FROM storage_meta WHERE key = ?`
+
+	// In encrypted databases the full-text index holds blinded tokens, written
+	// by the store since triggers cannot compute them
+	queryCreateBlindFTS = `CREATE VIRTUAL TABLE IF NOT EXISTS messages_blind_fts USING fts5(
+		tokens,
+		content='',
+		contentless_delete=1
+	)`
+
+	queryCreateBlindDeleteTrigger = `CREATE TRIGGER IF NOT EXISTS messages_blind_ad AFTER DELETE ON messages
+	BEGIN
+		DELETE FROM messages_blind_fts WHERE rowid = old.id;
+	END`
+
+	queryInsertBlindTokens = `INSERT INTO messages_blind_fts (rowid, tokens) VALUES (?, ?)`
+
 	queryCreateIndexMessagesConversation = `CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id)`
 	queryCreateIndexConversationsTool    = `CREATE INDEX IF NOT EXISTS idx_conversations_tool ON conversations(tool)`
 	queryCreateIndexConversationsProject = `CREATE INDEX IF NOT EXISTS idx_conversations_project ON conversations(project)`
@@ -62,17 +89,189 @@ const (
 		UPDATE messages_fts SET content = new.content WHERE rowid = new.id;
 	END`
 
+	// Fenced code blocks of assistant messages; lines are those of the code
+	// within the message, counting from 1
+	queryCreateCodeSnippetsTable = `CREATE TABLE IF NOT EXISTS code_snippets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		language TEXT NOT NULL DEFAULT '',
+		code TEXT NOT NULL,
+		start_line INTEGER NOT NULL,
+		end_line INTEGER NOT NULL,
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	)`
+
+	queryCreateIndexCodeSnippetsMessage  = `CREATE INDEX IF NOT EXISTS idx_code_snippets_message ON code_snippets(message_id)`
+	queryCreateIndexCodeSnippetsLanguage = `CREATE INDEX IF NOT EXISTS idx_code_snippets_language ON code_snippets(language)`
+
+	queryCreateCodeSnippetsFTS = `CREATE VIRTUAL TABLE IF NOT EXISTS code_snippets_fts USING fts5(
+		code,
+		content=code_snippets,
+		content_rowid=id
+	)`
+
+	queryCreateCodeSnippetsInsertTrigger = `CREATE TRIGGER IF NOT EXISTS code_snippets_ai AFTER INSERT ON code_snippets
+	BEGIN
+		INSERT INTO code_snippets_fts(rowid, code) VALUES (new.id, new.code);
+	END`
+
+	queryCreateCodeSnippetsDeleteTrigger = `CREATE TRIGGER IF NOT EXISTS code_snippets_ad AFTER DELETE ON code_snippets
+	BEGIN
+		INSERT INTO code_snippets_fts(code_snippets_fts, rowid, code) VALUES ('delete', old.id, old.code);
+	END`
+
+	queryCreateCodeSnippetsBlindFTS = `CREATE VIRTUAL TABLE IF NOT EXISTS code_snippets_blind_fts USING fts5(
+		tokens,
+		content='',
+		contentless_delete=1
+	)`
+
+	queryCreateCodeSnippetsBlindDeleteTrigger = `CREATE TRIGGER IF NOT EXISTS code_snippets_blind_ad AFTER DELETE ON code_snippets
+	BEGIN
+		DELETE FROM code_snippets_blind_fts WHERE rowid = old.id;
+	END`
+
+	queryInsertCodeSnippet = `INSERT INTO code_snippets (conversation_id, message_id, language, code, start_line, end_line)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	queryInsertCodeSnippetBlindTokens = `INSERT INTO code_snippets_blind_fts (rowid, tokens) VALUES (?, ?)`
+
+	querySelectCodeSnippets = `SELECT s.id, s.conversation_id, s.message_id, s.language, s.code, s.start_line, s.end_line, c.title
+		FROM code_snippets s JOIN conversations c ON s.conversation_id = c.id`
+
+	querySearchCodeSnippets = querySelectCodeSnippets + `
+		JOIN code_snippets_fts ON code_snippets_fts.rowid = s.id
+		WHERE code_snippets_fts MATCH ?`
+
+	querySearchCodeSnippetsBlind = querySelectCodeSnippets + `
+		JOIN code_snippets_blind_fts ON code_snippets_blind_fts.rowid = s.id
+		WHERE code_snippets_blind_fts MATCH ?`
+
+	// Messages whose code blocks are indexed when an older database is opened
+	querySelectAssistantMessages = `SELECT id, conversation_id, content FROM messages WHERE role = 'assistant'`
+
+	// Files each message worked on or mentioned; paths are relative to the
+	// session's working directory where they are inside it
+	queryCreateConversationFilesTable = `CREATE TABLE IF NOT EXISTS conversation_files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		access TEXT NOT NULL,
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	)`
+
+	queryCreateIndexConversationFilesPath         = `CREATE INDEX IF NOT EXISTS idx_conversation_files_path ON conversation_files(path)`
+	queryCreateIndexConversationFilesConversation = `CREATE INDEX IF NOT EXISTS idx_conversation_files_conversation ON conversation_files(conversation_id)`
+	queryCreateIndexConversationFilesMessage      = `CREATE INDEX IF NOT EXISTS idx_conversation_files_message ON conversation_files(message_id)`
+
+	queryInsertConversationFile = `INSERT INTO conversation_files (conversation_id, message_id, path, access) VALUES (?, ?, ?, ?)`
+
+	// One row per file access, with the time of its message
+	querySelectConversationFiles = `SELECT f.conversation_id, f.path, f.access, m.timestamp
+		FROM conversation_files f JOIN messages m ON f.message_id = m.id
+		WHERE f.conversation_id = ? ORDER BY f.path, f.id`
+
+	// Conversations with accesses to a path, the path's directory contents or
+	// a longer path ending in it; filters and access kinds are appended
+	querySelectFileConversations = `SELECT c.id, c.title, c.tool, c.project, c.tags, c.session_id, c.created_at, c.updated_at,
+		f.path, f.access, m.timestamp
+		FROM conversation_files f
+		JOIN messages m ON f.message_id = m.id
+		JOIN conversations c ON f.conversation_id = c.id
+		WHERE (f.path = ? OR f.path LIKE ? ESCAPE '\' OR f.path LIKE ? ESCAPE '\')`
+
+	// Git commits and the conversations they came from, one per commit
+	queryCreateCommitLinksTable = `CREATE TABLE IF NOT EXISTS commit_links (
+		repo TEXT NOT NULL,
+		sha TEXT NOT NULL,
+		conversation_id INTEGER NOT NULL,
+		method TEXT NOT NULL,
+		score REAL NOT NULL,
+		committed_at DATETIME NOT NULL,
+		subject TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (repo, sha),
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+	)`
+
+	queryCreateIndexCommitLinksConversation = `CREATE INDEX IF NOT EXISTS idx_commit_links_conversation ON commit_links(conversation_id)`
+
+	queryUpsertCommitLink = `INSERT INTO commit_links (repo, sha, conversation_id, method, score, committed_at, subject)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(repo, sha) DO UPDATE SET conversation_id = excluded.conversation_id, method = excluded.method,
+			score = excluded.score, committed_at = excluded.committed_at, subject = excluded.subject`
+
+	querySelectCommitLinks = `SELECT l.repo, l.sha, l.conversation_id, c.title, COALESCE(c.session_id, ''), l.method, l.score, l.committed_at, l.subject
+		FROM commit_links l JOIN conversations c ON l.conversation_id = c.id`
+
+	queryCreateShellCommandsTable = `CREATE TABLE IF NOT EXISTS shell_commands (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		command TEXT NOT NULL,
+		dir TEXT NOT NULL,
+		exit_code INTEGER NOT NULL DEFAULT 0,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME NOT NULL,
+		shell TEXT NOT NULL DEFAULT ''
+	)`
+
+	queryCreateIndexShellCommandsStarted = `CREATE INDEX IF NOT EXISTS idx_shell_commands_started ON shell_commands(started_at)`
+
+	queryInsertShellCommand = `INSERT INTO shell_commands (command, dir, exit_code, duration_ms, started_at, shell)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	// Shell commands; filters and the order are appended
+	querySelectShellCommands = `SELECT id, command, dir, exit_code, duration_ms, started_at, shell FROM shell_commands WHERE 1=1`
+
+	// The time of a message m, or NULL for messages stored without one (Go's
+	// zero time)
+	timedMessage = `CASE WHEN m.timestamp > '0001-01-01 00:00:00+00:00' THEN m.timestamp END`
+
+	// The time of a message m, counting messages without one as sent when their
+	// conversation c was created
+	messageTime = `COALESCE(` + timedMessage + `, c.created_at)`
+
+	// The project of a conversation and the times of its first and last timed
+	// message, or of its creation if it has none
+	querySelectConversationSpan = `SELECT c.project, COALESCE(p.project_path, ''), COALESCE(c.work_dir, ''),
+		COALESCE(MIN(` + timedMessage + `), c.created_at), COALESCE(MAX(` + timedMessage + `), c.created_at)
+		FROM conversations c LEFT JOIN projects p ON c.project_id = p.id LEFT JOIN messages m ON m.conversation_id = c.id
+		WHERE c.id = ? GROUP BY c.id`
+
+	// Conversations with the Claude Code project they ran in and the times of
+	// their first and last message; filters, then the grouping, the since bound
+	// and the order are appended
+	querySelectSessionSpans = `SELECT c.id, c.title, c.tool, c.project, COALESCE(p.project_path, ''), COALESCE(c.work_dir, ''), c.tags,
+		COALESCE(c.session_id, ''), c.created_at, c.updated_at,
+		COUNT(m.id), MIN(` + messageTime + `) AS span_start, MAX(` + messageTime + `) AS span_end
+		FROM conversations c LEFT JOIN projects p ON c.project_id = p.id LEFT JOIN messages m ON m.conversation_id = c.id
+		WHERE 1=1`
+	querySessionSpansGroup = ` GROUP BY c.id`
+	querySessionSpansSince = ` HAVING span_end >= ?`
+	querySessionSpansOrder = ` ORDER BY span_end DESC, c.id DESC`
+
+	// Messages, counted and their tokens summed per conversation and quarter
+	// hour since the Unix epoch; conversation filters, then the since bound and
+	// the grouping are appended
+	querySelectActivity = `SELECT m.conversation_id, CAST(strftime('%s', ` + messageTime + `) AS INTEGER) / 900 AS quarter,
+		COUNT(*), SUM(COALESCE(m.token_count, 0))
+		FROM messages m JOIN conversations c ON m.conversation_id = c.id WHERE 1=1`
+	queryActivitySince = ` AND ` + messageTime + ` >= ?`
+	queryActivityGroup = ` GROUP BY m.conversation_id, quarter`
+
 	queryInsertProject = `INSERT OR IGNORE INTO projects (project_path) VALUES (?)`
 
 	querySelectProjectID = `SELECT id FROM projects WHERE project_path = ?`
 
-	queryInsertConversation = `INSERT INTO conversations (title, tool, project, project_id, tags, session_id, source_path, audit_shard, raw_json, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	queryInsertConversation = `INSERT INTO conversations (title, tool, project, project_id, tags, session_id, source_path, audit_shard, raw_json, work_dir, ingested_to, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	queryInsertMessage = `INSERT INTO messages (conversation_id, role, content, timestamp, token_count)
 		VALUES (?, ?, ?, ?, ?)`
 
-	querySelectConversation = `SELECT id, title, tool, project, project_id, tags, session_id, source_path, audit_shard, raw_json, created_at, updated_at
+	querySelectConversation = `SELECT id, title, tool, project, project_id, tags, session_id, source_path, audit_shard, raw_json, COALESCE(work_dir, ''), created_at, updated_at
 		FROM conversations WHERE id = ?`
 
 	querySelectMessages = `SELECT id, conversation_id, role, content, timestamp, token_count
@@ -80,6 +279,20 @@ const (
 
 	queryDeleteConversation = `DELETE FROM conversations WHERE id = ?`
 
+	querySelectConversationIDBySession = `SELECT id, tags FROM conversations WHERE session_id = ? ORDER BY id LIMIT 1`
+
+	queryReplaceConversation = `UPDATE conversations SET title = ?, tool = ?, project = ?, project_id = ?, tags = ?,
+		source_path = ?, audit_shard = ?, raw_json = ?, work_dir = ?, ingested_to = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`
+
+	queryDeleteMessagesByConversation = `DELETE FROM messages WHERE conversation_id = ?`
+
+	queryUpdateAuditShard = `UPDATE conversations SET audit_shard = NULLIF(?, '') WHERE audit_shard = ?`
+
+	queryTouchConversation = `UPDATE conversations SET updated_at = ?, ingested_to = ? WHERE id = ?`
+
+	querySelectIngestedTo = `SELECT COALESCE(ingested_to, 0) FROM conversations WHERE session_id = ? ORDER BY id LIMIT 1`
+
 	querySearchConversations = `
 		SELECT DISTINCT
 			c.id, c.title, c.tool, c.project, c.tags, c.created_at, c.updated_at,
@@ -87,9 +300,64 @@ const (
 		FROM messages_fts
 		JOIN messages m ON messages_fts.rowid = m.id
 		JOIN conversations c ON m.conversation_id = c.id
-		WHERE messages_fts MATCH ?
+		WHERE messages_fts MATCH ?`
+
+	querySearchConversationsBlind = `
+		SELECT DISTINCT
+			c.id, c.title, c.tool, c.project, c.tags, c.created_at, c.updated_at,
+			m.content, bm25(messages_blind_fts) as score
+		FROM messages_blind_fts
+		JOIN messages m ON messages_blind_fts.rowid = m.id
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE messages_blind_fts MATCH ?`
+
+	querySearchMessages = `
+		SELECT c.id, c.title, c.tool, c.project, c.tags, c.created_at, c.updated_at,
+			m.id, m.role, m.content, m.timestamp, m.token_count, bm25(messages_fts) as score
+		FROM messages_fts
+		JOIN messages m ON messages_fts.rowid = m.id
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE messages_fts MATCH ?`
+
+	querySearchMessagesBlind = `
+		SELECT c.id, c.title, c.tool, c.project, c.tags, c.created_at, c.updated_at,
+			m.id, m.role, m.content, m.timestamp, m.token_count, bm25(messages_blind_fts) as score
+		FROM messages_blind_fts
+		JOIN messages m ON messages_blind_fts.rowid = m.id
+		JOIN conversations c ON m.conversation_id = c.id
+		WHERE messages_blind_fts MATCH ?`
+
+	// bm25 is lower for better matches
+	querySearchMessagesOrder = ` ORDER BY score, m.id LIMIT ?`
+
+	// Appended to a search query after any filter conditions
+	querySearchOrder = `
 		ORDER BY score DESC
-		LIMIT ?`
+		LIMIT ? OFFSET ?`
+
+	queryListConversations = `SELECT c.id, c.title, c.tool, c.project, c.tags, c.created_at, c.updated_at
+		FROM conversations c WHERE 1=1`
+
+	// Conversations listed after a cursor conversation, newest first
+	queryListAfter = ` AND (c.created_at < (SELECT created_at FROM conversations WHERE id = ?)
+		OR (c.created_at = (SELECT created_at FROM conversations WHERE id = ?) AND c.id < ?))`
+
+	queryListOrder = ` ORDER BY c.created_at DESC, c.id DESC LIMIT ?`
+
+	queryConversationExists = `SELECT 1 FROM conversations WHERE id = ?`
+
+	querySelectMessagesPage = `SELECT id, conversation_id, role, content, timestamp, token_count
+		FROM messages WHERE conversation_id = ? ORDER BY timestamp, id LIMIT ? OFFSET ?`
+
+	queryCountConversationMessages = `SELECT COUNT(*) FROM messages WHERE conversation_id = ?`
+
+	querySelectTags = `SELECT tags FROM conversations WHERE id = ?`
+
+	queryUpdateTags = `UPDATE conversations SET tags = ?, updated_at = ? WHERE id = ?`
+
+	queryCountTags = `SELECT t.value, COUNT(*) FROM conversations c,
+		json_each(CASE WHEN json_valid(c.tags) THEN c.tags ELSE '[]' END) t
+		WHERE t.type = 'text' GROUP BY t.value ORDER BY t.value`
 
 	queryCountConversations = `SELECT COUNT(*) FROM conversations`
 	queryCountMessages      = `SELECT COUNT(*) FROM messages`
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultRedactionReplacement replaces redacted text unless configured otherwise
+const DefaultRedactionReplacement = "[REDACTED]"
+
+// Redactor replaces text matching any of its patterns, such as API keys,
+// before it is stored
+type Redactor struct {
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+// NewRedactor compiles patterns into a redactor. An empty replacement uses
+// DefaultRedactionReplacement.
+func NewRedactor(patterns []string, replacement string) (*Redactor, error) {
+	if replacement == "" {
+		replacement = DefaultRedactionReplacement
+	}
+
+	r := &Redactor{replacement: replacement}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns text with every match replaced. A nil redactor returns text unchanged.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, re := range r.patterns {
+		text = re.ReplaceAllLiteralString(text, r.replacement)
+	}
+	return text
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// SessionSpans returns the conversations matching filter with the times of
+// their first and last message, latest first. Conversations whose last message
+// is before since are left out. Messages without a time count as sent when
+// their conversation was created.
+func (s *SQLiteStore) SessionSpans(filter ConversationFilter, since time.Time) ([]models.SessionSpan, error) {
+	conds, args := filter.where()
+	query := querySelectSessionSpans + conds + querySessionSpansGroup
+	if !since.IsZero() {
+		query += querySessionSpansSince
+		args = append(args, since.UTC())
+	}
+	rows, err := s.readDB.Query(query+querySessionSpansOrder, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spans := []models.SessionSpan{}
+	for rows.Next() {
+		var span models.SessionSpan
+		conv := &span.Conversation
+		var tagsJSON string
+		err := rows.Scan(&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &conv.ProjectPath, &conv.WorkDir, &tagsJSON,
+			&conv.SessionID, localTime(&conv.CreatedAt), localTime(&conv.UpdatedAt), &span.MessageCount, localTime(&span.Start), localTime(&span.End))
+		if err != nil {
+			return nil, err
+		}
+		if tagsJSON != "" {
+			json.Unmarshal([]byte(tagsJSON), &conv.Tags)
+		}
+		spans = append(spans, span)
+	}
+	return spans, rows.Err()
+}
\ No newline at end of file
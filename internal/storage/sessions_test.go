@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func TestSessionSpansAndCommitLinks(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	save := func(title string, offsets ...time.Duration) *models.Conversation {
+		conv := &models.Conversation{Title: title, Tool: "claude-code", Project: "api", SessionID: title, CreatedAt: base, UpdatedAt: base}
+		for _, offset := range offsets {
+			conv.Messages = append(conv.Messages, models.Message{Role: "user", Content: "Hello", Timestamp: base.Add(offset)})
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatal(err)
+		}
+		return conv
+	}
+
+	// Messages out of order, and across a change of day in the text of the time
+	long := save("long", 10*time.Hour, -2*time.Hour, 13*time.Hour)
+	short := save("short", 20*time.Hour, 20*time.Hour+time.Minute)
+	empty := save("empty")
+
+	spans, err := store.SessionSpans(ConversationFilter{}, time.Time{})
+	if err != nil || len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %+v (%v)", spans, err)
+	}
+	if spans[0].Conversation.ID != short.ID || spans[1].Conversation.ID != long.ID || spans[2].Conversation.ID != empty.ID {
+		t.Errorf("Expected spans latest first, got %d, %d, %d", spans[0].Conversation.ID, spans[1].Conversation.ID, spans[2].Conversation.ID)
+	}
+	if s := spans[1]; !s.Start.Equal(base.Add(-2*time.Hour)) || !s.End.Equal(base.Add(13*time.Hour)) || s.MessageCount != 3 {
+		t.Errorf("Unexpected span %+v", s)
+	}
+	if s := spans[2]; !s.Start.Equal(base) || !s.End.Equal(base) || s.MessageCount != 0 {
+		t.Errorf("Expected an empty conversation to span its creation, got %+v", s)
+	}
+	if spans, _ := store.SessionSpans(ConversationFilter{}, base.Add(14*time.Hour)); len(spans) != 1 {
+		t.Errorf("Expected 1 span since, got %d", len(spans))
+	}
+
+	links := []models.CommitLink{
+		{Repo: "/repo", SHA: "bbb", ConversationID: long.ID, Method: "files", Score: 0.75, CommittedAt: base.Add(time.Hour), Subject: "Second"},
+		{Repo: "/repo", SHA: "aaa", ConversationID: long.ID, Method: "time", Score: 0.5, CommittedAt: base, Subject: "First"},
+	}
+	if err := store.SaveCommitLinks(links); err != nil {
+		t.Fatal(err)
+	}
+	// Linking again replaces the link
+	links[1].ConversationID, links[1].Method = short.ID, "trailer"
+	if err := store.SaveCommitLinks(links[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := store.GetCommitLink("/repo", "aaa")
+	if err != nil || link.ConversationID != short.ID || link.SessionID != "short" || link.ConversationTitle != "short" || link.Method != "trailer" {
+		t.Errorf("Unexpected link %+v (%v)", link, err)
+	}
+	if _, err := store.GetCommitLink("/other", "aaa"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+	if commits, _ := store.ConversationCommits(long.ID); len(commits) != 1 || commits[0].SHA != "bbb" || !commits[0].CommittedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("Unexpected commits %+v", commits)
+	}
+
+	// Deleting the conversation removes its links
+	if err := store.DeleteConversation(short.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetCommitLink("/repo", "aaa"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the link to be deleted, got %v", err)
+	}
+}
\ No newline at end of file
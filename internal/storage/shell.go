@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+// ShellWindow is how long after a conversation's last message the shell
+// commands run in its project are shown with it
+const ShellWindow = time.Hour
+
+// ShellFilter narrows the shell commands ShellCommands returns
+type ShellFilter struct {
+	Since time.Time // Started at or after, if set
+	Until time.Time // Started before, if set
+	Dir   string    // Run in this directory or one below it, if set
+}
+
+// SaveShellCommand records a command run in an interactive shell. Commands are
+// redacted, and encrypted like message content in an encrypted database.
+func (s *SQLiteStore) SaveShellCommand(cmd *models.ShellCommand) error {
+	result, err := s.writeDB.Exec(queryInsertShellCommand, s.cipher.seal(s.redactor.Redact(cmd.Command)),
+		cmd.Dir, cmd.ExitCode, cmd.DurationMS, cmd.StartedAt.UTC(), cmd.Shell)
+	if err != nil {
+		return fmt.Errorf("failed to save shell command: %w", err)
+	}
+	cmd.ID, err = result.LastInsertId()
+	return err
+}
+
+// ShellCommands returns the recorded shell commands matching filter, oldest first
+func (s *SQLiteStore) ShellCommands(filter ShellFilter) ([]models.ShellCommand, error) {
+	query := querySelectShellCommands
+	var args []interface{}
+	if !filter.Since.IsZero() {
+		query += " AND started_at >= ?"
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		query += " AND started_at < ?"
+		args = append(args, filter.Until.UTC())
+	}
+	if filter.Dir != "" {
+		dir := strings.TrimSuffix(filter.Dir, "/")
+		query += ` AND (dir = ? OR dir LIKE ? ESCAPE '\')`
+		args = append(args, dir, likeEscaper.Replace(dir)+"/%")
+	}
+	query += " ORDER BY started_at, id"
+
+	rows, err := s.readDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commands := []models.ShellCommand{}
+	for rows.Next() {
+		var cmd models.ShellCommand
+		if err := rows.Scan(&cmd.ID, &cmd.Command, &cmd.Dir, &cmd.ExitCode, &cmd.DurationMS, localTime(&cmd.StartedAt), &cmd.Shell); err != nil {
+			return nil, err
+		}
+		if cmd.Command, err = s.cipher.open(cmd.Command); err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+	return commands, rows.Err()
+}
+
+// ConversationShellCommands returns the shell commands run in the directory a
+// conversation ran in, or below it, from its first message until after has
+// passed since its last
+func (s *SQLiteStore) ConversationShellCommands(convID int64, after time.Duration) ([]models.ShellCommand, error) {
+	var conv models.Conversation
+	var first, last time.Time
+	err := s.readDB.QueryRow(querySelectConversationSpan, convID).Scan(&conv.Project, &conv.ProjectPath, &conv.WorkDir, localTime(&first), localTime(&last))
+	if err == sql.ErrNoRows {
+		return []models.ShellCommand{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := s.ShellCommands(ShellFilter{Since: first, Until: last.Add(after)})
+	if err != nil {
+		return nil, err
+	}
+	matched := []models.ShellCommand{}
+	for _, cmd := range commands {
+		if conv.Covers(cmd.Dir) {
+			matched = append(matched, cmd)
+		}
+	}
+	return matched, nil
+}
\ No newline at end of file
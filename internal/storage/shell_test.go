@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasperwreed/ai-memory/internal/models"
+)
+
+func TestShellCommands(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	redactor, _ := NewRedactor([]string{`sk-[a-z0-9]+`}, "")
+	store.SetRedactor(redactor)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	conv := &models.Conversation{
+		Title: "Fix tests", Tool: "claude-code", Project: "api", ProjectPath: "-home-me-api", WorkDir: "/home/me/api", CreatedAt: base, UpdatedAt: base,
+		Messages: []models.Message{
+			{Role: "user", Content: "Fix the tests", Timestamp: base.Add(10 * time.Minute)},
+			{Role: "assistant", Content: "Run go test", Timestamp: base.Add(20 * time.Minute)},
+		},
+	}
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatal(err)
+	}
+
+	local := time.FixedZone("CEST", 2*60*60)
+	save := func(command, dir string, at time.Time) {
+		cmd := &models.ShellCommand{Command: command, Dir: dir, ExitCode: 1, DurationMS: 1500, StartedAt: at, Shell: "zsh"}
+		if err := store.SaveShellCommand(cmd); err != nil || cmd.ID == 0 {
+			t.Fatalf("SaveShellCommand() = %v, ID %d", err, cmd.ID)
+		}
+	}
+	save("ls", "/home/me/api", base.Add(5*time.Minute))
+	save("go test ./...", "/home/me/api/internal", base.Add(15*time.Minute+500*time.Millisecond).In(local))
+	save("export KEY=sk-abc123", "/home/me/api", base.Add(50*time.Minute))
+	save("git push", "/home/me/api", base.Add(90*time.Minute))
+	save("make", "/home/me/api2", base.Add(15*time.Minute))
+	save("npm test", "/home/me/api-gateway", base.Add(30*time.Minute))
+
+	commands, err := store.ShellCommands(ShellFilter{Since: base.Add(15 * time.Minute), Until: base.Add(90 * time.Minute), Dir: "/home/me/api/"})
+	if err != nil || len(commands) != 2 {
+		t.Fatalf("Expected 2 commands, got %+v (%v)", commands, err)
+	}
+	if c := commands[0]; c.Command != "go test ./..." || c.ExitCode != 1 || c.DurationMS != 1500 || c.Shell != "zsh" ||
+		!c.StartedAt.Equal(base.Add(15*time.Minute+500*time.Millisecond)) {
+		t.Errorf("Unexpected command %+v", c)
+	}
+	if commands[1].Command != "export KEY=[REDACTED]" {
+		t.Errorf("Expected the command to be redacted, got %q", commands[1].Command)
+	}
+
+	// From the first message until an hour after the last, in the project
+	commands, err = store.ConversationShellCommands(conv.ID, time.Hour)
+	if err != nil || len(commands) != 2 || commands[0].Command != "go test ./..." {
+		t.Errorf("Unexpected conversation commands %+v (%v)", commands, err)
+	}
+	if commands, _ := store.ConversationShellCommands(conv.ID+1, time.Hour); len(commands) != 0 {
+		t.Errorf("Expected no commands for a missing conversation, got %+v", commands)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetLength is the length of search result snippets, in bytes
+const snippetLength = 200
+
+// queryTerms returns the words of an FTS5 query, without operators and syntax
+func queryTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.Trim(word, `"()*^+-:`)
+		switch word {
+		case "", "AND", "OR", "NOT", "NEAR":
+			continue
+		}
+		terms = append(terms, strings.ToLower(word))
+	}
+	return terms
+}
+
+// snippet returns part of content around the first term of query it
+// contains, or its start if none is found
+func snippet(content, query string) string {
+	if len(content) <= snippetLength {
+		return content
+	}
+
+	lower := strings.ToLower(content)
+	match := -1
+	for _, term := range queryTerms(query) {
+		if i := strings.Index(lower, term); i >= 0 && (match < 0 || i < match) {
+			match = i
+		}
+	}
+	if match < 0 || len(lower) != len(content) {
+		// Lowercasing changed byte offsets, so they cannot be used
+		return truncateContent(content, snippetLength)
+	}
+
+	start := max(match-snippetLength/4, 0)
+	end := min(start+snippetLength, len(content))
+	start = max(end-snippetLength, 0)
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start++
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end--
+	}
+
+	result := strings.TrimSpace(content[start:end])
+	if start > 0 {
+		result = "..." + result
+	}
+	if end < len(content) {
+		result += "..."
+	}
+	return result
+}
\ No newline at end of file
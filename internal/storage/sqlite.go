@@ -17,9 +17,30 @@ type SQLiteStore struct {
 	writeDB *sql.DB  // Single connection for writes
 	readDB  *sql.DB  // Pool of connections for reads
 	dbPath  string
+	cipher  *Cipher  // Encrypts content at rest; nil for plaintext databases
+
+	redactor *Redactor // Scrubs text before it is written; nil keeps it as is
 }
 
+// dsnParams has times written in a format SQLite's date functions read. They
+// are written in UTC, so they also sort and compare as text.
+const dsnParams = "?_time_format=sqlite"
+
 func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	return openSQLiteStore(dbPath, nil)
+}
+
+// NewEncryptedSQLiteStore opens a database whose message content and raw JSON
+// are encrypted with cipher. A new database is created encrypted; an existing
+// plaintext one must be converted with EncryptDatabase first.
+func NewEncryptedSQLiteStore(dbPath string, cipher *Cipher) (*SQLiteStore, error) {
+	if cipher == nil {
+		return nil, fmt.Errorf("encrypted store requires a cipher")
+	}
+	return openSQLiteStore(dbPath, cipher)
+}
+
+func openSQLiteStore(dbPath string, cipher *Cipher) (*SQLiteStore, error) {
 	if dbPath == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
@@ -28,13 +49,14 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		dbPath = filepath.Join(homeDir, ".ai-memory", "conversations.db")
 	}
 
+	// Conversations are private: keep the directory and database owner-only
 	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
 	// Open write connection (single connection)
-	writeDB, err := sql.Open("sqlite", dbPath)
+	writeDB, err := sql.Open("sqlite", dbPath+dsnParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open write database: %w", err)
 	}
@@ -43,7 +65,7 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 	// Open read connection pool
 	// Note: We don't use ?mode=ro here because the database might not exist yet
 	// and we need at least one connection to create tables
-	readDB, err := sql.Open("sqlite", dbPath)
+	readDB, err := sql.Open("sqlite", dbPath+dsnParams)
 	if err != nil {
 		writeDB.Close()
 		return nil, fmt.Errorf("failed to open read database: %w", err)
@@ -55,6 +77,7 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		writeDB: writeDB,
 		readDB:  readDB,
 		dbPath:  dbPath,
+		cipher:  cipher,
 	}
 
 	// Initialize database with optimizations
@@ -63,14 +86,120 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	if err := store.checkEncryption(); err != nil {
+		store.closeDBs()
+		return nil, err
+	}
+
 	if err := store.createTables(); err != nil {
 		store.Close()
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	if err := store.addColumns(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to upgrade tables: %w", err)
+	}
+
+	if err := store.upgradeTimes(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to upgrade stored times: %w", err)
+	}
+
+	if err := store.indexOlderSnippets(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to index code snippets: %w", err)
+	}
+
+	os.Chmod(dbPath, 0600)
+
 	return store, nil
 }
 
+// SetRedactor scrubs titles, message content and raw JSON with r before they
+// are written. Conversations already stored are not changed.
+func (s *SQLiteStore) SetRedactor(r *Redactor) {
+	s.redactor = r
+}
+
+// checkEncryption makes sure the store's cipher matches the database: an
+// encrypted database needs the right key and a plaintext one must not get one,
+// except when it is still empty and can start out encrypted
+func (s *SQLiteStore) checkEncryption() error {
+	if _, err := s.writeDB.Exec(queryCreateMetaTable); err != nil {
+		return fmt.Errorf("failed to create metadata table: %w", err)
+	}
+
+	mode, err := s.meta("encryption")
+	if err != nil {
+		return err
+	}
+
+	if mode == "" {
+		if s.cipher == nil {
+			return nil
+		}
+
+		var messages int
+		s.writeDB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'messages'`).Scan(&messages)
+		if messages > 0 {
+			s.writeDB.QueryRow(queryCountMessages).Scan(&messages)
+		}
+		if messages > 0 {
+			return ErrNotEncrypted
+		}
+		return s.markEncrypted(s.writeDB)
+	}
+
+	if s.cipher == nil {
+		return ErrDatabaseEncrypted
+	}
+	check, err := s.meta("key_check")
+	if err != nil {
+		return err
+	}
+	if check != s.cipher.keyCheck() {
+		return ErrWrongKey
+	}
+	return nil
+}
+
+// meta returns a value from the metadata table, or "" if it is not set
+func (s *SQLiteStore) meta(key string) (string, error) {
+	var value string
+	err := s.writeDB.QueryRow(querySelectMeta, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read metadata: %w", err)
+	}
+	return value, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// markEncrypted records that the database is encrypted with the store's cipher
+func (s *SQLiteStore) markEncrypted(db execer) error {
+	for key, value := range map[string]string{
+		"encryption": "v1",
+		"key_check":  s.cipher.keyCheck(),
+	} {
+		if _, err := db.Exec(queryUpsertMeta, key, value); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsEncrypted reports whether the store encrypts content at rest
+func (s *SQLiteStore) IsEncrypted() bool {
+	return s.cipher != nil
+}
+
 func (s *SQLiteStore) initializeDB() error {
 	// Apply SQLite optimizations for performance
 	config := DefaultConfig()
@@ -97,11 +226,19 @@ func (s *SQLiteStore) createTables() error {
 		queryCreateIndexConversationsCreated,
 		queryCreateIndexConversationsSession,
 		queryCreateIndexConversationsSource,
-		queryCreateMessagesFTS,
-		queryCreateMessagesInsertTrigger,
-		queryCreateMessagesDeleteTrigger,
-		queryCreateMessagesUpdateTrigger,
-	}
+		queryCreateCodeSnippetsTable,
+		queryCreateIndexCodeSnippetsMessage,
+		queryCreateIndexCodeSnippetsLanguage,
+		queryCreateConversationFilesTable,
+		queryCreateIndexConversationFilesPath,
+		queryCreateIndexConversationFilesConversation,
+		queryCreateIndexConversationFilesMessage,
+		queryCreateCommitLinksTable,
+		queryCreateIndexCommitLinksConversation,
+		queryCreateShellCommandsTable,
+		queryCreateIndexShellCommandsStarted,
+	}
+	queries = append(queries, ftsQueries(s.cipher != nil)...)
 
 	for _, query := range queries {
 		if _, err := s.writeDB.Exec(query); err != nil {
@@ -112,37 +249,307 @@ func (s *SQLiteStore) createTables() error {
 	return nil
 }
 
-func (s *SQLiteStore) SaveConversation(conv *models.Conversation) error {
+// addedColumns are columns added to tables after they were first created, so
+// databases from older versions need them added
+var addedColumns = []struct{ table, column, definition string }{
+	{"conversations", "work_dir", "TEXT"},
+	{"conversations", "ingested_to", "INTEGER"},
+}
+
+// addColumns adds the columns in addedColumns that a database is missing
+func (s *SQLiteStore) addColumns() error {
+	for _, c := range addedColumns {
+		has, err := s.hasColumn(c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		_, err = s.writeDB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition))
+		// Another process may have added it since
+		if has, _ := s.hasColumn(c.table, c.column); err != nil && !has {
+			return fmt.Errorf("failed to add %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether table has a column
+func (s *SQLiteStore) hasColumn(table, column string) (bool, error) {
+	var n int
+	err := s.writeDB.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	return n > 0, err
+}
+
+// metaTimesUTC records that stored times are in UTC and SQLite's format
+const metaTimesUTC = "times_utc"
+
+// timeColumns are the columns holding times
+var timeColumns = []struct{ table, column string }{
+	{"conversations", "created_at"},
+	{"conversations", "updated_at"},
+	{"messages", "timestamp"},
+	{"commit_links", "committed_at"},
+	{"shell_commands", "started_at"},
+}
+
+// localTime scans a stored time into t in local time, the zone times are
+// shown in. Times are stored in UTC, and those SQLite computes, such as the
+// MIN of a time column, come back as text.
+func localTime(t *time.Time) sql.Scanner {
+	return timeScanner{t}
+}
+
+type timeScanner struct {
+	t *time.Time
+}
+
+func (s timeScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*s.t = v.Local()
+	case string:
+		// In SQLite's format, or as CURRENT_TIMESTAMP writes them
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+			if at, err := time.Parse(layout, v); err == nil {
+				*s.t = at.Local()
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid stored time %q", v)
+	case nil:
+		*s.t = time.Time{}
+	default:
+		return fmt.Errorf("invalid stored time %v", src)
+	}
+	return nil
+}
+
+// upgradeTimes rewrites the times older versions stored, in the zone they were
+// recorded in and Go's format, in UTC and SQLite's format
+func (s *SQLiteStore) upgradeTimes() error {
+	done, err := s.meta(metaTimesUTC)
+	if err != nil || done != "" {
+		return err
+	}
+
 	tx, err := s.writeDB.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Handle project insertion/lookup
-	var projectID *int64
-	if conv.ProjectPath != "" {
-		// Insert project if it doesn't exist
-		if _, err := tx.Exec(queryInsertProject, conv.ProjectPath); err != nil {
-			return fmt.Errorf("failed to insert project: %w", err)
+	// Another process may have upgraded them since
+	if err := tx.QueryRow(querySelectMeta, metaTimesUTC).Scan(&done); err == nil {
+		return nil
+	}
+
+	for _, c := range timeColumns {
+		if err := upgradeColumnTimes(tx, c.table, c.column); err != nil {
+			return fmt.Errorf("failed to upgrade %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	if _, err := tx.Exec(queryUpsertMeta, metaTimesUTC, "1"); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return tx.Commit()
+}
+
+// upgradeColumnTimes rewrites the times in one column in UTC
+func upgradeColumnTimes(tx *sql.Tx, table, column string) error {
+	// Times are read in full first; the single write connection is busy with
+	// the query until its rows are closed
+	rows, err := tx.Query(fmt.Sprintf("SELECT rowid, %s FROM %s WHERE %s IS NOT NULL", column, table, column))
+	if err != nil {
+		return err
+	}
+	type storedTime struct {
+		rowID int64
+		at    time.Time
+	}
+	var times []storedTime
+	for rows.Next() {
+		var t storedTime
+		if err := rows.Scan(&t.rowID, &t.at); err != nil {
+			rows.Close()
+			return err
+		}
+		times = append(times, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", table, column))
+	if err != nil {
+		return err
+	}
+	defer update.Close()
+	for _, t := range times {
+		if _, err := update.Exec(t.at.UTC(), t.rowID); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// Get project ID
-		var pid int64
-		if err := tx.QueryRow(querySelectProjectID, conv.ProjectPath).Scan(&pid); err != nil {
-			return fmt.Errorf("failed to get project ID: %w", err)
+// ftsQueries returns the statements creating the full-text index for a
+// plaintext or an encrypted database
+func ftsQueries(encrypted bool) []string {
+	if encrypted {
+		return []string{
+			queryCreateBlindFTS,
+			queryCreateBlindDeleteTrigger,
+			queryCreateCodeSnippetsBlindFTS,
+			queryCreateCodeSnippetsBlindDeleteTrigger,
 		}
-		projectID = &pid
-		conv.ProjectID = pid
+	}
+	return []string{
+		queryCreateMessagesFTS,
+		queryCreateMessagesInsertTrigger,
+		queryCreateMessagesDeleteTrigger,
+		queryCreateMessagesUpdateTrigger,
+		queryCreateCodeSnippetsFTS,
+		queryCreateCodeSnippetsInsertTrigger,
+		queryCreateCodeSnippetsDeleteTrigger,
+	}
+}
+
+func (s *SQLiteStore) SaveConversation(conv *models.Conversation) error {
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertConversationTx(tx, conv); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceConversationBySessionID saves a conversation, replacing the contents of
+// any existing conversation with the same session ID instead of adding a
+// duplicate. The existing row keeps its ID and any tags added since import.
+// It reports whether an existing conversation was replaced.
+func (s *SQLiteStore) ReplaceConversationBySessionID(conv *models.Conversation) (bool, error) {
+	if conv.SessionID == "" {
+		return false, s.SaveConversation(conv)
+	}
+
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var existingID int64
+	var existingTags sql.NullString
+	err = tx.QueryRow(querySelectConversationIDBySession, conv.SessionID).Scan(&existingID, &existingTags)
+	if err == sql.ErrNoRows {
+		if err := s.insertConversationTx(tx, conv); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	if existingTags.String != "" {
+		var tags []string
+		json.Unmarshal([]byte(existingTags.String), &tags)
+		conv.Tags = mergeTags(tags, conv.Tags)
+	}
+
+	projectID, err := upsertProjectTx(tx, conv)
+	if err != nil {
+		return false, err
+	}
+
+	tagsJSON, _ := json.Marshal(conv.Tags)
+	if _, err := tx.Exec(
+		queryReplaceConversation,
+		s.redactor.Redact(conv.Title), conv.Tool, conv.Project, projectID, string(tagsJSON),
+		conv.SourcePath, conv.AuditShard, s.cipher.seal(s.redactor.Redact(conv.RawJSON)), conv.WorkDir,
+		conv.IngestedTo, conv.CreatedAt.UTC(), conv.UpdatedAt.UTC(), existingID,
+	); err != nil {
+		return false, fmt.Errorf("failed to update conversation: %w", err)
+	}
+	conv.ID = existingID
+
+	if _, err := tx.Exec(queryDeleteMessagesByConversation, existingID); err != nil {
+		return false, fmt.Errorf("failed to delete old messages: %w", err)
+	}
+	if err := s.insertMessagesTx(tx, existingID, conv.Messages); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// AppendMessages adds messages to the conversation with the given session ID
+// and records that it now holds its source file up to ingestedTo. It returns
+// false, without writing anything, if no such conversation exists.
+func (s *SQLiteStore) AppendMessages(sessionID string, messages []models.Message, ingestedTo int64) (bool, error) {
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var convID int64
+	var tags sql.NullString
+	err = tx.QueryRow(querySelectConversationIDBySession, sessionID).Scan(&convID, &tags)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	if err := s.insertMessagesTx(tx, convID, messages); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(queryTouchConversation, time.Now().UTC(), ingestedTo, convID); err != nil {
+		return false, fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// IngestedTo returns how far into its source file the conversation with the
+// given session ID has been written, and whether such a conversation exists.
+// Conversations not written by the capture daemon are at 0.
+func (s *SQLiteStore) IngestedTo(sessionID string) (int64, bool, error) {
+	var offset int64
+	err := s.readDB.QueryRow(querySelectIngestedTo, sessionID).Scan(&offset)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to look up session: %w", err)
+	}
+	return offset, true, nil
+}
+
+// insertConversationTx inserts a conversation and its messages within tx
+func (s *SQLiteStore) insertConversationTx(tx *sql.Tx, conv *models.Conversation) error {
+	projectID, err := upsertProjectTx(tx, conv)
+	if err != nil {
+		return err
 	}
 
 	tagsJSON, _ := json.Marshal(conv.Tags)
 
 	result, err := tx.Exec(
 		queryInsertConversation,
-		conv.Title, conv.Tool, conv.Project, projectID, string(tagsJSON),
-		conv.SessionID, conv.SourcePath, conv.AuditShard, conv.RawJSON,
-		conv.CreatedAt, conv.UpdatedAt,
+		s.redactor.Redact(conv.Title), conv.Tool, conv.Project, projectID, string(tagsJSON),
+		conv.SessionID, conv.SourcePath, conv.AuditShard, s.cipher.seal(s.redactor.Redact(conv.RawJSON)), conv.WorkDir,
+		conv.IngestedTo, conv.CreatedAt.UTC(), conv.UpdatedAt.UTC(),
 	)
 	if err != nil {
 		return err
@@ -154,21 +561,74 @@ func (s *SQLiteStore) SaveConversation(conv *models.Conversation) error {
 	}
 	conv.ID = convID
 
-	for i := range conv.Messages {
+	return s.insertMessagesTx(tx, convID, conv.Messages)
+}
+
+// upsertProjectTx makes sure the conversation's project exists and returns its ID
+func upsertProjectTx(tx *sql.Tx, conv *models.Conversation) (*int64, error) {
+	if conv.ProjectPath == "" {
+		return nil, nil
+	}
+
+	// Insert project if it doesn't exist
+	if _, err := tx.Exec(queryInsertProject, conv.ProjectPath); err != nil {
+		return nil, fmt.Errorf("failed to insert project: %w", err)
+	}
+
+	var pid int64
+	if err := tx.QueryRow(querySelectProjectID, conv.ProjectPath).Scan(&pid); err != nil {
+		return nil, fmt.Errorf("failed to get project ID: %w", err)
+	}
+	conv.ProjectID = pid
+	return &pid, nil
+}
+
+// insertMessagesTx inserts messages for a conversation within tx
+func (s *SQLiteStore) insertMessagesTx(tx *sql.Tx, convID int64, messages []models.Message) error {
+	for i := range messages {
+		messages[i].Content = s.redactor.Redact(messages[i].Content)
 		result, err := tx.Exec(
 			queryInsertMessage,
-			convID, conv.Messages[i].Role, conv.Messages[i].Content,
-			conv.Messages[i].Timestamp, conv.Messages[i].TokenCount,
+			convID, messages[i].Role, s.cipher.seal(messages[i].Content),
+			messages[i].Timestamp.UTC(), messages[i].TokenCount,
 		)
 		if err != nil {
 			return err
 		}
 		msgID, _ := result.LastInsertId()
-		conv.Messages[i].ID = msgID
-		conv.Messages[i].ConversationID = convID
+		messages[i].ID = msgID
+		messages[i].ConversationID = convID
+
+		if s.cipher != nil {
+			if _, err := tx.Exec(queryInsertBlindTokens, msgID, s.cipher.blindText(messages[i].Content)); err != nil {
+				return fmt.Errorf("failed to index message: %w", err)
+			}
+		}
+		if err := s.insertCodeSnippetsTx(tx, convID, messages[i]); err != nil {
+			return err
+		}
+		for _, f := range messages[i].Files {
+			if _, err := tx.Exec(queryInsertConversationFile, convID, msgID, f.Path, f.Access); err != nil {
+				return fmt.Errorf("failed to save file reference: %w", err)
+			}
+		}
 	}
+	return nil
+}
 
-	return tx.Commit()
+// mergeTags appends tags from extra that are not already present in base
+func mergeTags(base, extra []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, tag := range base {
+		seen[tag] = true
+	}
+	for _, tag := range extra {
+		if !seen[tag] {
+			base = append(base, tag)
+			seen[tag] = true
+		}
+	}
+	return base
 }
 
 func (s *SQLiteStore) GetConversationBySessionID(sessionID string) (*models.Conversation, error) {
@@ -183,7 +643,7 @@ func (s *SQLiteStore) GetConversationBySessionID(sessionID string) (*models.Conv
 	err := s.readDB.QueryRow(query, sessionID).Scan(
 		&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &projectID, &tagsJSON,
 		&sessionIDVal, &sourcePath, &auditShard, &rawJSON,
-		&conv.CreatedAt, &conv.UpdatedAt,
+		localTime(&conv.CreatedAt), localTime(&conv.UpdatedAt),
 	)
 
 	if err == sql.ErrNoRows {
@@ -200,7 +660,9 @@ func (s *SQLiteStore) GetConversationBySessionID(sessionID string) (*models.Conv
 	conv.SessionID = sessionIDVal.String
 	conv.SourcePath = sourcePath.String
 	conv.AuditShard = auditShard.String
-	conv.RawJSON = rawJSON.String
+	if conv.RawJSON, err = s.cipher.open(rawJSON.String); err != nil {
+		return nil, err
+	}
 
 	if tagsJSON != "" {
 		json.Unmarshal([]byte(tagsJSON), &conv.Tags)
@@ -218,8 +680,8 @@ func (s *SQLiteStore) GetConversation(id int64) (*models.Conversation, error) {
 	err := s.readDB.QueryRow(
 		querySelectConversation, id,
 	).Scan(&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &projectID, &tagsJSON,
-		&sessionID, &sourcePath, &auditShard, &rawJSON,
-		&conv.CreatedAt, &conv.UpdatedAt)
+		&sessionID, &sourcePath, &auditShard, &rawJSON, &conv.WorkDir,
+		localTime(&conv.CreatedAt), localTime(&conv.UpdatedAt))
 
 	if err != nil {
 		return nil, err
@@ -232,7 +694,9 @@ func (s *SQLiteStore) GetConversation(id int64) (*models.Conversation, error) {
 	conv.SessionID = sessionID.String
 	conv.SourcePath = sourcePath.String
 	conv.AuditShard = auditShard.String
-	conv.RawJSON = rawJSON.String
+	if conv.RawJSON, err = s.cipher.open(rawJSON.String); err != nil {
+		return nil, err
+	}
 
 	if tagsJSON != "" {
 		json.Unmarshal([]byte(tagsJSON), &conv.Tags)
@@ -249,16 +713,47 @@ func (s *SQLiteStore) GetConversation(id int64) (*models.Conversation, error) {
 	conv.Messages = []models.Message{}
 	for rows.Next() {
 		var msg models.Message
-		err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.Timestamp, &msg.TokenCount)
+		err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, localTime(&msg.Timestamp), &msg.TokenCount)
 		if err != nil {
 			return nil, err
 		}
+		if msg.Content, err = s.cipher.open(msg.Content); err != nil {
+			return nil, err
+		}
 		conv.Messages = append(conv.Messages, msg)
 	}
 
 	return conv, nil
 }
 
+// GetMessages returns up to limit messages of a conversation in order,
+// skipping the first offset, along with how many it has in total
+func (s *SQLiteStore) GetMessages(convID int64, offset, limit int) ([]models.Message, int, error) {
+	var total int
+	if err := s.readDB.QueryRow(queryCountConversationMessages, convID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.readDB.Query(querySelectMessagesPage, convID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		var msg models.Message
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, localTime(&msg.Timestamp), &msg.TokenCount); err != nil {
+			return nil, 0, err
+		}
+		if msg.Content, err = s.cipher.open(msg.Content); err != nil {
+			return nil, 0, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, total, rows.Err()
+}
+
 func (s *SQLiteStore) ListConversations(limit, offset int, filter map[string]string) ([]models.Conversation, error) {
 	query := `SELECT id, title, tool, project, tags, created_at, updated_at FROM conversations WHERE 1=1`
 	args := []interface{}{}
@@ -285,7 +780,7 @@ func (s *SQLiteStore) ListConversations(limit, offset int, filter map[string]str
 	for rows.Next() {
 		var conv models.Conversation
 		var tagsJSON string
-		err := rows.Scan(&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &tagsJSON, &conv.CreatedAt, &conv.UpdatedAt)
+		err := rows.Scan(&conv.ID, &conv.Title, &conv.Tool, &conv.Project, &tagsJSON, localTime(&conv.CreatedAt), localTime(&conv.UpdatedAt))
 		if err != nil {
 			return nil, err
 		}
@@ -299,37 +794,7 @@ func (s *SQLiteStore) ListConversations(limit, offset int, filter map[string]str
 }
 
 func (s *SQLiteStore) Search(query string, limit int) ([]models.SearchResult, error) {
-	rows, err := s.readDB.Query(querySearchConversations, query, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var results []models.SearchResult
-	for rows.Next() {
-		var result models.SearchResult
-		var tagsJSON string
-		var content string
-
-		err := rows.Scan(
-			&result.Conversation.ID, &result.Conversation.Title,
-			&result.Conversation.Tool, &result.Conversation.Project,
-			&tagsJSON, &result.Conversation.CreatedAt,
-			&result.Conversation.UpdatedAt, &content, &result.Score,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		if tagsJSON != "" {
-			json.Unmarshal([]byte(tagsJSON), &result.Conversation.Tags)
-		}
-
-		result.Snippet = truncateContent(content, 200)
-		results = append(results, result)
-	}
-
-	return results, nil
+	return s.SearchPage(query, ConversationFilter{}, 0, limit)
 }
 
 func (s *SQLiteStore) GetStats() (*models.ConversationStats, error) {
@@ -391,6 +856,12 @@ func (s *SQLiteStore) DeleteConversation(id int64) error {
 	return err
 }
 
+// closeDBs closes the connections without optimizing, for stores that failed to open
+func (s *SQLiteStore) closeDBs() {
+	s.readDB.Close()
+	s.writeDB.Close()
+}
+
 func (s *SQLiteStore) Close() error {
 	var errs []error
 
@@ -427,7 +898,30 @@ func (s *SQLiteStore) UpdateConversation(conv *models.Conversation) error {
 
 	_, err := s.writeDB.Exec(
 		`UPDATE conversations SET title = ?, tool = ?, project = ?, tags = ?, updated_at = ? WHERE id = ?`,
-		conv.Title, conv.Tool, conv.Project, string(tagsJSON), conv.UpdatedAt, conv.ID,
+		s.redactor.Redact(conv.Title), conv.Tool, conv.Project, string(tagsJSON), conv.UpdatedAt.UTC(), conv.ID,
 	)
 	return err
+}
+
+// RetargetAuditShards points conversations captured to a shard that audit GC
+// replaced at the shard now holding their records. refs maps old shard names
+// to new ones; an empty name clears the reference. It returns how many
+// conversations were updated.
+func (s *SQLiteStore) RetargetAuditShards(refs map[string]string) (int64, error) {
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var updated int64
+	for from, to := range refs {
+		result, err := tx.Exec(queryUpdateAuditShard, to, from)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		updated += n
+	}
+	return updated, tx.Commit()
 }
\ No newline at end of file
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"database/sql"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -102,4 +105,322 @@ func TestSQLiteStore(t *testing.T) {
 			t.Error("Should have at least one message in stats")
 		}
 	})
+}
+
+func TestReplaceConversationBySessionID(t *testing.T) {
+	store, err := NewSQLiteStore(t.TempDir() + "/replace.db")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	conv := &models.Conversation{
+		Title:     "First parse",
+		Tool:      "claude-code",
+		SessionID: "session-1",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Messages: []models.Message{
+			{Role: "user", Content: "original question", Timestamp: time.Now()},
+		},
+	}
+
+	replaced, err := store.ReplaceConversationBySessionID(conv)
+	if err != nil {
+		t.Fatalf("ReplaceConversationBySessionID() error = %v", err)
+	}
+	if replaced {
+		t.Error("first save should not report a replacement")
+	}
+	firstID := conv.ID
+
+	// Tag the conversation the way a user would after import
+	conv.Tags = []string{"keep-me"}
+	if err := store.UpdateConversation(conv); err != nil {
+		t.Fatal(err)
+	}
+
+	reparsed := &models.Conversation{
+		Title:     "Second parse",
+		Tool:      "claude-code",
+		SessionID: "session-1",
+		Tags:      []string{"claude-code"},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Messages: []models.Message{
+			{Role: "user", Content: "original question", Timestamp: time.Now()},
+			{Role: "assistant", Content: "fixed answer", Timestamp: time.Now()},
+		},
+	}
+
+	replaced, err = store.ReplaceConversationBySessionID(reparsed)
+	if err != nil {
+		t.Fatalf("ReplaceConversationBySessionID() error = %v", err)
+	}
+	if !replaced {
+		t.Error("second save should replace the existing conversation")
+	}
+	if reparsed.ID != firstID {
+		t.Errorf("replaced conversation ID = %d, want %d", reparsed.ID, firstID)
+	}
+
+	got, err := store.GetConversation(firstID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Second parse" || len(got.Messages) != 2 {
+		t.Errorf("got title %q with %d messages", got.Title, len(got.Messages))
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "keep-me" {
+		t.Errorf("tags = %v, want user tag kept", got.Tags)
+	}
+
+	stats, err := store.GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalConversations != 1 {
+		t.Errorf("TotalConversations = %d, want 1", stats.TotalConversations)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	redactor, err := NewRedactor([]string{`sk-[a-z0-9]+`}, "")
+	if err != nil {
+		t.Fatalf("Failed to create redactor: %v", err)
+	}
+	store.SetRedactor(redactor)
+
+	conv := &models.Conversation{
+		Title:   "Key sk-abc123",
+		Tool:    "test-tool",
+		RawJSON: `{"key":"sk-abc123"}`,
+		Messages: []models.Message{
+			{Role: "user", Content: "use sk-abc123 please", Timestamp: time.Now()},
+		},
+	}
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	saved, err := store.GetConversation(conv.ID)
+	if err != nil {
+		t.Fatalf("Failed to get conversation: %v", err)
+	}
+	if saved.Title != "Key [REDACTED]" || saved.Messages[0].Content != "use [REDACTED] please" {
+		t.Errorf("Expected the key to be redacted, got %q and %q", saved.Title, saved.Messages[0].Content)
+	}
+	if results, _ := store.Search("abc123", 10); len(results) != 0 {
+		t.Errorf("Expected redacted text not to be searchable, got %d results", len(results))
+	}
+
+	if _, err := NewRedactor([]string{"("}, ""); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+func TestListConversationsPage(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Two conversations share a creation time, so pages must break ties by ID
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, created := range []time.Time{base, base.Add(time.Hour), base.Add(time.Hour), base.Add(2 * time.Hour)} {
+		tags := []string{"even"}
+		if i%2 == 1 {
+			tags = []string{"odd"}
+		}
+		conv := &models.Conversation{
+			Title: "conv", Tool: "test-tool", Tags: tags, CreatedAt: created, UpdatedAt: created,
+			Messages: []models.Message{{Role: "user", Content: "hello", Timestamp: created}},
+		}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+	}
+
+	var ids []int64
+	var after int64
+	for {
+		page, err := store.ListConversationsPage(ConversationFilter{}, after, 1)
+		if err != nil {
+			t.Fatalf("Failed to list: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		ids = append(ids, page[0].ID)
+		after = page[0].ID
+	}
+	if len(ids) != 4 || ids[0] != 4 || ids[1] != 3 || ids[2] != 2 || ids[3] != 1 {
+		t.Errorf("Expected every conversation once, newest first, got %v", ids)
+	}
+
+	odd, err := store.ListConversationsPage(ConversationFilter{Tag: "odd"}, 0, 10)
+	if err != nil || len(odd) != 2 {
+		t.Errorf("Expected two conversations tagged odd, got %d (%v)", len(odd), err)
+	}
+
+	store.DeleteConversation(2)
+	if _, err := store.ListConversationsPage(ConversationFilter{}, 2, 10); err != ErrStaleCursor {
+		t.Errorf("Expected ErrStaleCursor, got %v", err)
+	}
+}
+
+func TestUpdateTags(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	conv := &models.Conversation{Title: "conv", Tool: "test-tool", Tags: []string{"a"}, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	tags, err := store.UpdateTags(conv.ID, func(tags []string) []string { return append(tags, "b", "a") })
+	if err != nil || len(tags) != 2 || tags[1] != "b" {
+		t.Fatalf("Expected tags a and b, got %v (%v)", tags, err)
+	}
+
+	counts, err := store.ListTags()
+	if err != nil || len(counts) != 2 || counts[0] != (TagCount{Name: "a", Count: 1}) {
+		t.Errorf("Unexpected tag counts %v (%v)", counts, err)
+	}
+
+	if _, err := store.UpdateTags(999, func(tags []string) []string { return tags }); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for a missing conversation, got %v", err)
+	}
+}
+
+func TestRetargetAuditShards(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	shards := []string{"shard_a.jsonl", "shard_b.jsonl", "shard_c.jsonl"}
+	ids := make([]int64, len(shards))
+	for i, shard := range shards {
+		conv := &models.Conversation{Title: shard, Tool: "test-tool", AuditShard: shard, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		if err := store.SaveConversation(conv); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+		ids[i] = conv.ID
+	}
+
+	updated, err := store.RetargetAuditShards(map[string]string{
+		"shard_a.jsonl": "shard_a.jsonl.zst",
+		"shard_b.jsonl": "",
+	})
+	if err != nil || updated != 2 {
+		t.Fatalf("RetargetAuditShards() = %d, %v; want 2 updated", updated, err)
+	}
+
+	for i, want := range []string{"shard_a.jsonl.zst", "", "shard_c.jsonl"} {
+		conv, err := store.GetConversation(ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conv.AuditShard != want {
+			t.Errorf("conversation %d audit shard = %q, want %q", i, conv.AuditShard, want)
+		}
+	}
+}
+
+func TestAddColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	// Databases from older versions have no work_dir column
+	if _, err := store.writeDB.Exec(`ALTER TABLE conversations DROP COLUMN work_dir`); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	conv := &models.Conversation{Title: "t", Tool: "test-tool", WorkDir: "/home/me/api", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	if got, err := store.GetConversation(conv.ID); err != nil || got.WorkDir != "/home/me/api" {
+		t.Errorf("GetConversation() = %+v, %v; want work dir /home/me/api", got, err)
+	}
+}
+
+func TestUpgradeTimes(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	base := time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC)
+	conv := &models.Conversation{Title: "t", Tool: "test-tool", CreatedAt: base, UpdatedAt: base,
+		Messages: []models.Message{{Role: "user", Content: "Hello", Timestamp: base}}}
+	if err := store.SaveConversation(conv); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	store.Close()
+
+	// Older versions stored times in Go's format, in the zone they were recorded in
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cest := base.In(time.FixedZone("CEST", 2*60*60))
+	if _, err := db.Exec(`UPDATE messages SET timestamp = ?`, cest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`DELETE FROM storage_meta WHERE key = ?`, metaTimesUTC); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	var stored string
+	if err := store.readDB.QueryRow(`SELECT CAST(timestamp AS TEXT) FROM messages`).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != "2025-06-01 23:00:00+00:00" {
+		t.Errorf("Expected the time in UTC, got %q", stored)
+	}
+	spans, err := store.SessionSpans(ConversationFilter{}, time.Time{})
+	if err != nil || len(spans) != 1 || !spans[0].Start.Equal(base) {
+		t.Errorf("Unexpected spans %+v (%v)", spans, err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	content := strings.Repeat("filler words here ", 30) + "the Migration failed " + strings.Repeat("more text after ", 30)
+
+	got := snippet(content, `"migration" AND fail*`)
+	if !strings.Contains(got, "Migration failed") || !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected a snippet around the match, got %q", got)
+	}
+	if got := snippet(content, "absent"); !strings.HasPrefix(got, "filler") {
+		t.Errorf("Expected the start without a match, got %q", got)
+	}
+	if got := snippet("short", "absent"); got != "short" {
+		t.Errorf("Expected short content unchanged, got %q", got)
+	}
 }
\ No newline at end of file
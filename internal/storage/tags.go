@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// TagCount is a tag and the number of conversations that have it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// UpdateTags replaces the tags of a conversation with the result of update,
+// which is given the current tags, and returns the new tags. The read and
+// write happen in one transaction so concurrent updates are not lost. It
+// returns sql.ErrNoRows if the conversation does not exist.
+func (s *SQLiteStore) UpdateTags(id int64, update func(tags []string) []string) ([]string, error) {
+	tx, err := s.writeDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tagsJSON sql.NullString
+	if err := tx.QueryRow(querySelectTags, id).Scan(&tagsJSON); err != nil {
+		return nil, err
+	}
+	var tags []string
+	if tagsJSON.String != "" {
+		json.Unmarshal([]byte(tagsJSON.String), &tags)
+	}
+
+	tags = mergeTags(nil, update(tags))
+	if tags == nil {
+		tags = []string{}
+	}
+	data, _ := json.Marshal(tags)
+	if _, err := tx.Exec(queryUpdateTags, string(data), time.Now().UTC(), id); err != nil {
+		return nil, err
+	}
+	return tags, tx.Commit()
+}
+
+// ListTags returns every tag in use, by name, with how many conversations have it
+func (s *SQLiteStore) ListTags() ([]TagCount, error) {
+	rows, err := s.readDB.Query(queryCountTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
\ No newline at end of file
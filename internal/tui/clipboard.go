@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// copyToClipboard copies text to the system clipboard. Over SSH, or when no
+// clipboard tool is installed, the terminal is asked to copy it with an OSC 52
+// escape sequence instead.
+func copyToClipboard(text string) error {
+	if os.Getenv("SSH_TTY") == "" {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
\ No newline at end of file
@@ -1,9 +1,12 @@
 package tui
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -31,6 +34,7 @@ type enhancedModel struct {
 	viewport         viewport.Model
 	commandInput     textinput.Model
 	selectedConv     *models.Conversation
+	snippets         []models.CodeSnippet
 	width            int
 	height           int
 	ready            bool
@@ -238,6 +242,9 @@ func (m *enhancedModel) executeCommand(cmdStr string) enhancedModel {
 			m.statusMessage = "Usage: :import <filename>"
 		}
 
+	case "yank", "y":
+		m.yankSnippet(args)
+
 	case "delete":
 		if m.selectedConv != nil {
 			m.statusMessage = "Deleting conversation..."
@@ -332,10 +339,45 @@ func (m *enhancedModel) runDelete(id int64) {
 	} else {
 		m.statusMessage = "Conversation deleted"
 		m.selectedConv = nil
+		m.snippets = nil
 		m.refreshList()
 	}
 }
 
+// yankSnippet copies a code snippet to the clipboard: the one with the ID in
+// args, or else the last one of the selected conversation
+func (m *enhancedModel) yankSnippet(args []string) {
+	var snippet *models.CodeSnippet
+	if len(args) > 0 {
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			m.statusMessage = "Usage: :yank [snippet id]"
+			return
+		}
+		snippet, err = m.store.GetCodeSnippet(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			m.statusMessage = fmt.Sprintf("Snippet %d not found", id)
+			return
+		}
+		if err != nil {
+			m.statusMessage = fmt.Sprintf("Yank failed: %v", err)
+			return
+		}
+	} else {
+		if len(m.snippets) == 0 {
+			m.statusMessage = "No code snippets in the selected conversation"
+			return
+		}
+		snippet = &m.snippets[len(m.snippets)-1]
+	}
+
+	if err := copyToClipboard(snippet.Code); err != nil {
+		m.statusMessage = fmt.Sprintf("Yank failed: %v", err)
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Copied snippet #%d (%d lines)", snippet.ID, strings.Count(snippet.Code, "\n")+1)
+}
+
 func (m *enhancedModel) showStats() {
 	conversations, _ := m.store.ListConversations(0, 0, nil)
 
@@ -379,6 +421,7 @@ Vim-style Commands (press : to enter command mode):
   :export <file>  - Export conversations
   :import <file>  - Import conversations
   :delete         - Delete selected conversation
+  :yank [id]      - Copy a code snippet (default: the last one shown)
   :help           - Show this help
 
 Normal Mode Keys:
@@ -430,7 +473,13 @@ func (m *enhancedModel) updateViewport() {
 
 	content.WriteString("\n" + strings.Repeat("─", 40) + "\n\n")
 
+	// Shell commands go before the first message sent after they started
+	commands, _ := m.store.ConversationShellCommands(m.selectedConv.ID, storage.ShellWindow)
+	next := 0
 	for _, msg := range m.selectedConv.Messages {
+		for ; next < len(commands) && !msg.Timestamp.IsZero() && commands[next].StartedAt.Before(msg.Timestamp); next++ {
+			content.WriteString(renderShellCommand(commands[next]))
+		}
 		roleStyle := lipgloss.NewStyle().Bold(true)
 		if msg.Role == "user" {
 			roleStyle = roleStyle.Foreground(lipgloss.Color("#00FF00"))
@@ -443,11 +492,40 @@ func (m *enhancedModel) updateViewport() {
 		content.WriteString(msg.Content)
 		content.WriteString("\n\n")
 	}
+	for ; next < len(commands); next++ {
+		content.WriteString(renderShellCommand(commands[next]))
+	}
+
+	m.snippets, _ = m.store.ConversationCodeSnippets(m.selectedConv.ID)
+	if len(m.snippets) > 0 {
+		content.WriteString(strings.Repeat("─", 40) + "\n\n")
+		content.WriteString(titleStyle.Render("Code snippets"))
+		content.WriteString(" (:yank <id> to copy)\n\n")
+		for _, s := range m.snippets {
+			language := s.Language
+			if language == "" {
+				language = "text"
+			}
+			content.WriteString(fmt.Sprintf("  #%d  %s, lines %d-%d\n", s.ID, language, s.StartLine, s.EndLine))
+		}
+	}
 
 	m.viewport.SetContent(content.String())
 	m.viewport.GotoTop()
 }
 
+// renderShellCommand renders a command recorded by the shell hook, with its
+// exit code if it failed
+func renderShellCommand(cmd models.ShellCommand) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+	label := fmt.Sprintf("Shell (%s in %s):", cmd.StartedAt.Local().Format("15:04:05"), cmd.Dir)
+	result := style.Render("$ " + cmd.Command)
+	if cmd.ExitCode != 0 {
+		result += lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render(fmt.Sprintf("  exit %d", cmd.ExitCode))
+	}
+	return lipgloss.NewStyle().Bold(true).Render(label) + "\n" + result + "\n\n"
+}
+
 func (m enhancedModel) View() string {
 	if !m.ready {
 		return "\n  Initializing..."
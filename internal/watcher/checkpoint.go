@@ -0,0 +1,195 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Checkpoint records how far a session file has been captured
+type Checkpoint struct {
+	Path      string    `json:"path"`
+	Inode     uint64    `json:"inode"`
+	Size      int64     `json:"size"`   // Largest size seen, to detect truncation
+	Offset    int64     `json:"offset"` // Everything before this was captured
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckpointStore persists per-file checkpoints so tailing resumes where it
+// stopped instead of re-reading whole files. Lines may be committed out of
+// order; a checkpoint only advances over lines that were all committed.
+type CheckpointStore struct {
+	path    string
+	entries map[string]*Checkpoint
+	done    map[string]map[int64]int64 // committed ranges past each offset, start -> end
+	dirty   bool
+	mu      sync.Mutex
+	saveMu  sync.Mutex // serializes writes of the checkpoint file
+}
+
+// OpenCheckpointStore loads checkpoints from path. A missing file is an empty store.
+func OpenCheckpointStore(path string) (*CheckpointStore, error) {
+	store := &CheckpointStore{
+		path:    path,
+		entries: make(map[string]*Checkpoint),
+		done:    make(map[string]map[int64]int64),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+
+	var entries []*Checkpoint
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoints %s: %w", path, err)
+	}
+	for _, entry := range entries {
+		store.entries[entry.Path] = entry
+	}
+	return store, nil
+}
+
+// Get returns the checkpoint for a file
+func (c *CheckpointStore) Get(path string) (Checkpoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[path]
+	if !ok {
+		return Checkpoint{}, false
+	}
+	return *entry, true
+}
+
+// Resume returns the offset to start tailing a file from. It is the saved
+// offset if the file is still the same one and has not shrunk, otherwise 0.
+func (c *CheckpointStore) Resume(path string, info os.FileInfo) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inode := fileInode(info)
+	entry, ok := c.entries[path]
+	if ok && (entry.Inode == 0 || inode == 0 || entry.Inode == inode) &&
+		info.Size() >= entry.Size && info.Size() >= entry.Offset {
+		delete(c.done, path)
+		return entry.Offset
+	}
+
+	c.resetLocked(path, inode)
+	return 0
+}
+
+// Reset starts a file's checkpoint over, for a file that was truncated or replaced
+func (c *CheckpointStore) Reset(path string, info os.FileInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resetLocked(path, fileInode(info))
+}
+
+func (c *CheckpointStore) resetLocked(path string, inode uint64) {
+	c.entries[path] = &Checkpoint{Path: path, Inode: inode, UpdatedAt: time.Now()}
+	delete(c.done, path)
+	c.dirty = true
+}
+
+// Observe records the current size of a file being tailed
+func (c *CheckpointStore) Observe(path string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[path]; ok && size > entry.Size {
+		entry.Size = size
+		c.dirty = true
+	}
+}
+
+// Commit marks the bytes from start to end of a file as captured
+func (c *CheckpointStore) Commit(path string, start, end int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[path]
+	if !ok {
+		entry = &Checkpoint{Path: path}
+		c.entries[path] = entry
+	}
+	if end <= entry.Offset {
+		return
+	}
+
+	done := c.done[path]
+	if done == nil {
+		done = make(map[int64]int64)
+		c.done[path] = done
+	}
+	done[start] = end
+
+	// Advance over the contiguous committed prefix
+	for {
+		next, ok := done[entry.Offset]
+		if !ok {
+			break
+		}
+		delete(done, entry.Offset)
+		entry.Offset = next
+		c.dirty = true
+	}
+	if entry.Offset > entry.Size {
+		entry.Size = entry.Offset
+	}
+	entry.UpdatedAt = time.Now()
+}
+
+// Forget drops the checkpoint of a removed file
+func (c *CheckpointStore) Forget(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[path]; ok {
+		delete(c.entries, path)
+		delete(c.done, path)
+		c.dirty = true
+	}
+}
+
+// Save writes the checkpoints to disk if they changed
+func (c *CheckpointStore) Save() error {
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	entries := make([]*Checkpoint, 0, len(c.entries))
+	for _, entry := range c.entries {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	// Write atomically
+	tmpFile := c.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return os.Rename(tmpFile, c.path)
+}
\ No newline at end of file
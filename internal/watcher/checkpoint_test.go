@@ -0,0 +1,89 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointCommitContiguous(t *testing.T) {
+	store, err := OpenCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.json"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	// Lines committed out of order only advance past a gap once it is filled
+	store.Commit("/s.jsonl", 10, 20)
+	if cp, _ := store.Get("/s.jsonl"); cp.Offset != 0 {
+		t.Errorf("Expected offset 0 with a gap, got %d", cp.Offset)
+	}
+	store.Commit("/s.jsonl", 0, 10)
+	if cp, _ := store.Get("/s.jsonl"); cp.Offset != 20 {
+		t.Errorf("Expected offset 20, got %d", cp.Offset)
+	}
+
+	// Recommitting old lines does not move the checkpoint back
+	store.Commit("/s.jsonl", 0, 10)
+	if cp, _ := store.Get("/s.jsonl"); cp.Offset != 20 {
+		t.Errorf("Expected offset to stay 20, got %d", cp.Offset)
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "checkpoints.json")
+	session := filepath.Join(dir, "a.jsonl")
+	os.WriteFile(session, []byte("one\ntwo\n"), 0644)
+
+	store, err := OpenCheckpointStore(storePath)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	info, _ := os.Stat(session)
+	if offset := store.Resume(session, info); offset != 0 {
+		t.Fatalf("Expected new file to start at 0, got %d", offset)
+	}
+	store.Commit(session, 0, 4)
+	if err := store.Save(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	// A reopened store resumes after the committed line
+	store, err = OpenCheckpointStore(storePath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	info, _ = os.Stat(session)
+	if offset := store.Resume(session, info); offset != 4 {
+		t.Errorf("Expected resume at 4, got %d", offset)
+	}
+
+	// A truncated file starts over
+	os.WriteFile(session, []byte("x\n"), 0644)
+	info, _ = os.Stat(session)
+	if offset := store.Resume(session, info); offset != 0 {
+		t.Errorf("Expected truncated file to start at 0, got %d", offset)
+	}
+}
+
+func TestCheckpointResumeReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	session := filepath.Join(dir, "a.jsonl")
+	os.WriteFile(session, []byte("one\n"), 0644)
+
+	store, _ := OpenCheckpointStore(filepath.Join(dir, "checkpoints.json"))
+	info, _ := os.Stat(session)
+	store.Resume(session, info)
+	store.Commit(session, 0, 4)
+
+	// Keep the old file alive so the replacement gets a different inode
+	os.Rename(session, session+".old")
+	os.WriteFile(session, []byte("other line\n"), 0644)
+	info, _ = os.Stat(session)
+	if fileInode(info) == 0 {
+		t.Skip("File identity not available on this platform")
+	}
+	if offset := store.Resume(session, info); offset != 0 {
+		t.Errorf("Expected replaced file to start at 0, got %d", offset)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package watcher
+
+import "os"
+
+// fileInode returns 0 where inode numbers are not available; checkpoints
+// then rely on the file size alone
+func fileInode(info os.FileInfo) uint64 {
+	return 0
+}
\ No newline at end of file
@@ -0,0 +1,319 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotWatched is returned when unwatching a directory that is not watched
+var ErrNotWatched = errors.New("directory is not watched")
+
+// watchTree is a directory watched for session files
+type watchTree struct {
+	root      string
+	pattern   string
+	tool      string // empty to detect the tool from each file's path
+	recursive bool
+}
+
+// contains reports whether path is inside the tree (or is its root)
+func (t *watchTree) contains(path string) bool {
+	rel, err := filepath.Rel(t.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return t.recursive || rel == "." || !strings.Contains(rel, string(filepath.Separator))
+}
+
+// matches reports whether path is a session file of the tree
+func (t *watchTree) matches(path string) bool {
+	if !t.contains(path) || path == t.root {
+		return false
+	}
+	matched, _ := filepath.Match(t.pattern, filepath.Base(path))
+	return matched
+}
+
+// WatchedRoots returns the roots of every watched tree, including those
+// still waiting to be created
+func (w *SessionWatcher) WatchedRoots() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	roots := make([]string, 0, len(w.trees))
+	for _, tree := range w.trees {
+		roots = append(roots, tree.root)
+	}
+	return roots
+}
+
+// Unwatch stops watching the tree rooted at root. Session files no other tree
+// covers stop being tailed; their checkpoints are kept for a later watch.
+func (w *SessionWatcher) Unwatch(root string) error {
+	root = expandHome(root)
+	removed := &watchTree{root: root, recursive: true}
+
+	w.mu.Lock()
+	var trees []*watchTree
+	for _, tree := range w.trees {
+		if tree.root != root {
+			trees = append(trees, tree)
+		}
+	}
+	if len(trees) == len(w.trees) {
+		w.mu.Unlock()
+		return ErrNotWatched
+	}
+	w.trees = trees
+
+	var pending []*watchTree
+	for _, tree := range w.pending {
+		if tree.root != root {
+			pending = append(pending, tree)
+		}
+	}
+	w.pending = pending
+
+	for dir := range w.watchedPaths {
+		if removed.contains(dir) && !w.needsWatchLocked(dir) {
+			w.watcher.Remove(dir)
+			delete(w.watchedPaths, dir)
+		}
+	}
+
+	var stale []string
+	for path := range w.activeSessions {
+		if removed.contains(path) && w.treeForFileLocked(path) == nil {
+			stale = append(stale, path)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, path := range stale {
+		w.stopTailing(path, false)
+	}
+	return nil
+}
+
+// needsWatchLocked reports whether a remaining tree still needs a watch on dir
+func (w *SessionWatcher) needsWatchLocked(dir string) bool {
+	for _, tree := range w.trees {
+		if tree.root == dir || (tree.recursive && tree.contains(dir)) {
+			return true
+		}
+	}
+	for _, tree := range w.pending {
+		// Ancestors of missing roots are watched to notice their creation
+		ancestor := &watchTree{root: dir, recursive: true}
+		if ancestor.contains(tree.root) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTree registers a tree and starts watching it, or waits for its root to appear
+func (w *SessionWatcher) addTree(tree *watchTree) error {
+	w.mu.Lock()
+	for _, existing := range w.trees {
+		if *existing == *tree {
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	w.trees = append(w.trees, tree)
+	w.mu.Unlock()
+
+	if _, err := os.Stat(tree.root); os.IsNotExist(err) {
+		w.mu.Lock()
+		w.pending = append(w.pending, tree)
+		w.mu.Unlock()
+		return w.watchAncestor(tree.root)
+	}
+
+	return w.addDirectory(tree, tree.root)
+}
+
+// addDirectory watches dir (and, for recursive trees, every directory below
+// it) and tails the session files already in it
+func (w *SessionWatcher) addDirectory(tree *watchTree, dir string) error {
+	var files []string
+
+	if !tree.recursive {
+		if err := w.addWatch(dir); err != nil {
+			return err
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, tree.pattern))
+		if err != nil {
+			return fmt.Errorf("failed to scan directory: %w", err)
+		}
+		files = matches
+	} else {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable subtrees
+				return nil
+			}
+			if entry.IsDir() {
+				return w.addWatch(path)
+			}
+			if tree.matches(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, path := range files {
+		if err := w.startTailing(path, tree.tool); err != nil {
+			// Log error but continue with other files
+			w.countError("tail")
+			fmt.Fprintf(os.Stderr, "Failed to tail %s: %v\n", path, err)
+		}
+	}
+	return nil
+}
+
+// addWatch adds an fsnotify watch for dir unless it already has one
+func (w *SessionWatcher) addWatch(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watchedPaths[dir] {
+		return nil
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch directory %s: %w", dir, err)
+	}
+	w.watchedPaths[dir] = true
+	return nil
+}
+
+// watchAncestor watches the closest existing parent of a missing root so the
+// root's creation is noticed
+func (w *SessionWatcher) watchAncestor(root string) error {
+	dir := filepath.Dir(root)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return w.addWatch(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no existing parent directory for %s", root)
+		}
+		dir = parent
+	}
+}
+
+// handleCreate starts watching new directories and tailing new session files
+func (w *SessionWatcher) handleCreate(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if !info.IsDir() {
+		if tree := w.treeForFile(path); tree != nil {
+			w.startTailing(path, tree.tool)
+		}
+		return
+	}
+
+	w.checkPending()
+
+	w.mu.RLock()
+	var trees []*watchTree
+	for _, tree := range w.trees {
+		if tree.recursive && tree.contains(path) {
+			trees = append(trees, tree)
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, tree := range trees {
+		if err := w.addDirectory(tree, path); err != nil {
+			w.countError("watch")
+			fmt.Fprintf(os.Stderr, "Failed to watch %s: %v\n", path, err)
+		}
+	}
+}
+
+// handleRemove forgets watches on removed directories. A removed tree root
+// is waited for again.
+func (w *SessionWatcher) handleRemove(path string) {
+	w.mu.Lock()
+	if !w.watchedPaths[path] {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.watchedPaths, path)
+
+	var roots []*watchTree
+	for _, tree := range w.trees {
+		if tree.root == path {
+			roots = append(roots, tree)
+		}
+	}
+	w.pending = append(w.pending, roots...)
+	w.mu.Unlock()
+
+	if len(roots) > 0 {
+		w.watchAncestor(path)
+	}
+}
+
+// checkPending starts watching trees whose root has been created, and moves
+// the ancestor watch closer for those still missing
+func (w *SessionWatcher) checkPending() {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	for _, tree := range pending {
+		if _, err := os.Stat(tree.root); err == nil {
+			if err := w.addDirectory(tree, tree.root); err != nil {
+				w.countError("watch")
+				fmt.Fprintf(os.Stderr, "Failed to watch %s: %v\n", tree.root, err)
+			}
+			continue
+		}
+
+		w.mu.Lock()
+		w.pending = append(w.pending, tree)
+		w.mu.Unlock()
+		w.watchAncestor(tree.root)
+	}
+}
+
+// treeForFile returns the tree a session file belongs to, or nil
+func (w *SessionWatcher) treeForFile(path string) *watchTree {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.treeForFileLocked(path)
+}
+
+func (w *SessionWatcher) treeForFileLocked(path string) *watchTree {
+	for _, tree := range w.trees {
+		if tree.matches(path) {
+			return tree
+		}
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(dir string) string {
+	if strings.HasPrefix(dir, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, dir[2:])
+	}
+	return dir
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectLines records w's message events and returns a function reporting them by path
+func collectLines(t *testing.T, w *SessionWatcher) func() map[string][]Event {
+	t.Helper()
+
+	var mu sync.Mutex
+	events := make(map[string][]Event)
+	w.AddHandler(func(event Event) error {
+		if event.Type == "message" {
+			mu.Lock()
+			events[event.Path] = append(events[event.Path], event)
+			mu.Unlock()
+		}
+		return nil
+	})
+
+	return func() map[string][]Event {
+		mu.Lock()
+		defer mu.Unlock()
+		copied := make(map[string][]Event, len(events))
+		for path, list := range events {
+			copied[path] = append([]Event(nil), list...)
+		}
+		return copied
+	}
+}
+
+func startWatcher(t *testing.T, w *SessionWatcher) {
+	t.Helper()
+	if err := w.Start(); err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+	t.Cleanup(func() { w.Stop() })
+}
+
+// waitFor polls until cond holds or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
+
+func TestWatchTreeRecursive(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, ".claude", "projects")
+
+	// Existing session in an existing project
+	existing := filepath.Join(root, "-src-old")
+	os.MkdirAll(existing, 0755)
+	appendLine(t, filepath.Join(existing, "a.jsonl"), `{"sessionId":"a"}`)
+	os.WriteFile(filepath.Join(existing, "notes.txt"), []byte("ignored\n"), 0644)
+
+	w, err := NewSessionWatcher()
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	lines := collectLines(t, w)
+	if err := w.WatchTree(root, "*.jsonl", "claude-code"); err != nil {
+		t.Fatalf("WatchTree failed: %v", err)
+	}
+	startWatcher(t, w)
+
+	waitFor(t, "existing session", func() bool {
+		return len(lines()[filepath.Join(existing, "a.jsonl")]) == 1
+	})
+
+	// A project directory created after the watch started
+	project := filepath.Join(root, "-src-new")
+	os.MkdirAll(project, 0755)
+	session := filepath.Join(project, "b.jsonl")
+	appendLine(t, session, `{"sessionId":"b"}`)
+
+	waitFor(t, "session in new project", func() bool {
+		return len(lines()[session]) == 1
+	})
+
+	appendLine(t, session, `{"sessionId":"b","n":2}`)
+	waitFor(t, "appended line", func() bool {
+		return len(lines()[session]) == 2
+	})
+
+	got := lines()[session]
+	if got[0].Tool != "claude-code" || got[0].SessionID != "b" {
+		t.Errorf("Unexpected event: tool %q session %q", got[0].Tool, got[0].SessionID)
+	}
+	if got[1].Offset != int64(len(`{"sessionId":"b"}`)+1) {
+		t.Errorf("Expected second line at offset %d, got %d", len(`{"sessionId":"b"}`)+1, got[1].Offset)
+	}
+	if _, ok := lines()[filepath.Join(existing, "notes.txt")]; ok {
+		t.Errorf("File not matching the pattern was tailed")
+	}
+}
+
+func TestWatchTreeMissingRoot(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "not", "yet", "projects")
+
+	w, err := NewSessionWatcher()
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	lines := collectLines(t, w)
+	if err := w.WatchTree(root, "*.jsonl", "claude-code"); err != nil {
+		t.Fatalf("WatchTree should accept a missing root: %v", err)
+	}
+	startWatcher(t, w)
+
+	// Create the root one level at a time, as a first install would
+	os.Mkdir(filepath.Join(base, "not"), 0755)
+	time.Sleep(100 * time.Millisecond)
+	os.MkdirAll(filepath.Join(root, "-src-app"), 0755)
+	session := filepath.Join(root, "-src-app", "c.jsonl")
+	appendLine(t, session, `{"sessionId":"c"}`)
+
+	waitFor(t, "session under late root", func() bool {
+		return len(lines()[session]) == 1
+	})
+}
\ No newline at end of file
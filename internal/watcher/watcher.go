@@ -7,7 +7,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -17,8 +19,14 @@ import (
 type SessionWatcher struct {
 	watcher       *fsnotify.Watcher
 	watchedPaths  map[string]bool
+	trees         []*watchTree
+	pending       []*watchTree // trees whose root does not exist yet
+	checkpoints   *CheckpointStore
 	activeSessions map[string]*SessionTail
 	handlers      []EventHandler
+	paused        atomic.Bool
+	errorCounts   map[string]int64 // by kind, guarded by errorsMu
+	errorsMu      sync.Mutex
 	mu            sync.RWMutex
 	stopCh        chan struct{}
 	wg            sync.WaitGroup
@@ -30,9 +38,12 @@ type SessionTail struct {
 	File       *os.File
 	Reader     *bufio.Reader
 	LastOffset int64
+	Inode      uint64
 	SessionID  string
 	Tool       string
 	StartTime  time.Time
+
+	readMu sync.Mutex // serializes reads from the tail loop and write events
 }
 
 // Event represents a captured event from a session file
@@ -44,6 +55,7 @@ type Event struct {
 	Timestamp time.Time              `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
 	RawLine   []byte                 `json:"-"`
+	Offset    int64                  `json:"offset"` // Byte offset of RawLine in the file
 }
 
 // EventHandler processes captured events
@@ -61,10 +73,19 @@ func NewSessionWatcher() (*SessionWatcher, error) {
 		watchedPaths:   make(map[string]bool),
 		activeSessions: make(map[string]*SessionTail),
 		handlers:       []EventHandler{},
+		errorCounts:    make(map[string]int64),
 		stopCh:         make(chan struct{}),
 	}, nil
 }
 
+// SetCheckpoints makes the watcher resume files from their saved offsets.
+// Handlers commit lines to the store once they are safely written.
+func (w *SessionWatcher) SetCheckpoints(checkpoints *CheckpointStore) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checkpoints = checkpoints
+}
+
 // AddHandler adds an event handler
 func (w *SessionWatcher) AddHandler(handler EventHandler) {
 	w.mu.Lock()
@@ -72,62 +93,46 @@ func (w *SessionWatcher) AddHandler(handler EventHandler) {
 	w.handlers = append(w.handlers, handler)
 }
 
-// WatchDirectory adds a directory to watch for session files
+// WatchDirectory adds a directory to watch for session files. Only files
+// directly inside dir are tailed; see WatchTree for nested layouts.
 func (w *SessionWatcher) WatchDirectory(dir string, pattern string) error {
-	// Expand home directory
-	if dir[:2] == "~/" {
-		home, _ := os.UserHomeDir()
-		dir = filepath.Join(home, dir[2:])
-	}
-
-	// Check if directory exists
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return fmt.Errorf("directory does not exist: %s", dir)
-	}
-
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	// Add to watcher if not already watching
-	if !w.watchedPaths[dir] {
-		if err := w.watcher.Add(dir); err != nil {
-			return fmt.Errorf("failed to watch directory %s: %w", dir, err)
-		}
-		w.watchedPaths[dir] = true
-	}
-
-	// Scan for existing session files
-	matches, err := filepath.Glob(filepath.Join(dir, pattern))
-	if err != nil {
-		return fmt.Errorf("failed to scan directory: %w", err)
-	}
-
-	for _, path := range matches {
-		if err := w.startTailing(path); err != nil {
-			// Log error but continue with other files
-			fmt.Fprintf(os.Stderr, "Failed to tail %s: %v\n", path, err)
-		}
-	}
+	return w.addTree(&watchTree{root: expandHome(dir), pattern: pattern})
+}
 
-	return nil
+// WatchTree watches a directory tree for session files matching pattern,
+// adding watches for subdirectories as they appear. The root does not need to
+// exist yet. Events from the tree are attributed to tool.
+func (w *SessionWatcher) WatchTree(root, pattern, tool string) error {
+	return w.addTree(&watchTree{root: expandHome(root), pattern: pattern, tool: tool, recursive: true})
 }
 
-// WatchClaudeCodeSessions watches for Claude Code session files
+// WatchClaudeCodeSessions watches Claude Code's project tree
 func (w *SessionWatcher) WatchClaudeCodeSessions() error {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	// Claude Code stores sessions in ~/.claude-code/sessions/
-	sessionDir := filepath.Join(home, ".claude-code", "sessions")
+	// Claude Code stores sessions in ~/.claude/projects/<encoded-path>/
+	return w.WatchTree(filepath.Join(home, ".claude", "projects"), "*.jsonl", "claude-code")
+}
+
+// Pause stops reading session files. Files keep being tracked, and what was
+// written while paused is read on Resume.
+func (w *SessionWatcher) Pause() {
+	w.paused.Store(true)
+}
 
-	// Create directory if it doesn't exist (for new installations)
-	if err := os.MkdirAll(sessionDir, 0755); err != nil {
-		return fmt.Errorf("failed to create session directory: %w", err)
+// Resume reads session files again, starting with what was written while paused
+func (w *SessionWatcher) Resume() {
+	if w.paused.Swap(false) {
+		w.checkAllSessions()
 	}
+}
 
-	return w.WatchDirectory(sessionDir, "*.jsonl")
+// Paused reports whether the watcher is paused
+func (w *SessionWatcher) Paused() bool {
+	return w.paused.Load()
 }
 
 // Start begins watching for file changes
@@ -175,18 +180,17 @@ func (w *SessionWatcher) watchLoop() {
 			if event.Op&fsnotify.Write == fsnotify.Write {
 				w.handleFileWrite(event.Name)
 			} else if event.Op&fsnotify.Create == fsnotify.Create {
-				// Check if it's a JSONL file
-				if filepath.Ext(event.Name) == ".jsonl" {
-					w.startTailing(event.Name)
-				}
-			} else if event.Op&fsnotify.Remove == fsnotify.Remove {
-				w.stopTailing(event.Name)
+				w.handleCreate(event.Name)
+			} else if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				w.stopTailing(event.Name, true)
+				w.handleRemove(event.Name)
 			}
 
 		case err, ok := <-w.watcher.Errors:
 			if !ok {
 				return
 			}
+			w.countError("fsnotify")
 			fmt.Fprintf(os.Stderr, "Watcher error: %v\n", err)
 		}
 	}
@@ -217,54 +221,68 @@ func (w *SessionWatcher) handleFileWrite(path string) {
 
 	if exists {
 		w.readNewLines(session)
+		return
 	}
-}
 
-// startTailing begins tailing a session file
-func (w *SessionWatcher) startTailing(path string) error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	// Check if already tailing
-	if _, exists := w.activeSessions[path]; exists {
-		return nil
+	// A file created before its directory was watched shows up on first write
+	if tree := w.treeForFile(path); tree != nil {
+		w.startTailing(path, tree.tool)
 	}
+}
 
+// startTailing begins tailing a session file. An empty tool is detected from the path.
+func (w *SessionWatcher) startTailing(path, tool string) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
-
-	// Get file info to start from end
 	info, err := file.Stat()
 	if err != nil {
 		file.Close()
 		return fmt.Errorf("failed to stat file: %w", err)
 	}
 
+	w.mu.Lock()
+
+	// Check if already tailing. A file replaced under the same name is
+	// tailed again from its start.
+	if existing, exists := w.activeSessions[path]; exists {
+		if existing.Inode == fileInode(info) {
+			w.mu.Unlock()
+			file.Close()
+			return nil
+		}
+		existing.readMu.Lock()
+		existing.File.Close()
+		existing.readMu.Unlock()
+		delete(w.activeSessions, path)
+		if w.checkpoints != nil {
+			w.checkpoints.Reset(path, info)
+		}
+	}
+
+	// Resume after what was captured before, or start from the beginning
+	var offset int64
+	if w.checkpoints != nil {
+		offset = w.checkpoints.Resume(path, info)
+	}
+
 	session := &SessionTail{
 		Path:       path,
 		File:       file,
 		Reader:     bufio.NewReader(file),
-		LastOffset: 0, // Start from beginning for existing files
-		Tool:       detectToolFromPath(path),
+		LastOffset: offset,
+		Inode:      fileInode(info),
+		Tool:       tool,
 		StartTime:  time.Now(),
 	}
-
-	// For new files, start from end
-	if info.Size() == 0 {
-		session.LastOffset = 0
-	} else {
-		// Read existing content first
-		w.mu.Unlock()
-		w.readNewLines(session)
-		w.mu.Lock()
-		session.LastOffset = info.Size()
+	if session.Tool == "" {
+		session.Tool = detectToolFromPath(path)
 	}
-
 	w.activeSessions[path] = session
+	w.mu.Unlock()
 
-	// Send session start event
+	// Handlers run without the lock held so they may call back into the watcher
 	event := Event{
 		Type:      "session_start",
 		Tool:      session.Tool,
@@ -273,17 +291,30 @@ func (w *SessionWatcher) startTailing(path string) error {
 	}
 	w.notifyHandlers(event)
 
+	// Read existing content
+	w.readNewLines(session)
+
 	return nil
 }
 
-// stopTailing stops tailing a session file
-func (w *SessionWatcher) stopTailing(path string) {
+// stopTailing stops tailing a session file. The checkpoint of a file that is
+// gone is forgotten; one that is only no longer watched keeps it.
+func (w *SessionWatcher) stopTailing(path string, removed bool) {
 	w.mu.Lock()
-	defer w.mu.Unlock()
+	session, exists := w.activeSessions[path]
+	if exists {
+		delete(w.activeSessions, path)
+	}
+	w.mu.Unlock()
 
-	if session, exists := w.activeSessions[path]; exists {
+	if exists {
+		session.readMu.Lock()
 		session.File.Close()
-		delete(w.activeSessions, path)
+		session.readMu.Unlock()
+
+		if removed && w.checkpoints != nil {
+			w.checkpoints.Forget(path)
+		}
 
 		// Send session end event
 		event := Event{
@@ -313,6 +344,26 @@ func (w *SessionWatcher) checkAllSessions() {
 
 // readNewLines reads new lines from a session file
 func (w *SessionWatcher) readNewLines(session *SessionTail) {
+	if w.paused.Load() {
+		return
+	}
+
+	session.readMu.Lock()
+	defer session.readMu.Unlock()
+
+	// A file that shrank was truncated or rewritten: read it again from the start
+	if info, err := session.File.Stat(); err == nil {
+		if info.Size() < session.LastOffset {
+			session.LastOffset = 0
+			if w.checkpoints != nil {
+				w.checkpoints.Reset(session.Path, info)
+			}
+		}
+		if w.checkpoints != nil {
+			w.checkpoints.Observe(session.Path, info.Size())
+		}
+	}
+
 	// Seek to last known position
 	session.File.Seek(session.LastOffset, 0)
 	session.Reader.Reset(session.File)
@@ -321,12 +372,14 @@ func (w *SessionWatcher) readNewLines(session *SessionTail) {
 		line, err := session.Reader.ReadBytes('\n')
 		if err != nil {
 			if err != io.EOF {
+				w.countError("read")
 				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", session.Path, err)
 			}
 			break
 		}
 
 		// Update offset
+		offset := session.LastOffset
 		session.LastOffset += int64(len(line))
 
 		// Parse and emit event
@@ -337,6 +390,7 @@ func (w *SessionWatcher) readNewLines(session *SessionTail) {
 			Path:      session.Path,
 			Timestamp: time.Now(),
 			RawLine:   line,
+			Offset:    offset,
 		}
 
 		// Try to parse as JSON
@@ -345,8 +399,11 @@ func (w *SessionWatcher) readNewLines(session *SessionTail) {
 			event.Data = data
 
 			// Extract session ID if available
-			if sid, ok := data["sessionId"].(string); ok && session.SessionID == "" {
-				session.SessionID = sid
+			if sid, ok := data["sessionId"].(string); ok && sid != "" {
+				if session.SessionID == "" {
+					session.SessionID = sid
+				}
+				event.SessionID = sid
 			}
 		}
 
@@ -363,6 +420,7 @@ func (w *SessionWatcher) notifyHandlers(event Event) {
 
 	for _, handler := range handlers {
 		if err := handler(event); err != nil {
+			w.countError("handler")
 			fmt.Fprintf(os.Stderr, "Handler error: %v\n", err)
 		}
 	}
@@ -372,6 +430,10 @@ func (w *SessionWatcher) notifyHandlers(event Event) {
 func detectToolFromPath(path string) string {
 	dir := filepath.Dir(path)
 
+	if strings.Contains(filepath.ToSlash(path), "/.claude/projects/") {
+		return "claude-code"
+	}
+
 	if filepath.Base(dir) == "sessions" && filepath.Base(filepath.Dir(dir)) == ".claude-code" {
 		return "claude-code"
 	}
@@ -384,18 +446,44 @@ func detectToolFromPath(path string) string {
 	return "unknown"
 }
 
+// countError records an error of the given kind
+func (w *SessionWatcher) countError(kind string) {
+	w.errorsMu.Lock()
+	defer w.errorsMu.Unlock()
+	w.errorCounts[kind]++
+}
+
+// ErrorCounts returns how many errors of each kind the watcher has hit
+func (w *SessionWatcher) ErrorCounts() map[string]int64 {
+	w.errorsMu.Lock()
+	defer w.errorsMu.Unlock()
+
+	counts := make(map[string]int64, len(w.errorCounts))
+	for kind, n := range w.errorCounts {
+		counts[kind] = n
+	}
+	return counts
+}
+
 // GetActiveSessions returns information about active sessions
 func (w *SessionWatcher) GetActiveSessions() []SessionInfo {
 	w.mu.RLock()
-	defer w.mu.RUnlock()
-
-	sessions := make([]SessionInfo, 0, len(w.activeSessions))
+	tails := make([]*SessionTail, 0, len(w.activeSessions))
 	for _, session := range w.activeSessions {
+		tails = append(tails, session)
+	}
+	w.mu.RUnlock()
+
+	// Tails are read without w.mu held, since readers notify handlers under readMu
+	sessions := make([]SessionInfo, 0, len(tails))
+	for _, session := range tails {
+		session.readMu.Lock()
 		info := SessionInfo{
 			Path:      session.Path,
 			Tool:      session.Tool,
 			SessionID: session.SessionID,
 			StartTime: session.StartTime,
+			Offset:    session.LastOffset,
 			Active:    true,
 		}
 
@@ -403,6 +491,7 @@ func (w *SessionWatcher) GetActiveSessions() []SessionInfo {
 		if fi, err := session.File.Stat(); err == nil {
 			info.Size = fi.Size()
 		}
+		session.readMu.Unlock()
 
 		sessions = append(sessions, info)
 	}
@@ -417,5 +506,6 @@ type SessionInfo struct {
 	SessionID string    `json:"session_id"`
 	StartTime time.Time `json:"start_time"`
 	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"` // Bytes read so far
 	Active    bool      `json:"active"`
 }
\ No newline at end of file
@@ -0,0 +1,155 @@
+//go:build integration && unix
+
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildMem builds the mem binary into a temp directory
+func buildMem(t *testing.T) string {
+	t.Helper()
+
+	bin := filepath.Join(t.TempDir(), "mem")
+	build := exec.Command("go", "build", "-o", bin, "../../cmd/mem")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build mem: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// runMem runs mem with HOME set to home and returns its combined output
+func runMem(t *testing.T, bin, home string, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command(bin, args...)
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"))
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestDaemonLifecycleIntegration(t *testing.T) {
+	// Skip if running short tests
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	bin := buildMem(t)
+	home := t.TempDir()
+	project := filepath.Join(home, ".claude", "projects", "-src-app")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runMem(t, bin, home, "daemon", "start", "-b")
+	if err != nil {
+		t.Fatalf("daemon start failed: %v\n%s", err, out)
+	}
+	t.Cleanup(func() { runMem(t, bin, home, "daemon", "stop") })
+
+	// The starting process has exited; the daemon must outlive it
+	out, err = runMem(t, bin, home, "daemon", "status")
+	if err != nil || !strings.Contains(out, "Daemon status: running") {
+		t.Fatalf("Expected running daemon, got: %v\n%s", err, out)
+	}
+
+	// A second daemon is refused while the first holds the lock
+	out, err = runMem(t, bin, home, "daemon", "start", "-b")
+	if err == nil || !strings.Contains(out, "already running") {
+		t.Errorf("Expected second start to fail as already running, got: %v\n%s", err, out)
+	}
+
+	// A session written after startup is captured and ingested
+	line := `{"type":"user","sessionId":"s1","cwd":"/src/app","timestamp":"2026-01-01T10:00:00Z","message":{"role":"user","content":"daemonized capture works"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(project, "s1.jsonl"), []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		out, _ = runMem(t, bin, home, "search", "--all", "daemonized")
+		if strings.Contains(out, "daemonized capture works") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Session was not ingested by the daemon:\n%s", out)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	out, err = runMem(t, bin, home, "daemon", "stop")
+	if err != nil || !strings.Contains(out, "Daemon stopped") {
+		t.Fatalf("daemon stop failed: %v\n%s", err, out)
+	}
+
+	out, err = runMem(t, bin, home, "daemon", "status")
+	if err != nil || !strings.Contains(out, "Daemon status: stopped") {
+		t.Errorf("Expected stopped daemon, got: %v\n%s", err, out)
+	}
+
+	logData, err := os.ReadFile(filepath.Join(home, ".ai-memory", "daemon.log"))
+	if err != nil || !strings.Contains(string(logData), "shutting down") {
+		t.Errorf("Expected shutdown in daemon log, got: %v\n%s", err, logData)
+	}
+}
+
+func TestDaemonStaleStatusIntegration(t *testing.T) {
+	// Skip if running short tests
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	bin := buildMem(t)
+	home := t.TempDir()
+	auditDir := filepath.Join(home, ".ai-memory", "audit")
+	os.MkdirAll(auditDir, 0755)
+
+	// Files left by a crashed daemon whose PID now belongs to another process
+	os.WriteFile(filepath.Join(auditDir, "daemon.pid"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(auditDir, "daemon.status"), []byte(`{"pid":1,"status":"running"}`), 0644)
+
+	out, err := runMem(t, bin, home, "daemon", "status")
+	if err != nil || !strings.Contains(out, "Daemon status: stopped") {
+		t.Errorf("Expected stale status to read as stopped, got: %v\n%s", err, out)
+	}
+
+	out, err = runMem(t, bin, home, "daemon", "stop")
+	if err != nil || !strings.Contains(out, "not running") {
+		t.Errorf("Expected stop to report not running, got: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(auditDir, "daemon.pid")); !os.IsNotExist(err) {
+		t.Errorf("Expected stale PID file to be removed")
+	}
+}
+
+func TestDaemonInstallIntegration(t *testing.T) {
+	// Skip if running short tests
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	bin := buildMem(t)
+	home := t.TempDir()
+
+	out, err := runMem(t, bin, home, "daemon", "install", "--systemd")
+	if err != nil {
+		t.Fatalf("daemon install failed: %v\n%s", err, out)
+	}
+
+	unit, err := os.ReadFile(filepath.Join(home, ".config", "systemd", "user", "mem-daemon.service"))
+	if err != nil {
+		t.Fatalf("Unit file not written: %v", err)
+	}
+	if !strings.Contains(string(unit), "ExecStart="+bin+" daemon start\n") {
+		t.Errorf("Unexpected unit:\n%s", unit)
+	}
+
+	out, err = runMem(t, bin, home, "daemon", "install", "--launchd", "--print")
+	if err != nil || !strings.Contains(out, "<string>"+bin+"</string>") {
+		t.Errorf("Unexpected launchd plist: %v\n%s", err, out)
+	}
+}
\ No newline at end of file
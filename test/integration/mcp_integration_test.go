@@ -0,0 +1,92 @@
+//go:build integration && unix
+
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMCPServerIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	bin := buildMem(t)
+	home := t.TempDir()
+
+	cmd := exec.Command(bin, "mcp")
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"))
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start mem mcp: %v", err)
+	}
+	responses := bufio.NewScanner(stdout)
+
+	// call sends a request and returns the result of its response
+	call := func(id int, method, params string) map[string]interface{} {
+		t.Helper()
+		line := `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"` + method + `","params":` + params + "}\n"
+		if _, err := stdin.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to send %s: %v", method, err)
+		}
+		if !responses.Scan() {
+			t.Fatalf("No response to %s: %v\n%s", method, responses.Err(), stderr.String())
+		}
+		var resp struct {
+			ID     int
+			Result map[string]interface{}
+			Error  map[string]interface{}
+		}
+		if err := json.Unmarshal(responses.Bytes(), &resp); err != nil {
+			t.Fatalf("Response to %s is not JSON: %q", method, responses.Text())
+		}
+		if resp.ID != id || resp.Error != nil {
+			t.Fatalf("Unexpected response to %s: %s", method, responses.Text())
+		}
+		return resp.Result
+	}
+
+	init := call(1, "initialize", `{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}`)
+	if init["protocolVersion"] != "2025-06-18" {
+		t.Errorf("Unexpected initialize result %v", init)
+	}
+	stdin.Write([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"))
+
+	saved := call(2, "tools/call", `{"name":"save_note","arguments":{"content":"Rotate the audit shards nightly"}}`)
+	if saved["isError"] == true {
+		t.Fatalf("save_note failed: %v", saved)
+	}
+
+	found := call(3, "tools/call", `{"name":"search_conversations","arguments":{"query":"shards"}}`)
+	text := found["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "Rotate the audit shards nightly") {
+		t.Errorf("Expected the note to be found, got %s", text)
+	}
+
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("Expected mem mcp to exit cleanly when stdin closes: %v\n%s", err, stderr.String())
+	}
+
+	// The note is in the default database
+	out, err := runMem(t, bin, home, "list", "--all")
+	if err != nil || !strings.Contains(out, "Rotate the audit shards nightly") {
+		t.Errorf("Expected the note in mem list, got %v:\n%s", err, out)
+	}
+}
\ No newline at end of file